@@ -0,0 +1,247 @@
+// Package analytics keeps approximate per-key check and denial counters in minute and hour buckets,
+// to find the hottest and most throttled keys over the last 5 minutes, hour or day.
+//
+// A Recorder observes the limiter decisions, aggregates them in memory and flushes them to a Store
+// periodically. Each bucket only keeps its top keys, so counts of rare keys are approximate
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// maxPendingKeys bounds the keys aggregated between two flushes, further keys are dropped
+const maxPendingKeys = 100000
+
+// Counts are the checks and denials of a key
+type Counts struct {
+	Checks int64
+	Denied int64
+}
+
+// KeyCount is a key and its count in a window
+type KeyCount struct {
+	Key   string `json:"key" example:"your_api_key:gpt-4"`
+	Count int64  `json:"count" example:"1200"`
+}
+
+// Window is a time window counters are summed over
+type Window struct {
+	Name    string
+	bucket  time.Duration // Bucket size, a minute or an hour
+	buckets int           // Number of buckets summed, including the current one
+}
+
+var windows = map[string]Window{
+	"5m":  {Name: "5m", bucket: time.Minute, buckets: 5},
+	"1h":  {Name: "1h", bucket: time.Minute, buckets: 60},
+	"24h": {Name: "24h", bucket: time.Hour, buckets: 24},
+}
+
+// ParseWindow parses one of the supported windows: 5m, 1h, 24h
+func ParseWindow(s string) (Window, error) {
+	w, ok := windows[s]
+	if !ok {
+		return Window{}, fmt.Errorf("unsupported window %q, must be 5m, 1h or 24h", s)
+	}
+	return w, nil
+}
+
+// bucketStarts returns the start of the buckets of w ending with the one holding now
+func (w Window) bucketStarts(now time.Time) []int64 {
+	current := now.Truncate(w.bucket)
+	starts := make([]int64, 0, w.buckets)
+	for i := 0; i < w.buckets; i++ {
+		starts = append(starts, current.Add(-time.Duration(i)*w.bucket).Unix())
+	}
+	return starts
+}
+
+// Store persists counters in minute and hour buckets
+type Store interface {
+	// Add adds counts to the minute and hour buckets holding t
+	Add(ctx context.Context, t time.Time, counts map[string]Counts) error
+
+	// Top returns the n keys with the most checks and with the most denials in w
+	Top(ctx context.Context, w Window, now time.Time, n int) (checks, denied []KeyCount, err error)
+}
+
+// Recorder aggregates decisions in memory and flushes them to its Store
+type Recorder struct {
+	store   Store
+	maxKeys int
+	now     func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*Counts
+	dropped int64
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewRecorder creates a Recorder flushing to store every cfg.FlushInterval. Call Close to flush
+// the remaining counts
+func NewRecorder(store Store, cfg *config.AnalyticsConfig) *Recorder {
+	interval := cfg.FlushInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	r := &Recorder{
+		store:   store,
+		maxKeys: int(cfg.MaxKeysPerBucket),
+		now:     time.Now,
+		pending: make(map[string]*Counts),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go r.run(interval)
+	return r
+}
+
+// Observe counts a decision, it is a limiter.Observer
+func (r *Recorder) Observe(_ context.Context, d limiter.Decision) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.pending[d.Key]
+	if !ok {
+		if len(r.pending) >= maxPendingKeys {
+			r.dropped++
+			return
+		}
+		c = &Counts{}
+		r.pending[d.Key] = c
+	}
+	c.Checks++
+	if !d.Allowed {
+		c.Denied++
+	}
+}
+
+// Top returns the n keys with the most checks and with the most denials in w.
+// Counts not flushed yet are not included
+func (r *Recorder) Top(ctx context.Context, w Window, n int) (checks, denied []KeyCount, err error) {
+	return r.store.Top(ctx, w, r.now(), n)
+}
+
+// TopWithPrefix returns the n keys starting with prefix with the most checks and with the most
+// denials in w, e.g. the keys of a namespace. They are picked among the keys the buckets retain
+func (r *Recorder) TopWithPrefix(ctx context.Context, w Window, prefix string, n int) (checks, denied []KeyCount, err error) {
+	if prefix == "" {
+		return r.Top(ctx, w, n)
+	}
+
+	limit := r.maxKeys
+	if limit < n {
+		limit = n
+	}
+	checks, denied, err = r.store.Top(ctx, w, r.now(), limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return filterPrefix(checks, prefix, n), filterPrefix(denied, prefix, n), nil
+}
+
+func filterPrefix(counts []KeyCount, prefix string, n int) []KeyCount {
+	filtered := make([]KeyCount, 0, n)
+	for _, kc := range counts {
+		if strings.HasPrefix(kc.Key, prefix) {
+			filtered = append(filtered, kc)
+			if len(filtered) == n {
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// Close stops the flusher and flushes the remaining counts
+func (r *Recorder) Close() {
+	r.once.Do(func() {
+		close(r.stop)
+		<-r.done
+	})
+}
+
+func (r *Recorder) run(interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			r.flush()
+			return
+		case <-ticker.C:
+			r.flush()
+		}
+	}
+}
+
+// flush writes the pending counts to the store. Counts are lost if the write fails
+func (r *Recorder) flush() {
+	r.mu.Lock()
+	pending, dropped := r.pending, r.dropped
+	r.pending = make(map[string]*Counts, len(pending))
+	r.dropped = 0
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		logger.Warn("Analytics dropped decisions, too many keys between flushes",
+			logger.Int64("dropped", dropped),
+		)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	counts := make(map[string]Counts, len(pending))
+	for key, c := range pending {
+		counts[key] = *c
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := r.store.Add(ctx, r.now(), counts); err != nil {
+		logger.Error("Failed to flush analytics",
+			logger.Int("keys", len(counts)),
+			logger.ErrorField(err),
+		)
+		return
+	}
+	logger.Debug("Analytics flushed", logger.Int("keys", len(counts)))
+}
+
+// topN returns the n entries of counts with the highest counts, ties ordered by key
+func topN(counts map[string]int64, n int) []KeyCount {
+	top := make([]KeyCount, 0, len(counts))
+	for key, count := range counts {
+		if count > 0 {
+			top = append(top, KeyCount{Key: key, Count: count})
+		}
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Key < top[j].Key
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
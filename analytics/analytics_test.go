@@ -0,0 +1,129 @@
+package analytics
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+)
+
+// stores returns a memory and a Redis store keeping the top maxKeys keys of each bucket
+func stores(t *testing.T, maxKeys int64) (map[string]Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return map[string]Store{"memory": NewMemoryStore(maxKeys), "redis": NewRedisStore(client, maxKeys)}, mr
+}
+
+// record observes checks of key, denied ones after allowed ones, flushed at t
+func record(r *Recorder, now *time.Time, t time.Time, key string, allowed, denied int) {
+	*now = t
+	for i := 0; i < allowed+denied; i++ {
+		r.Observe(context.Background(), limiter.Decision{Key: key, Allowed: i < allowed})
+	}
+	r.flush()
+}
+
+func TestTop(t *testing.T) {
+	backends, mr := stores(t, 100)
+	for name, store := range backends {
+		t.Run(name, func(t *testing.T) {
+			var now time.Time
+			r := NewRecorder(store, &config.AnalyticsConfig{FlushInterval: time.Hour, MaxKeysPerBucket: 100})
+			defer r.Close()
+			r.now = func() time.Time { return now }
+
+			end := time.Unix(1700000000, 0)                          // 22:13:20 UTC
+			record(r, &now, end.Add(-3*time.Hour), "old", 100, 100)  // in 24h only
+			record(r, &now, end.Add(-30*time.Minute), "hour", 50, 0) // in 1h and 24h
+			record(r, &now, end.Add(-2*time.Minute), "a", 3, 2)      // in every window
+			record(r, &now, end.Add(-1*time.Minute), "a", 4, 0)      // summed with the minute before
+			record(r, &now, end.Add(-1*time.Minute), "b", 5, 3)
+			record(r, &now, end, "c", 1, 0)
+			record(r, &now, end, "ns:team:a", 6, 1)
+			record(r, &now, end, "ns:team:b", 2, 4)
+			now = end
+
+			tests := []struct {
+				window string
+				n      int
+				checks []KeyCount
+				denied []KeyCount
+			}{
+				{window: "5m", n: 3,
+					checks: []KeyCount{{"a", 9}, {"b", 8}, {"ns:team:a", 7}},
+					denied: []KeyCount{{"ns:team:b", 4}, {"b", 3}, {"a", 2}}},
+				{window: "1h", n: 2,
+					checks: []KeyCount{{"hour", 50}, {"a", 9}},
+					denied: []KeyCount{{"ns:team:b", 4}, {"b", 3}}},
+				{window: "24h", n: 1,
+					checks: []KeyCount{{"old", 200}},
+					denied: []KeyCount{{"old", 100}}},
+			}
+			for _, tt := range tests {
+				w, err := ParseWindow(tt.window)
+				if err != nil {
+					t.Fatal(err)
+				}
+				checks, denied, err := r.Top(context.Background(), w, tt.n)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(checks, tt.checks) || !reflect.DeepEqual(denied, tt.denied) {
+					t.Errorf("%s: got %v and %v, want %v and %v", tt.window, checks, denied, tt.checks, tt.denied)
+				}
+			}
+
+			// The keys of a namespace, among the keys the buckets retain
+			w, _ := ParseWindow("5m")
+			checks, denied, err := r.TopWithPrefix(context.Background(), w, "ns:team:", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []KeyCount{{"ns:team:a", 7}}; !reflect.DeepEqual(checks, want) {
+				t.Errorf("checks with prefix: got %v, want %v", checks, want)
+			}
+			if want := []KeyCount{{"ns:team:b", 4}}; !reflect.DeepEqual(denied, want) {
+				t.Errorf("denied with prefix: got %v, want %v", denied, want)
+			}
+			if checks, _, _ := r.TopWithPrefix(context.Background(), w, "ns:other:", 10); len(checks) != 0 {
+				t.Errorf("prefix without keys: got %v", checks)
+			}
+		})
+	}
+
+	for _, key := range mr.Keys() {
+		if strings.Contains(key, "{") || strings.Contains(key, "tmp") {
+			t.Errorf("Redis key %s: want bucket keys only, without a hash tag", key)
+		}
+	}
+}
+
+func TestMaxKeysPerBucket(t *testing.T) {
+	backends, _ := stores(t, 2)
+	for name, store := range backends {
+		t.Run(name, func(t *testing.T) {
+			now := time.Unix(1700000000, 0)
+			if err := store.Add(context.Background(), now, map[string]Counts{
+				"a": {Checks: 3}, "b": {Checks: 2}, "c": {Checks: 1},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			w, _ := ParseWindow("5m")
+			checks, _, err := store.Top(context.Background(), w, now, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []KeyCount{{"a", 3}, {"b", 2}}; !reflect.DeepEqual(checks, want) {
+				t.Errorf("got %v, want the top 2 keys only", checks)
+			}
+		})
+	}
+}
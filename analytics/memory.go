@@ -0,0 +1,110 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps buckets in the current process, for the memory backend
+type MemoryStore struct {
+	maxKeys int64
+
+	mu      sync.Mutex
+	minutes map[int64]*memoryBucket
+	hours   map[int64]*memoryBucket
+}
+
+type memoryBucket struct {
+	checks map[string]int64
+	denied map[string]int64
+}
+
+// NewMemoryStore creates a store keeping the top maxKeys keys of each bucket
+func NewMemoryStore(maxKeys int64) *MemoryStore {
+	return &MemoryStore{
+		maxKeys: maxKeys,
+		minutes: make(map[int64]*memoryBucket),
+		hours:   make(map[int64]*memoryBucket),
+	}
+}
+
+// Add adds counts to the minute and hour buckets holding t and drops expired buckets
+func (s *MemoryStore) Add(_ context.Context, t time.Time, counts map[string]Counts) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(s.minutes, t.Truncate(time.Minute).Unix(), counts)
+	s.add(s.hours, t.Truncate(time.Hour).Unix(), counts)
+
+	expire(s.minutes, t.Add(-minuteRetention).Unix())
+	expire(s.hours, t.Add(-hourRetention).Unix())
+	return nil
+}
+
+func (s *MemoryStore) add(buckets map[int64]*memoryBucket, start int64, counts map[string]Counts) {
+	b, ok := buckets[start]
+	if !ok {
+		b = &memoryBucket{checks: make(map[string]int64), denied: make(map[string]int64)}
+		buckets[start] = b
+	}
+	for key, c := range counts {
+		b.checks[key] += c.Checks
+		if c.Denied > 0 {
+			b.denied[key] += c.Denied
+		}
+	}
+	s.trim(b.checks)
+	s.trim(b.denied)
+}
+
+// trim keeps the top maxKeys keys of counts
+func (s *MemoryStore) trim(counts map[string]int64) {
+	if s.maxKeys <= 0 || int64(len(counts)) <= s.maxKeys {
+		return
+	}
+	keep := make(map[string]bool, s.maxKeys)
+	for _, kc := range topN(counts, int(s.maxKeys)) {
+		keep[kc.Key] = true
+	}
+	for key := range counts {
+		if !keep[key] {
+			delete(counts, key)
+		}
+	}
+}
+
+func expire(buckets map[int64]*memoryBucket, before int64) {
+	for start := range buckets {
+		if start < before {
+			delete(buckets, start)
+		}
+	}
+}
+
+// Top sums the buckets of w and returns the n keys with the highest counts
+func (s *MemoryStore) Top(_ context.Context, w Window, now time.Time, n int) (checks, denied []KeyCount, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets := s.minutes
+	if w.bucket == time.Hour {
+		buckets = s.hours
+	}
+
+	checksSum := make(map[string]int64)
+	deniedSum := make(map[string]int64)
+	for _, start := range w.bucketStarts(now) {
+		b, ok := buckets[start]
+		if !ok {
+			continue
+		}
+		for key, c := range b.checks {
+			checksSum[key] += c
+		}
+		for key, c := range b.denied {
+			deniedSum[key] += c
+		}
+	}
+	return topN(checksSum, n), topN(deniedSum, n), nil
+}
@@ -0,0 +1,111 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Bucket retention, a bit longer than the longest window using the bucket size
+const (
+	minuteRetention = 65 * time.Minute
+	hourRetention   = 25 * time.Hour
+)
+
+// RedisStore keeps buckets in sorted sets scored by count, e.g. analytics:checks:m:<unix minute>.
+// Windows are summed in the process rather than with ZUNIONSTORE, so the buckets do not need a
+// common hash tag and spread over the nodes of a cluster
+type RedisStore struct {
+	client  redis.Cmdable
+	maxKeys int64
+}
+
+// NewRedisStore creates a store keeping the top maxKeys keys of each bucket
+func NewRedisStore(client redis.Cmdable, maxKeys int64) *RedisStore {
+	return &RedisStore{client: client, maxKeys: maxKeys}
+}
+
+func bucketKey(metric string, bucket time.Duration, start int64) string {
+	unit := "m"
+	if bucket == time.Hour {
+		unit = "h"
+	}
+	return fmt.Sprintf("analytics:%s:%s:%d", metric, unit, start)
+}
+
+// Add adds counts to the minute and hour buckets holding t in one pipeline
+func (s *RedisStore) Add(ctx context.Context, t time.Time, counts map[string]Counts) error {
+	buckets := []struct {
+		size      time.Duration
+		retention time.Duration
+	}{
+		{time.Minute, minuteRetention},
+		{time.Hour, hourRetention},
+	}
+
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, b := range buckets {
+			start := t.Truncate(b.size).Unix()
+			checksKey := bucketKey("checks", b.size, start)
+			deniedKey := bucketKey("denied", b.size, start)
+
+			hasDenied := false
+			for key, c := range counts {
+				pipe.ZIncrBy(ctx, checksKey, float64(c.Checks), key)
+				if c.Denied > 0 {
+					pipe.ZIncrBy(ctx, deniedKey, float64(c.Denied), key)
+					hasDenied = true
+				}
+			}
+
+			zsets := []string{checksKey}
+			if hasDenied {
+				zsets = append(zsets, deniedKey)
+			}
+			for _, zset := range zsets {
+				// Keep the top keys only, lowest scores come first
+				if s.maxKeys > 0 {
+					pipe.ZRemRangeByRank(ctx, zset, 0, -s.maxKeys-1)
+				}
+				pipe.Expire(ctx, zset, b.retention)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add analytics counts: %w", err)
+	}
+	return nil
+}
+
+// Top reads the buckets of w in one pipeline and returns the n keys with the highest summed counts
+func (s *RedisStore) Top(ctx context.Context, w Window, now time.Time, n int) (checks, denied []KeyCount, err error) {
+	starts := w.bucketStarts(now)
+	checksCmds := make([]*redis.ZSliceCmd, 0, len(starts))
+	deniedCmds := make([]*redis.ZSliceCmd, 0, len(starts))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, start := range starts {
+			checksCmds = append(checksCmds, pipe.ZRangeWithScores(ctx, bucketKey("checks", w.bucket, start), 0, -1))
+			deniedCmds = append(deniedCmds, pipe.ZRangeWithScores(ctx, bucketKey("denied", w.bucket, start), 0, -1))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get top keys: %w", err)
+	}
+	return topN(sum(checksCmds), n), topN(sum(deniedCmds), n), nil
+}
+
+// sum adds up the scores of each member over the buckets read by cmds
+func sum(cmds []*redis.ZSliceCmd) map[string]int64 {
+	counts := make(map[string]int64)
+	for _, cmd := range cmds {
+		for _, z := range cmd.Val() {
+			key, _ := z.Member.(string)
+			counts[key] += int64(z.Score)
+		}
+	}
+	return counts
+}
@@ -0,0 +1,174 @@
+// Package auth authenticates the callers of the HTTP and gRPC APIs with static API keys or JWTs
+// verified against a JWKS file, and authorizes them by role:
+//
+//   - check: take tokens (check endpoints, forward auth, Envoy RLS)
+//   - viewer: read rules, statistics, analytics, usage and events
+//   - admin: everything, including changing rules and resetting buckets
+//
+// A principal is scoped to a namespace, e.g. the admin of a team, or to every namespace with "*",
+// see Namespace
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/your-org/rate-limiter/config"
+)
+
+// Roles
+const (
+	RoleCheck  = "check"
+	RoleViewer = "viewer"
+	RoleAdmin  = "admin"
+)
+
+// ErrNoCredentials is returned when the request carries no credentials
+var ErrNoCredentials = errors.New("missing credentials")
+
+// ErrInvalidCredentials is returned for unknown API keys and invalid tokens
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrNamespaceForbidden is returned when a principal scoped to a namespace asks for another one
+var ErrNamespaceForbidden = errors.New("namespace not allowed")
+
+// AnyNamespace is the namespace of the principals that may use every namespace
+const AnyNamespace = "*"
+
+// Principal is an authenticated caller
+type Principal struct {
+	Name      string // API key name or JWT subject
+	Roles     []string
+	Namespace string // The only namespace the principal may use, the default one if empty, any if AnyNamespace
+}
+
+// Has reports whether p has role. Admins have every role
+func (p *Principal) Has(role string) bool {
+	for _, r := range p.Roles {
+		if r == role || r == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Namespace returns the namespace a request of p asking for requested runs in: requested, or the
+// namespace of p when requested is empty. It fails if p is scoped to another namespace. A nil p,
+// without authentication, and a p of AnyNamespace may use any namespace
+func Namespace(p *Principal, requested string) (string, error) {
+	if p == nil || p.Namespace == AnyNamespace {
+		return requested, nil
+	}
+	if requested == "" || requested == p.Namespace {
+		return p.Namespace, nil
+	}
+	return "", ErrNamespaceForbidden
+}
+
+// Option configures an Authenticator
+type Option func(*options)
+
+type options struct {
+	requireNamespace bool
+}
+
+// WithNamespaces requires JWTs to carry the namespace claim, for deployments with namespaces.
+// Without it, a JWT lacking the claim is scoped to the default namespace
+func WithNamespaces() Option {
+	return func(o *options) { o.requireNamespace = true }
+}
+
+// Authenticator resolves credentials to principals
+type Authenticator struct {
+	header string
+	keys   map[[sha256.Size]byte]*Principal // by SHA-256 of the key, so lookups do not leak the keys through timing
+	jwt    *jwtVerifier
+}
+
+// New creates an Authenticator from cfg. It returns nil when authentication is disabled; a nil
+// Authenticator lets every request through
+func New(cfg *config.AuthConfig, opts ...Option) (*Authenticator, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	a := &Authenticator{
+		header: cfg.Header,
+		keys:   make(map[[sha256.Size]byte]*Principal, len(cfg.APIKeys)),
+	}
+	if a.header == "" {
+		a.header = "Authorization"
+	}
+
+	for _, k := range cfg.APIKeys {
+		if k.Key == "" {
+			return nil, fmt.Errorf("api key %q: key is required", k.Name)
+		}
+		if err := validRoles(k.Roles); err != nil {
+			return nil, fmt.Errorf("api key %q: %w", k.Name, err)
+		}
+		sum := sha256.Sum256([]byte(k.Key))
+		if _, ok := a.keys[sum]; ok {
+			return nil, fmt.Errorf("api key %q: duplicate key", k.Name)
+		}
+		a.keys[sum] = &Principal{Name: k.Name, Roles: k.Roles, Namespace: k.Namespace}
+	}
+
+	if cfg.JWT.Enabled {
+		v, err := newJWTVerifier(&cfg.JWT, o.requireNamespace)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = v
+	}
+
+	if len(a.keys) == 0 && a.jwt == nil {
+		return nil, errors.New("auth is enabled but neither api keys nor jwt are configured")
+	}
+	return a, nil
+}
+
+func validRoles(roles []string) error {
+	if len(roles) == 0 {
+		return errors.New("at least one role is required")
+	}
+	for _, r := range roles {
+		if r != RoleCheck && r != RoleViewer && r != RoleAdmin {
+			return fmt.Errorf("unknown role %q", r)
+		}
+	}
+	return nil
+}
+
+// Header returns the name of the header carrying the credentials
+func (a *Authenticator) Header() string {
+	return a.header
+}
+
+// Authenticate resolves the value of the credentials header, "Bearer <token>" or the bare token.
+// Tokens shaped like a JWT are verified as JWTs when JWT is enabled, other tokens are API keys
+func (a *Authenticator) Authenticate(value string) (*Principal, error) {
+	token := strings.TrimSpace(value)
+	if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	if a.jwt != nil && strings.Count(token, ".") == 2 {
+		return a.jwt.verify(token)
+	}
+
+	p, ok := a.keys[sha256.Sum256([]byte(token))]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return p, nil
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/your-org/rate-limiter/config"
+)
+
+func TestAuthenticateAPIKeys(t *testing.T) {
+	a, err := New(&config.AuthConfig{
+		Enabled: true,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "checker", Key: "check-key", Roles: []string{RoleCheck}},
+			{Name: "team-a-admin", Key: "team-a-key", Roles: []string{RoleAdmin}, Namespace: "team-a"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr error
+	}{
+		{name: "bare key", header: "check-key", want: "checker"},
+		{name: "bearer key", header: "Bearer team-a-key", want: "team-a-admin"},
+		{name: "bearer is case insensitive", header: "bearer  check-key ", want: "checker"},
+		{name: "empty", header: "", wantErr: ErrNoCredentials},
+		{name: "unknown key", header: "other-key", wantErr: ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := a.Authenticate(tt.header)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Name != tt.want {
+				t.Errorf("got principal %q, want %q", p.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []config.APIKeyConfig
+	}{
+		{name: "no credentials", keys: nil},
+		{name: "empty key", keys: []config.APIKeyConfig{{Name: "a", Roles: []string{RoleCheck}}}},
+		{name: "no role", keys: []config.APIKeyConfig{{Name: "a", Key: "k"}}},
+		{name: "unknown role", keys: []config.APIKeyConfig{{Name: "a", Key: "k", Roles: []string{"root"}}}},
+		{name: "duplicate key", keys: []config.APIKeyConfig{
+			{Name: "a", Key: "k", Roles: []string{RoleCheck}},
+			{Name: "b", Key: "k", Roles: []string{RoleViewer}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(&config.AuthConfig{Enabled: true, APIKeys: tt.keys}); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestHas(t *testing.T) {
+	tests := []struct {
+		roles []string
+		role  string
+		want  bool
+	}{
+		{roles: []string{RoleCheck}, role: RoleCheck, want: true},
+		{roles: []string{RoleCheck}, role: RoleViewer, want: false},
+		{roles: []string{RoleViewer}, role: RoleAdmin, want: false},
+		{roles: []string{RoleAdmin}, role: RoleCheck, want: true},
+		{roles: []string{RoleAdmin}, role: RoleViewer, want: true},
+		{roles: []string{RoleCheck, RoleViewer}, role: RoleViewer, want: true},
+	}
+	for _, tt := range tests {
+		p := &Principal{Roles: tt.roles}
+		if got := p.Has(tt.role); got != tt.want {
+			t.Errorf("%v has %s: got %v, want %v", tt.roles, tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	scoped := &Principal{Name: "team-a", Namespace: "team-a"}
+	unscoped := &Principal{Name: "checker"}
+	global := &Principal{Name: "ops", Namespace: AnyNamespace}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		requested string
+		want      string
+		wantErr   bool
+	}{
+		{name: "no auth, default", principal: nil, requested: "", want: ""},
+		{name: "no auth, any namespace", principal: nil, requested: "team-b", want: "team-b"},
+		{name: "global, default", principal: global, requested: "", want: ""},
+		{name: "global, any namespace", principal: global, requested: "team-b", want: "team-b"},
+		{name: "unscoped, default", principal: unscoped, requested: "", want: ""},
+		{name: "unscoped, named namespace", principal: unscoped, requested: "team-b", wantErr: true},
+		{name: "scoped, implicit", principal: scoped, requested: "", want: "team-a"},
+		{name: "scoped, own namespace", principal: scoped, requested: "team-a", want: "team-a"},
+		{name: "scoped, other namespace", principal: scoped, requested: "team-b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Namespace(tt.principal, tt.requested)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNamespaceForbidden) {
+					t.Fatalf("got %q, %v, want ErrNamespaceForbidden", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// principalKey is the gin context key of the authenticated Principal
+const principalKey = "auth.principal"
+
+// Require returns a Gin middleware letting through the callers with role. Unauthenticated
+// requests get 401, authenticated callers without role 403. With a nil Authenticator it lets
+// every request through
+func (a *Authenticator) Require(role string) gin.HandlerFunc {
+	if a == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		p, err := a.Authenticate(c.GetHeader(a.header))
+		if err != nil {
+			logger.Warn("Authentication failed",
+				logger.String("path", c.FullPath()),
+				logger.String("client_ip", c.ClientIP()),
+				logger.ErrorField(err),
+			)
+			message := "Invalid credentials"
+			if errors.Is(err, ErrNoCredentials) {
+				message = "Missing credentials"
+			}
+			c.Header("WWW-Authenticate", `Bearer realm="rate-limiter"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "Unauthorized",
+				"details": message,
+			})
+			return
+		}
+
+		if !p.Has(role) {
+			logger.Warn("Permission denied",
+				logger.String("principal", p.Name),
+				logger.String("role", role),
+				logger.String("path", c.FullPath()),
+			)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "Forbidden",
+				"details": "role " + role + " required",
+			})
+			return
+		}
+
+		c.Set(principalKey, p)
+		c.Next()
+	}
+}
+
+// FromGin returns the Principal authenticated by Require, nil without authentication
+func FromGin(c *gin.Context) *Principal {
+	if v, ok := c.Get(principalKey); ok {
+		return v.(*Principal)
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// unknownKidReload is how often an unknown key ID may trigger a check of the JWKS file, so
+// a key rotated in before its tokens are used is picked up without waiting for the refresh
+const unknownKidReload = time.Second
+
+// jwtVerifier verifies JWTs signed with the asymmetric keys of a JWKS file. Verifications read the
+// current key set without locking; one caller at a time checks the file for changes
+type jwtVerifier struct {
+	file             string
+	rolesClaim       []string
+	namespaceClaim   []string
+	requireNamespace bool // reject tokens without the namespace claim
+	refresh          time.Duration
+	parser           *jwt.Parser
+
+	keys    atomic.Pointer[keySet]
+	checked atomic.Int64 // unix nanoseconds of the last check of the file
+
+	reloadMu sync.Mutex // held while the file is checked and loaded
+}
+
+// keySet is the keys of a JWKS file, replaced as a whole when the file changes
+type keySet struct {
+	keys    map[string]crypto.PublicKey // by key ID
+	modTime time.Time
+}
+
+func newJWTVerifier(cfg *config.JWTConfig, requireNamespace bool) (*jwtVerifier, error) {
+	if cfg.JWKSFile == "" {
+		return nil, errors.New("jwt: jwks_file is required")
+	}
+
+	opts := []jwt.ParserOption{
+		// Only asymmetric algorithms, a JWKS of public keys cannot verify HMAC tokens
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	rolesClaim := cfg.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = "roles"
+	}
+	namespaceClaim := cfg.NamespaceClaim
+	if namespaceClaim == "" {
+		namespaceClaim = "namespace"
+	}
+	v := &jwtVerifier{
+		file:             cfg.JWKSFile,
+		rolesClaim:       strings.Split(rolesClaim, "."),
+		namespaceClaim:   strings.Split(namespaceClaim, "."),
+		requireNamespace: requireNamespace,
+		refresh:          cfg.RefreshInterval,
+		parser:           jwt.NewParser(opts...),
+	}
+	if v.refresh <= 0 {
+		v.refresh = time.Minute
+	}
+
+	if err := v.load(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// verify checks the signature and claims of token and returns its subject, roles and namespace
+func (v *jwtVerifier) verify(token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	roles := rolesFromClaims(claims, v.rolesClaim)
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("%w: token has no known role", ErrInvalidCredentials)
+	}
+	sub, _ := claims.GetSubject()
+	namespace, ok := claimAt(claims, v.namespaceClaim).(string)
+	if !ok && v.requireNamespace {
+		return nil, fmt.Errorf("%w: token has no namespace", ErrInvalidCredentials)
+	}
+	return &Principal{Name: sub, Roles: roles, Namespace: namespace}, nil
+}
+
+// claimAt returns the claim at path, nil if it is missing
+func claimAt(claims jwt.MapClaims, path []string) interface{} {
+	var value interface{} = map[string]interface{}(claims)
+	for _, name := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if value, ok = m[name]; !ok {
+			return nil
+		}
+	}
+	return value
+}
+
+// rolesFromClaims reads the roles at path, an array of strings or a space separated string,
+// keeping the known roles only
+func rolesFromClaims(claims jwt.MapClaims, path []string) []string {
+	var names []string
+	switch v := claimAt(claims, path).(type) {
+	case string:
+		names = strings.Fields(v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				names = append(names, s)
+			}
+		}
+	}
+
+	var roles []string
+	for _, name := range names {
+		if name == RoleCheck || name == RoleViewer || name == RoleAdmin {
+			roles = append(roles, name)
+		}
+	}
+	return roles
+}
+
+// key returns the key of the token's key ID, or the only key if the token has no key ID
+func (v *jwtVerifier) key(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	// Callers finding a refresh in progress use the current keys rather than waiting
+	v.reload(v.refresh, false)
+	if key, ok := v.lookup(kid); ok {
+		return key, nil
+	}
+	// The key may have been rotated in, callers wait for the check of the file
+	if v.reload(unknownKidReload, true) {
+		if key, ok := v.lookup(kid); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (v *jwtVerifier) lookup(kid string) (crypto.PublicKey, bool) {
+	set := v.keys.Load()
+	if kid == "" && len(set.keys) == 1 {
+		for _, key := range set.keys {
+			return key, true
+		}
+	}
+	key, ok := set.keys[kid]
+	return key, ok
+}
+
+// sinceChecked returns the time elapsed since the last check of the file
+func (v *jwtVerifier) sinceChecked() time.Duration {
+	return time.Since(time.Unix(0, v.checked.Load()))
+}
+
+// reload loads the JWKS file again if it was last checked at least interval ago and changed since.
+// With wait, it waits for a check in progress, otherwise it returns at once. It reports whether the
+// file was checked by this call or the one waited for. On failure the current keys are kept
+func (v *jwtVerifier) reload(interval time.Duration, wait bool) bool {
+	if v.sinceChecked() < interval {
+		return false
+	}
+	if wait {
+		v.reloadMu.Lock()
+	} else if !v.reloadMu.TryLock() {
+		return false
+	}
+	defer v.reloadMu.Unlock()
+
+	// The file may have been checked while this caller waited
+	if v.sinceChecked() < interval {
+		return true
+	}
+	v.checked.Store(time.Now().UnixNano())
+
+	info, err := os.Stat(v.file)
+	if err != nil {
+		logger.Error("Failed to check JWKS file", logger.String("file", v.file), logger.ErrorField(err))
+		return true
+	}
+	if info.ModTime().Equal(v.keys.Load().modTime) {
+		return true
+	}
+	if err := v.loadLocked(); err != nil {
+		logger.Error("Failed to reload JWKS file, keeping the previous keys",
+			logger.String("file", v.file),
+			logger.ErrorField(err),
+		)
+	}
+	return true
+}
+
+func (v *jwtVerifier) load() error {
+	v.reloadMu.Lock()
+	defer v.reloadMu.Unlock()
+
+	v.checked.Store(time.Now().UnixNano())
+	return v.loadLocked()
+}
+
+// loadLocked loads the JWKS file. v.reloadMu must be held
+func (v *jwtVerifier) loadLocked() error {
+	info, err := os.Stat(v.file)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	data, err := os.ReadFile(v.file)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	v.keys.Store(&keySet{keys: keys, modTime: info.ModTime()})
+	logger.Info("JWKS loaded", logger.String("file", v.file), logger.Int("keys", len(keys)))
+	return nil
+}
+
+// jwk is a JSON Web Key (RFC 7517) holding a public key
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS parses the RSA, EC (P-256, P-384, P-521) and Ed25519 signing keys of a JWKS
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for i, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %d (%q): %w", i, k.Kid, err)
+		}
+		if _, ok := keys[k.Kid]; ok {
+			return nil, fmt.Errorf("JWKS key %d: duplicate key id %q", i, k.Kid)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no signing key")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid e")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid x")
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
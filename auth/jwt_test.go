@@ -0,0 +1,340 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/your-org/rate-limiter/config"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// publicJWK returns the JWK of the public key of signer
+func publicJWK(t *testing.T, kid string, signer crypto.Signer) map[string]string {
+	t.Helper()
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "n": b64(pub.N.Bytes()), "e": b64(big.NewInt(int64(pub.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		return map[string]string{"kty": "EC", "kid": kid, "crv": pub.Curve.Params().Name,
+			"x": b64(pub.X.FillBytes(make([]byte, size))), "y": b64(pub.Y.FillBytes(make([]byte, size)))}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": kid, "crv": "Ed25519", "x": b64(pub)}
+	default:
+		t.Fatalf("unsupported key %T", pub)
+		return nil
+	}
+}
+
+func writeJWKS(t *testing.T, file string, keys ...map[string]string) {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key crypto.Signer, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func claims(extra jwt.MapClaims) jwt.MapClaims {
+	c := jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix(), "roles": []string{RoleCheck}}
+	for k, v := range extra {
+		c[k] = v
+	}
+	return c
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name    string
+		keys    []map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "rsa, ec and ed25519", keys: []map[string]string{
+			publicJWK(t, "rsa", rsaKey), publicJWK(t, "ec", ecKey), publicJWK(t, "ed", edKey),
+		}, want: 3},
+		{name: "encryption keys are skipped", keys: []map[string]string{
+			publicJWK(t, "sig", ecKey), {"kty": "RSA", "kid": "enc", "use": "enc"},
+		}, want: 1},
+		{name: "no signing key", keys: nil, wantErr: true},
+		{name: "duplicate kid", keys: []map[string]string{publicJWK(t, "a", ecKey), publicJWK(t, "a", edKey)}, wantErr: true},
+		{name: "unsupported type", keys: []map[string]string{{"kty": "oct", "kid": "a", "k": "c2VjcmV0"}}, wantErr: true},
+		{name: "unsupported curve", keys: []map[string]string{{"kty": "EC", "kid": "a", "crv": "P-192", "x": "AQ", "y": "AQ"}}, wantErr: true},
+		{name: "point off the curve", keys: []map[string]string{{"kty": "EC", "kid": "a", "crv": "P-256", "x": "AQ", "y": "AQ"}}, wantErr: true},
+		{name: "invalid rsa exponent", keys: []map[string]string{{"kty": "RSA", "kid": "a", "n": b64(rsaKey.N.Bytes()), "e": "AQ"}}, wantErr: true},
+		{name: "short ed25519 key", keys: []map[string]string{{"kty": "OKP", "kid": "a", "crv": "Ed25519", "x": "AQ"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := json.Marshal(map[string]interface{}{"keys": tt.keys})
+			keys, err := parseJWKS(data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(keys) != tt.want {
+				t.Errorf("got %d keys, want %d", len(keys), tt.want)
+			}
+		})
+	}
+
+	if _, err := parseJWKS([]byte("not json")); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func newTestVerifier(t *testing.T, cfg config.JWTConfig, keys ...map[string]string) (*jwtVerifier, string) {
+	t.Helper()
+	cfg.JWKSFile = filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, cfg.JWKSFile, keys...)
+	v, err := newJWTVerifier(&cfg, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v, cfg.JWKSFile
+}
+
+func TestVerify(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	v, _ := newTestVerifier(t, config.JWTConfig{Issuer: "https://issuer", Audience: "rate-limiter"},
+		publicJWK(t, "ec", ecKey), publicJWK(t, "rsa", rsaKey), publicJWK(t, "ed", edKey))
+	valid := jwt.MapClaims{"iss": "https://issuer", "aud": "rate-limiter"}
+
+	tests := []struct {
+		name          string
+		token         string
+		wantRoles     []string
+		wantNamespace string
+		wantErr       bool
+	}{
+		{name: "es256", token: sign(t, jwt.SigningMethodES256, "ec", ecKey, claims(valid)), wantRoles: []string{RoleCheck}},
+		{name: "rs256", token: sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims(valid)), wantRoles: []string{RoleCheck}},
+		{name: "eddsa", token: sign(t, jwt.SigningMethodEdDSA, "ed", edKey, claims(valid)), wantRoles: []string{RoleCheck}},
+		{name: "space separated roles", token: sign(t, jwt.SigningMethodES256, "ec", ecKey,
+			claims(jwt.MapClaims{"iss": "https://issuer", "aud": "rate-limiter", "roles": "viewer unknown admin"})),
+			wantRoles: []string{RoleViewer, RoleAdmin}},
+		{name: "namespace", token: sign(t, jwt.SigningMethodES256, "ec", ecKey,
+			claims(jwt.MapClaims{"iss": "https://issuer", "aud": "rate-limiter", "namespace": "team-a"})),
+			wantRoles: []string{RoleCheck}, wantNamespace: "team-a"},
+		{name: "no known role", token: sign(t, jwt.SigningMethodES256, "ec", ecKey,
+			claims(jwt.MapClaims{"iss": "https://issuer", "aud": "rate-limiter", "roles": []string{"root"}})), wantErr: true},
+		{name: "expired", token: sign(t, jwt.SigningMethodES256, "ec", ecKey,
+			claims(jwt.MapClaims{"iss": "https://issuer", "aud": "rate-limiter", "exp": time.Now().Add(-time.Hour).Unix()})), wantErr: true},
+		{name: "no expiration", token: sign(t, jwt.SigningMethodES256, "ec", ecKey,
+			jwt.MapClaims{"iss": "https://issuer", "aud": "rate-limiter", "roles": []string{RoleCheck}}), wantErr: true},
+		{name: "wrong issuer", token: sign(t, jwt.SigningMethodES256, "ec", ecKey,
+			claims(jwt.MapClaims{"iss": "https://other", "aud": "rate-limiter"})), wantErr: true},
+		{name: "wrong audience", token: sign(t, jwt.SigningMethodES256, "ec", ecKey,
+			claims(jwt.MapClaims{"iss": "https://issuer", "aud": "other"})), wantErr: true},
+		{name: "unknown kid", token: sign(t, jwt.SigningMethodES256, "other", otherKey, claims(valid)), wantErr: true},
+		{name: "wrong key", token: sign(t, jwt.SigningMethodES256, "ec", otherKey, claims(valid)), wantErr: true},
+		{name: "no kid with several keys", token: sign(t, jwt.SigningMethodES256, "", ecKey, claims(valid)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("got %+v, %v, want ErrInvalidCredentials", p, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Name != "alice" || p.Namespace != tt.wantNamespace || len(p.Roles) != len(tt.wantRoles) {
+				t.Fatalf("got %+v, want roles %v and namespace %q", p, tt.wantRoles, tt.wantNamespace)
+			}
+			for i, role := range tt.wantRoles {
+				if p.Roles[i] != role {
+					t.Errorf("role %d: got %q, want %q", i, p.Roles[i], role)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsHMAC(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	v, _ := newTestVerifier(t, config.JWTConfig{}, publicJWK(t, "ec", ecKey))
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(nil)).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.verify(token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("got %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestVerifyNestedClaims(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	v, _ := newTestVerifier(t, config.JWTConfig{RolesClaim: "realm_access.roles", NamespaceClaim: "org.team"},
+		publicJWK(t, "ec", ecKey))
+
+	// The only key is used for tokens without a key ID
+	token := sign(t, jwt.SigningMethodES256, "", ecKey, jwt.MapClaims{
+		"sub":          "bob",
+		"exp":          time.Now().Add(time.Hour).Unix(),
+		"realm_access": map[string]interface{}{"roles": []string{RoleViewer}},
+		"org":          map[string]interface{}{"team": "team-b"},
+	})
+	p, err := v.verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Has(RoleViewer) || p.Has(RoleAdmin) || p.Namespace != "team-b" {
+		t.Errorf("got %+v, want a viewer of team-b", p)
+	}
+}
+
+func TestAuthenticateRequiresNamespaceClaim(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, file, publicJWK(t, "ec", ecKey))
+
+	tests := []struct {
+		name          string
+		namespaces    bool
+		claims        jwt.MapClaims
+		wantNamespace string
+		wantErr       bool
+	}{
+		{name: "scoped", namespaces: true, claims: claims(jwt.MapClaims{"namespace": "team-a"}), wantNamespace: "team-a"},
+		{name: "any namespace", namespaces: true, claims: claims(jwt.MapClaims{"namespace": AnyNamespace}), wantNamespace: AnyNamespace},
+		{name: "missing claim", namespaces: true, claims: claims(nil), wantErr: true},
+		{name: "claim not a string", namespaces: true, claims: claims(jwt.MapClaims{"namespace": 1}), wantErr: true},
+		{name: "missing claim without namespaces", claims: claims(nil), wantNamespace: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.namespaces {
+				opts = append(opts, WithNamespaces())
+			}
+			a, err := New(&config.AuthConfig{Enabled: true, JWT: config.JWTConfig{Enabled: true, JWKSFile: file}}, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := a.Authenticate("Bearer " + sign(t, jwt.SigningMethodES256, "ec", ecKey, tt.claims))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("got %+v, %v, want ErrInvalidCredentials", p, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Namespace != tt.wantNamespace {
+				t.Errorf("got namespace %q, want %q", p.Namespace, tt.wantNamespace)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	v, file := newTestVerifier(t, config.JWTConfig{RefreshInterval: time.Hour}, publicJWK(t, "old", oldKey))
+
+	token := sign(t, jwt.SigningMethodES256, "new", newKey, claims(nil))
+	if _, err := v.verify(token); err == nil {
+		t.Fatal("token of a key not yet in the JWKS verified")
+	}
+
+	writeJWKS(t, file, publicJWK(t, "old", oldKey), publicJWK(t, "new", newKey))
+	modTime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	// An unknown key ID checks the file at most every unknownKidReload
+	if _, err := v.verify(token); err == nil {
+		t.Fatal("file checked again right after the previous check")
+	}
+	v.checked.Store(time.Now().Add(-unknownKidReload).UnixNano())
+
+	// Concurrent verifications of the new key share one check of the file
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := v.verify(token)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("token of the rotated key: %v", err)
+		}
+	}
+}
+
+func TestInvalidReloadKeepsKeys(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	v, file := newTestVerifier(t, config.JWTConfig{RefreshInterval: time.Hour}, publicJWK(t, "ec", ecKey))
+
+	if err := os.WriteFile(file, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	v.checked.Store(0)
+
+	if _, err := v.verify(sign(t, jwt.SigningMethodES256, "ec", ecKey, claims(nil))); err != nil {
+		t.Fatalf("previous key dropped after an invalid reload: %v", err)
+	}
+}
@@ -0,0 +1,284 @@
+// Package client is a Go client for the rate limiter HTTP API.
+//
+// A Client reuses connections across calls and retries transient failures with backoff:
+//
+//	c := client.New("http://localhost:8080")
+//	res, err := c.Check(ctx, "your_api_key:gpt-4")
+//	if err == nil && !res.Allowed {
+//		// rate limited
+//	}
+//
+// With WithLease, the client takes a batch of tokens from the server at once and spends
+// them locally, trading a bounded overshoot for fewer round trips on hot keys
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CheckResult is the result of a rate limit check
+type CheckResult struct {
+	Allowed bool   // Whether the request is allowed
+	Remain  int64  // Number of remaining tokens, local ones for leased tokens
+	Message string // Message returned by the server (if any)
+	Leased  bool   // Whether the result was served from locally leased tokens
+	// Failure policy (open, closed, local) that decided the check while the server's store was
+	// unavailable, empty otherwise
+	Degraded string
+}
+
+// StatusError is returned for non-2xx responses that are not retried or exhausted the retries
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("rate limiter returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// Client calls the rate limiter HTTP API. It is safe for concurrent use
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	lease *leaser
+}
+
+// Option customizes a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client, by default one with a pooled transport and a 5s timeout
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithHeader adds a header to every request, e.g. for authentication
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.header.Add(key, value) }
+}
+
+// WithNamespace sends every request to namespace, by default the default namespace or the
+// namespace of the credentials
+func WithNamespace(namespace string) Option {
+	return WithHeader("X-Namespace", namespace)
+}
+
+// WithRetries sets how many times a request is retried on network errors and 5xx responses,
+// and the bounds of the exponential backoff between attempts. Defaults to 2 retries, 50ms to 1s.
+// A check that reached the server before failing may already have taken its tokens
+func WithRetries(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithLease enables local token leasing: when a key has no leased tokens left, Check takes
+// batch tokens from the server in one request and serves the following checks of that key
+// locally until they run out or ttl elapses. Unused tokens are lost when the lease expires.
+// When the bucket holds fewer than batch tokens, the key is checked one token at a time for ttl.
+//
+// The server never hands out more tokens than its buckets allow, but leased tokens are spent
+// later than the server accounted for them: a client may admit up to batch-1 requests per key
+// that the server already considers consumed, for at most ttl. Keep batch small relative to the
+// burst of the rule and ttl short relative to batch/rate
+func WithLease(batch int64, ttl time.Duration) Option {
+	return func(c *Client) {
+		if batch > 1 && ttl > 0 {
+			c.lease = newLeaser(batch, ttl)
+		}
+	}
+}
+
+// New creates a client for the server at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		header:     make(http.Header),
+		maxRetries: 2,
+		minBackoff: 50 * time.Millisecond,
+		maxBackoff: time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{
+			Transport: defaultTransport(),
+			Timeout:   5 * time.Second,
+		}
+	}
+	return c
+}
+
+// defaultTransport keeps enough idle connections per host for a busy client to reuse them
+func defaultTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = 100
+	t.MaxIdleConnsPerHost = 100
+	t.IdleConnTimeout = 90 * time.Second
+	return t
+}
+
+type checkReq struct {
+	Key    string `json:"key"`
+	Tokens int64  `json:"tokens,omitempty"`
+}
+
+type checkResp struct {
+	Allowed  bool   `json:"allowed"`
+	Message  string `json:"message"`
+	Remain   int64  `json:"remain"`
+	Degraded string `json:"degraded"`
+}
+
+// Check checks if a request for key is allowed, taking 1 token
+func (c *Client) Check(ctx context.Context, key string) (*CheckResult, error) {
+	if c.lease != nil {
+		return c.lease.check(ctx, c, key)
+	}
+	return c.CheckN(ctx, key, 1)
+}
+
+// CheckN checks if n tokens can be taken from the bucket of key at once.
+// It always calls the server, leased tokens are not used
+func (c *Client) CheckN(ctx context.Context, key string, n int64) (*CheckResult, error) {
+	var resp checkResp
+	if err := c.do(ctx, http.MethodPost, "/v1/check_rate_limit", checkReq{Key: key, Tokens: n}, &resp); err != nil {
+		return nil, err
+	}
+	return &CheckResult{
+		Allowed:  resp.Allowed,
+		Remain:   resp.Remain,
+		Message:  resp.Message,
+		Degraded: resp.Degraded,
+	}, nil
+}
+
+// do sends a JSON request and decodes the JSON response into out, retrying transient failures
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.backoff(attempt)); err != nil {
+				return err
+			}
+		}
+
+		retry, err := c.attempt(ctx, method, path, body, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+	return lastErr
+}
+
+// attempt sends a single request and reports whether a failure may be retried
+func (c *Client) attempt(ctx context.Context, method, path string, body []byte, out interface{}) (bool, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Network errors are retried, the loop stops once ctx is done
+		return true, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return true, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode >= 500, &StatusError{
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(data),
+		}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return false, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return false, nil
+}
+
+// backoff returns the delay before the given retry, exponential with full jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.minBackoff << (attempt - 1)
+	if d <= 0 || d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)) + 1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// errorMessage extracts the error of the server's {"error": ..., "details": ...} responses
+func errorMessage(data []byte) string {
+	var e struct {
+		Error   string `json:"error"`
+		Details string `json:"details"`
+	}
+	if json.Unmarshal(data, &e) != nil || e.Error == "" {
+		return strings.TrimSpace(string(data))
+	}
+	if e.Details != "" {
+		return e.Error + ": " + e.Details
+	}
+	return e.Error
+}
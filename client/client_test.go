@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/your-org/rate-limiter/client/clienttest"
+	"github.com/your-org/rate-limiter/config"
+)
+
+func newServer(t *testing.T, rate, burst int64) *clienttest.Server {
+	t.Helper()
+	clock := time.Unix(1700000000, 0)
+	srv := clienttest.NewServer(config.LimiterConfig{DefaultRate: rate, DefaultBurst: burst},
+		clienttest.WithClock(func() time.Time { return clock }))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int64
+		maxRetries int
+		wantErr    bool
+		requests   int64
+	}{
+		{name: "no failure", failures: 0, maxRetries: 2, requests: 1},
+		{name: "retried failures", failures: 2, maxRetries: 2, requests: 3},
+		{name: "retries exhausted", failures: 3, maxRetries: 2, wantErr: true, requests: 3},
+		{name: "no retries", failures: 1, maxRetries: 0, wantErr: true, requests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t, 1, 5)
+			srv.FailNext(tt.failures)
+			c := New(srv.URL, WithRetries(tt.maxRetries, time.Millisecond, time.Millisecond))
+
+			res, err := c.Check(context.Background(), "key")
+			if tt.wantErr {
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+					t.Fatalf("got %v, want a 503 StatusError", err)
+				}
+			} else if err != nil || !res.Allowed {
+				t.Fatalf("got %+v, %v, want an allowed check", res, err)
+			}
+			if got := srv.Requests(); got != tt.requests {
+				t.Errorf("requests: got %d, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	srv := newServer(t, 1, 5)
+	c := New(srv.URL, WithRetries(2, time.Millisecond, time.Millisecond))
+
+	_, err := c.Check(context.Background(), "")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %v, want a 400 StatusError", err)
+	}
+	if got := srv.Requests(); got != 1 {
+		t.Errorf("requests: got %d, want 1", got)
+	}
+}
+
+func TestRetriesStopWithContext(t *testing.T) {
+	srv := newServer(t, 1, 5)
+	srv.FailNext(10)
+	c := New(srv.URL, WithRetries(5, time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Check(ctx, "key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if got := srv.Requests(); got != 1 {
+		t.Errorf("requests: got %d, want 1", got)
+	}
+}
+
+func TestLease(t *testing.T) {
+	srv := newServer(t, 1, 10)
+	c := New(srv.URL, WithLease(4, time.Minute))
+	ctx := context.Background()
+
+	// The first check leases 4 tokens, the next 3 are served locally
+	for i, want := range []int64{3, 2, 1, 0} {
+		res, err := c.Check(ctx, "key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || !res.Leased || res.Remain != want {
+			t.Fatalf("check %d: got %+v, want a leased check with %d left", i, res, want)
+		}
+	}
+	if got := srv.Requests(); got != 1 {
+		t.Fatalf("requests after one lease: got %d, want 1", got)
+	}
+
+	// The next check leases 4 of the 6 tokens left in the bucket
+	if res, err := c.Check(ctx, "key"); err != nil || !res.Leased {
+		t.Fatalf("second lease: got %+v, %v", res, err)
+	}
+	if got := srv.Requests(); got != 2 {
+		t.Fatalf("requests after two leases: got %d, want 2", got)
+	}
+}
+
+func TestLeaseSkipsDegradedResults(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"allowed": true, "remain": 9, "degraded": "open"}`))
+	}))
+	t.Cleanup(srv.Close)
+	c := New(srv.URL, WithLease(4, time.Minute))
+
+	for i := 0; i < 3; i++ {
+		res, err := c.Check(context.Background(), "key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Leased || res.Degraded != "open" {
+			t.Fatalf("check %d: got %+v, want a degraded check not leased", i, res)
+		}
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests: got %d, want 3, degraded results must not be leased", got)
+	}
+	if ls := c.lease.get("key"); ls.tokens != 0 {
+		t.Errorf("leased tokens: got %d, want 0", ls.tokens)
+	}
+}
+
+func TestLeaseExpires(t *testing.T) {
+	srv := newServer(t, 1, 10)
+	c := New(srv.URL, WithLease(4, time.Second))
+	now := time.Unix(1700000000, 0)
+	c.lease.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := c.Check(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Second)
+	if _, err := c.Check(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.Requests(); got != 2 {
+		t.Errorf("requests: got %d, want 2, the expired lease must not be spent", got)
+	}
+}
+
+func TestLeaseNearLimit(t *testing.T) {
+	srv := newServer(t, 1, 6)
+	c := New(srv.URL, WithLease(4, time.Minute))
+	ctx := context.Background()
+
+	// Lease 4 tokens and spend them, 2 are left in the bucket
+	for i := 0; i < 4; i++ {
+		if _, err := c.Check(ctx, "key"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		allowed  bool
+		requests int64 // total after the check
+	}{
+		{allowed: true, requests: 3},  // the batch is denied, a single token is taken
+		{allowed: true, requests: 4},  // single token mode, one request
+		{allowed: false, requests: 5}, // empty bucket, one request
+		{allowed: false, requests: 6},
+	}
+	for i, tt := range tests {
+		res, err := c.Check(ctx, "key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed != tt.allowed || res.Leased {
+			t.Errorf("check %d: got %+v, want allowed=%v not leased", i, res, tt.allowed)
+		}
+		if got := srv.Requests(); got != tt.requests {
+			t.Errorf("check %d: requests got %d, want %d", i, got, tt.requests)
+		}
+	}
+}
+
+func TestLeaseDeniedBatchOfEmptyBucket(t *testing.T) {
+	srv := newServer(t, 1, 4)
+	c := New(srv.URL, WithLease(4, time.Minute))
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		if _, err := c.Check(ctx, "key"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := c.Check(ctx, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed {
+		t.Fatal("check of an empty bucket allowed")
+	}
+	if got := srv.Requests(); got != 2 {
+		t.Errorf("requests: got %d, want 2, the denied batch of an empty bucket needs no retry", got)
+	}
+}
@@ -0,0 +1,113 @@
+// Package clienttest provides an in-process rate limiter server for testing code that uses the client package.
+//
+// The server runs the real HTTP handlers on an in-memory store, so checks behave like the service:
+//
+//	srv := clienttest.NewServer(config.LimiterConfig{DefaultRate: 1, DefaultBurst: 2})
+//	defer srv.Close()
+//
+//	c := client.New(srv.URL)
+//	res, err := c.Check(ctx, "your_api_key:gpt-4")
+package clienttest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/handler"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/limiter/memory"
+)
+
+// Server is a rate limiter server listening on a local address
+type Server struct {
+	*httptest.Server
+
+	store    *memory.Store
+	limiter  *limiter.Limiter
+	requests atomic.Int64
+	failures atomic.Int64
+}
+
+// Option customizes a Server
+type Option func(*serverOptions)
+
+type serverOptions struct {
+	now func() time.Time
+}
+
+// WithClock sets the clock used to refill token buckets, so tests can control refills
+func WithClock(now func() time.Time) Option {
+	return func(o *serverOptions) { o.now = now }
+}
+
+// NewServer starts a server using defaults for keys without a rule. Call Close when done
+func NewServer(defaults config.LimiterConfig, opts ...Option) *Server {
+	o := &serverOptions{now: time.Now}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	s := &Server{store: memory.New(time.Minute)}
+	s.limiter = limiter.New(s.store, defaults, limiter.WithClock(o.now))
+	h := handler.New(s.limiter, &config.Config{Limiter: defaults})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(s.count)
+	v1 := r.Group("/v1")
+	{
+		v1.POST("/check_rate_limit", h.CheckRateLimit)
+		v1.POST("/update_rule", h.UpdateRule)
+		v1.POST("/delete_rule", h.DeleteRule)
+		v1.POST("/reset_bucket", h.ResetBucket)
+		v1.GET("/stats", h.GetStats)
+		v1.GET("/rule_stats", h.GetRuleStats)
+	}
+
+	s.Server = httptest.NewServer(r)
+	return s
+}
+
+// Close shuts down the server and its store
+func (s *Server) Close() {
+	s.Server.Close()
+	s.store.Close()
+}
+
+// SetRule sets the rate limiting rule of key
+func (s *Server) SetRule(key string, rate, burst int64) error {
+	return s.limiter.SetRule(context.Background(), key, rate, burst)
+}
+
+// Requests returns the number of requests received, including failed ones
+func (s *Server) Requests() int64 {
+	return s.requests.Load()
+}
+
+// FailNext makes the next n requests fail with 503 Service Unavailable
+func (s *Server) FailNext(n int64) {
+	s.failures.Store(n)
+}
+
+// count counts requests and injects the failures set with FailNext
+func (s *Server) count(c *gin.Context) {
+	s.requests.Add(1)
+	for {
+		n := s.failures.Load()
+		if n <= 0 {
+			break
+		}
+		if s.failures.CompareAndSwap(n, n-1) {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Injected failure",
+			})
+			return
+		}
+	}
+	c.Next()
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxLeases bounds the number of keys tracked before expired leases are dropped
+const maxLeases = 10000
+
+// leaser holds the tokens leased from the server per key
+type leaser struct {
+	batch int64
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu     sync.Mutex
+	leases map[string]*lease
+}
+
+type lease struct {
+	// mu is held while the lease is refilled, so concurrent checks of a key share one request
+	mu        sync.Mutex
+	tokens    int64
+	expiresAt time.Time
+	// singleUntil is set when the bucket could not hand out a batch: until then the key is near its
+	// limit and checked one token at a time, without trying a batch first
+	singleUntil time.Time
+}
+
+func newLeaser(batch int64, ttl time.Duration) *leaser {
+	return &leaser{
+		batch:  batch,
+		ttl:    ttl,
+		now:    time.Now,
+		leases: make(map[string]*lease),
+	}
+}
+
+// get returns the lease of key, creating it if needed
+func (l *leaser) get(key string) *lease {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ls, ok := l.leases[key]
+	if !ok {
+		if len(l.leases) >= maxLeases {
+			l.dropExpired()
+		}
+		ls = &lease{}
+		l.leases[key] = ls
+	}
+	return ls
+}
+
+// dropExpired removes leases that have nothing left to spend. l.mu must be held
+func (l *leaser) dropExpired() {
+	now := l.now()
+	for key, ls := range l.leases {
+		if ls.mu.TryLock() {
+			if ls.tokens == 0 || !now.Before(ls.expiresAt) {
+				delete(l.leases, key)
+			}
+			ls.mu.Unlock()
+		}
+	}
+}
+
+// check spends a leased token of key, leasing a new batch from the server when none is left.
+// When the server cannot hand out a full batch, the key is checked one token at a time for ttl,
+// so a key near its limit costs one request per check rather than a denied batch and a retry.
+// Degraded results are not leased, the tokens of a failure policy are not worth keeping
+func (l *leaser) check(ctx context.Context, c *Client, key string) (*CheckResult, error) {
+	ls := l.get(key)
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	now := l.now()
+	if ls.tokens > 0 && now.Before(ls.expiresAt) {
+		ls.tokens--
+		return &CheckResult{Allowed: true, Remain: ls.tokens, Leased: true}, nil
+	}
+	ls.tokens = 0
+
+	if now.Before(ls.singleUntil) {
+		res, err := c.CheckN(ctx, key, 1)
+		if err != nil {
+			return nil, err
+		}
+		if res.Remain >= l.batch {
+			// The bucket refilled, the next check leases a batch again
+			ls.singleUntil = time.Time{}
+		}
+		return res, nil
+	}
+
+	res, err := c.CheckN(ctx, key, l.batch)
+	if err != nil {
+		return nil, err
+	}
+	if res.Degraded != "" {
+		// The next check asks the server again, which may have recovered
+		return res, nil
+	}
+	if res.Allowed {
+		// One token of the batch is spent on this check
+		ls.tokens = l.batch - 1
+		ls.expiresAt = now.Add(l.ttl)
+		res.Remain = ls.tokens
+		res.Leased = true
+		return res, nil
+	}
+
+	// Fewer than batch tokens are left in the bucket
+	ls.singleUntil = now.Add(l.ttl)
+	if res.Remain < 1 {
+		// A single token would be denied as well
+		return res, nil
+	}
+	return c.CheckN(ctx, key, 1)
+}
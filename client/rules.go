@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Rule is a rate limiting rule with the current state of its bucket
+type Rule struct {
+	Key           string    `json:"key"`
+	Rate          int64     `json:"rate"`                     // Tokens per second
+	Burst         int64     `json:"burst"`                    // Bucket capacity
+	UpdatedAt     time.Time `json:"updated_at,omitempty"`     // Last update of the rule, zero if unknown
+	CurrentTokens *int64    `json:"current_tokens,omitempty"` // Tokens left in the bucket, nil if unknown
+}
+
+// RuleStats is the rule and bucket state of a key
+type RuleStats struct {
+	Key           string `json:"key"`
+	Found         bool   `json:"found"` // Whether a rule is set, the other fields are zero if not
+	Rate          int64  `json:"rate"`
+	Burst         int64  `json:"burst"`
+	CurrentTokens int64  `json:"current_tokens"`
+}
+
+type updateRuleReq struct {
+	Key       string `json:"key"`
+	RateLimit int64  `json:"rate_limit"`
+	Burst     int64  `json:"burst,omitempty"`
+}
+
+type keyReq struct {
+	Key string `json:"key"`
+}
+
+type statsResp struct {
+	Rules map[string]map[string]interface{} `json:"rules"`
+	Stats map[string]map[string]interface{} `json:"stats"`
+}
+
+type ruleStatsResp struct {
+	Key   string                 `json:"key"`
+	Stats map[string]interface{} `json:"stats"`
+}
+
+// SetRule sets the rule of key. A zero burst uses the server default
+func (c *Client) SetRule(ctx context.Context, key string, rate, burst int64) error {
+	return c.do(ctx, http.MethodPost, "/v1/update_rule", updateRuleReq{Key: key, RateLimit: rate, Burst: burst}, nil)
+}
+
+// DeleteRule deletes the rule of key. It returns a *StatusError with status 404 if no rule is set
+func (c *Client) DeleteRule(ctx context.Context, key string) error {
+	return c.do(ctx, http.MethodPost, "/v1/delete_rule", keyReq{Key: key}, nil)
+}
+
+// ResetBucket refills the token bucket of key
+func (c *Client) ResetBucket(ctx context.Context, key string) error {
+	return c.do(ctx, http.MethodPost, "/v1/reset_bucket", keyReq{Key: key}, nil)
+}
+
+// RuleStats gets the rule and current tokens of key without taking any token
+func (c *Client) RuleStats(ctx context.Context, key string) (*RuleStats, error) {
+	var resp ruleStatsResp
+	if err := c.do(ctx, http.MethodGet, "/v1/rule_stats?key="+url.QueryEscape(key), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	stats := &RuleStats{Key: key}
+	rate, ok := toInt64(resp.Stats["rate"])
+	if !ok {
+		// The server reports "unknown" for keys without a rule
+		return stats, nil
+	}
+	stats.Found = true
+	stats.Rate = rate
+	stats.Burst, _ = toInt64(resp.Stats["burst"])
+	stats.CurrentTokens, _ = toInt64(resp.Stats["current_tokens"])
+	return stats, nil
+}
+
+// Rules gets all rules sorted by key
+func (c *Client) Rules(ctx context.Context) ([]Rule, error) {
+	var resp statsResp
+	if err := c.do(ctx, http.MethodGet, "/v1/stats", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	rules := make([]Rule, 0, len(resp.Rules))
+	for key, fields := range resp.Rules {
+		r := Rule{Key: key}
+		r.Rate, _ = toInt64(fields["rate"])
+		r.Burst, _ = toInt64(fields["burst"])
+		if ts, ok := toInt64(fields["updated_at"]); ok {
+			r.UpdatedAt = time.Unix(ts, 0)
+		}
+		if tokens, ok := toInt64(resp.Stats[key]["current_tokens"]); ok {
+			r.CurrentTokens = &tokens
+		}
+		rules = append(rules, r)
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Key < rules[j].Key })
+	return rules, nil
+}
+
+// KeyCount is a key and its count in a window
+type KeyCount struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// TopKeys are the keys with the most checks and denials in a window
+type TopKeys struct {
+	Window string     `json:"window"`
+	Checks []KeyCount `json:"checks"`
+	Denied []KeyCount `json:"denied"`
+}
+
+// TopKeys gets the n keys with the most checks and denials over window (5m, 1h or 24h)
+func (c *Client) TopKeys(ctx context.Context, window string, n int) (*TopKeys, error) {
+	q := url.Values{}
+	q.Set("window", window)
+	q.Set("n", strconv.Itoa(n))
+
+	var resp TopKeys
+	if err := c.do(ctx, http.MethodGet, "/v1/top_keys?"+q.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UsagePoint is the usage of a key during one minute
+type UsagePoint struct {
+	Time    time.Time `json:"time"`
+	Allowed int64     `json:"allowed"`
+	Denied  int64     `json:"denied"`
+	Tokens  int64     `json:"tokens"`
+}
+
+// Usage is the usage history of a key with its current rule
+type Usage struct {
+	Key            string       `json:"key"`
+	Window         string       `json:"window"`
+	Rate           int64        `json:"rate"`
+	Burst          int64        `json:"burst"`
+	LimitPerMinute int64        `json:"limit_per_minute"`
+	Points         []UsagePoint `json:"points"`
+}
+
+// Usage gets the per-minute usage of key over window, capped to the server retention
+func (c *Client) Usage(ctx context.Context, key string, window time.Duration) (*Usage, error) {
+	q := url.Values{}
+	q.Set("key", key)
+	q.Set("window", window.String())
+
+	var resp Usage
+	if err := c.do(ctx, http.MethodGet, "/v1/usage?"+q.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// toInt64 converts the stats values, which are numbers or numeric strings, to int64
+func toInt64(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return int64(v), true
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
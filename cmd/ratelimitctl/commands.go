@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/your-org/rate-limiter/client"
+	"gopkg.in/yaml.v3"
+)
+
+// app holds what the commands share
+type app struct {
+	client *client.Client
+	output string
+	stdout io.Writer
+}
+
+// ruleSpec is a rule in export and import files
+type ruleSpec struct {
+	Key   string `json:"key" yaml:"key"`
+	Rate  int64  `json:"rate" yaml:"rate"`
+	Burst int64  `json:"burst" yaml:"burst"`
+}
+
+type rulesFile struct {
+	Rules []ruleSpec `json:"rules" yaml:"rules"`
+}
+
+func runCheck(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	tokens := fs.Int64("tokens", 1, "number of tokens to take at once")
+	key, err := parseKey(fs, args)
+	if err != nil {
+		return err
+	}
+
+	res, err := a.client.CheckN(ctx, key, *tokens)
+	if err != nil {
+		return err
+	}
+	return a.print(map[string]interface{}{
+		"key":     key,
+		"allowed": res.Allowed,
+		"remain":  res.Remain,
+	}, []string{"KEY", "ALLOWED", "REMAIN"}, [][]string{
+		{key, strconv.FormatBool(res.Allowed), strconv.FormatInt(res.Remain, 10)},
+	})
+}
+
+func runPeek(ctx context.Context, a *app, args []string) error {
+	key, err := parseKey(flag.NewFlagSet("peek", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+
+	stats, err := a.client.RuleStats(ctx, key)
+	if err != nil {
+		return err
+	}
+	row := []string{key, "-", "-", "-"}
+	if stats.Found {
+		row = []string{
+			key,
+			strconv.FormatInt(stats.Rate, 10),
+			strconv.FormatInt(stats.Burst, 10),
+			strconv.FormatInt(stats.CurrentTokens, 10),
+		}
+	}
+	return a.print(stats, []string{"KEY", "RATE", "BURST", "TOKENS"}, [][]string{row})
+}
+
+func runSet(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("set", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 || fs.NArg() > 3 {
+		return errors.New("usage: set <key> <rate> [burst]")
+	}
+
+	key := fs.Arg(0)
+	rate, err := strconv.ParseInt(fs.Arg(1), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid rate %q", fs.Arg(1))
+	}
+	var burst int64
+	if fs.NArg() == 3 {
+		burst, err = strconv.ParseInt(fs.Arg(2), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid burst %q", fs.Arg(2))
+		}
+	}
+
+	if err := a.client.SetRule(ctx, key, rate, burst); err != nil {
+		return err
+	}
+	return a.done("Rule of %s updated", key)
+}
+
+func runDelete(ctx context.Context, a *app, args []string) error {
+	key, err := parseKey(flag.NewFlagSet("delete", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+
+	if err := a.client.DeleteRule(ctx, key); err != nil {
+		return err
+	}
+	return a.done("Rule of %s deleted", key)
+}
+
+func runReset(ctx context.Context, a *app, args []string) error {
+	key, err := parseKey(flag.NewFlagSet("reset", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+
+	if err := a.client.ResetBucket(ctx, key); err != nil {
+		return err
+	}
+	return a.done("Bucket of %s reset", key)
+}
+
+func runList(ctx context.Context, a *app, args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: list")
+	}
+
+	rules, err := a.client.Rules(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(rules))
+	for _, r := range rules {
+		tokens, updated := "-", "-"
+		if r.CurrentTokens != nil {
+			tokens = strconv.FormatInt(*r.CurrentTokens, 10)
+		}
+		if !r.UpdatedAt.IsZero() {
+			updated = r.UpdatedAt.Format("2006-01-02 15:04:05")
+		}
+		rows = append(rows, []string{
+			r.Key,
+			strconv.FormatInt(r.Rate, 10),
+			strconv.FormatInt(r.Burst, 10),
+			tokens,
+			updated,
+		})
+	}
+	return a.print(rules, []string{"KEY", "RATE", "BURST", "TOKENS", "UPDATED"}, rows)
+}
+
+func runExport(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	file := fs.String("f", "", "output file (default stdout)")
+	format := fs.String("format", "json", "file format: json or yaml")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rules, err := a.client.Rules(ctx)
+	if err != nil {
+		return err
+	}
+	out := rulesFile{Rules: make([]ruleSpec, 0, len(rules))}
+	for _, r := range rules {
+		out.Rules = append(out.Rules, ruleSpec{Key: r.Key, Rate: r.Rate, Burst: r.Burst})
+	}
+
+	var data []byte
+	switch *format {
+	case "json":
+		data, err = json.MarshalIndent(out, "", "  ")
+		data = append(data, '\n')
+	case "yaml":
+		data, err = yaml.Marshal(out)
+	default:
+		return fmt.Errorf("invalid format %q, must be json or yaml", *format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode rules: %w", err)
+	}
+
+	if *file == "" {
+		_, err = a.stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*file, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write rules: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d rules to %s\n", len(out.Rules), *file)
+	return nil
+}
+
+func runImport(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := fs.String("f", "", "input file (default stdin)")
+	dryRun := fs.Bool("dry-run", false, "validate and print the rules without applying them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var data []byte
+	var err error
+	if *file == "" || *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read rules: %w", err)
+	}
+
+	// YAML is a superset of JSON, so both formats are parsed the same way
+	var in rulesFile
+	if err := yaml.Unmarshal(data, &in); err != nil {
+		return fmt.Errorf("failed to parse rules: %w", err)
+	}
+	for i, r := range in.Rules {
+		if r.Key == "" {
+			return fmt.Errorf("rule %d: key is required", i+1)
+		}
+		if r.Rate <= 0 {
+			return fmt.Errorf("rule %s: rate must be greater than 0", r.Key)
+		}
+		if r.Burst < 0 {
+			return fmt.Errorf("rule %s: burst must not be negative", r.Key)
+		}
+	}
+
+	rows := make([][]string, 0, len(in.Rules))
+	for _, r := range in.Rules {
+		result := "dry-run"
+		if !*dryRun {
+			if err := a.client.SetRule(ctx, r.Key, r.Rate, r.Burst); err != nil {
+				return fmt.Errorf("rule %s: %w", r.Key, err)
+			}
+			result = "applied"
+		}
+		rows = append(rows, []string{r.Key, strconv.FormatInt(r.Rate, 10), strconv.FormatInt(r.Burst, 10), result})
+	}
+	return a.print(in, []string{"KEY", "RATE", "BURST", "RESULT"}, rows)
+}
+
+func runTop(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("top", flag.ContinueOnError)
+	window := fs.String("window", "5m", "time window: 5m, 1h or 24h")
+	n := fs.Int("n", 10, "number of keys")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	top, err := a.client.TopKeys(ctx, *window, *n)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(top.Checks)+len(top.Denied))
+	for i, kc := range top.Checks {
+		rows = append(rows, []string{"checks", strconv.Itoa(i + 1), kc.Key, strconv.FormatInt(kc.Count, 10)})
+	}
+	for i, kc := range top.Denied {
+		rows = append(rows, []string{"denied", strconv.Itoa(i + 1), kc.Key, strconv.FormatInt(kc.Count, 10)})
+	}
+	return a.print(top, []string{"BY", "RANK", "KEY", "COUNT"}, rows)
+}
+
+func runUsage(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	window := fs.Duration("window", time.Hour, "time window")
+	key, err := parseKey(fs, args)
+	if err != nil {
+		return err
+	}
+
+	u, err := a.client.Usage(ctx, key, *window)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(u.Points))
+	for _, p := range u.Points {
+		rows = append(rows, []string{
+			p.Time.Local().Format("2006-01-02 15:04"),
+			strconv.FormatInt(p.Allowed, 10),
+			strconv.FormatInt(p.Denied, 10),
+			strconv.FormatInt(p.Tokens, 10),
+			strconv.FormatInt(u.LimitPerMinute, 10),
+		})
+	}
+	return a.print(u, []string{"MINUTE", "ALLOWED", "DENIED", "TOKENS", "LIMIT"}, rows)
+}
+
+// parseKey parses the flags of fs and returns the single key argument
+func parseKey(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() != 1 || fs.Arg(0) == "" {
+		return "", fmt.Errorf("usage: %s <key>", fs.Name())
+	}
+	return fs.Arg(0), nil
+}
@@ -0,0 +1,177 @@
+// Command ratelimitctl manages the rules and buckets of a rate limiter server.
+//
+//	ratelimitctl [global flags] <command> [args]
+//
+// The server address, admin token and namespace are read from the flags, then the
+// RATELIMITCTL_SERVER, RATELIMITCTL_TOKEN and RATELIMITCTL_NAMESPACE environment variables, then
+// the config file (~/.config/ratelimitctl/config.yaml by default):
+//
+//	server: http://localhost:8080
+//	token: <admin token>
+//	namespace: <namespace>
+//	output: table
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/your-org/rate-limiter/client"
+	"gopkg.in/yaml.v3"
+)
+
+// ctlConfig is the config file of ratelimitctl
+type ctlConfig struct {
+	Server    string `yaml:"server"`    // 服务地址
+	Token     string `yaml:"token"`     // 管理员凭证，以 Bearer token 发送
+	Namespace string `yaml:"namespace"` // 命名空间，为空时使用默认命名空间或凭证所属命名空间
+	Output    string `yaml:"output"`    // 输出格式: table 或 json
+}
+
+// command is a ratelimitctl subcommand
+type command struct {
+	usage string
+	help  string
+	run   func(ctx context.Context, app *app, args []string) error
+}
+
+var commands = map[string]command{
+	"check":  {"check [-tokens n] <key>", "Take tokens from the bucket of key and print the decision", runCheck},
+	"peek":   {"peek <key>", "Print the rule and current tokens of key without taking any", runPeek},
+	"set":    {"set <key> <rate> [burst]", "Create or update the rule of key", runSet},
+	"delete": {"delete <key>", "Delete the rule of key, it falls back to the defaults", runDelete},
+	"list":   {"list", "List all rules with their current tokens", runList},
+	"export": {"export [-f file] [-format json|yaml]", "Write all rules to file or stdout", runExport},
+	"import": {"import [-dry-run] [-f file]", "Create or update the rules read from file or stdin (JSON or YAML)", runImport},
+	"reset":  {"reset <key>", "Refill the token bucket of key", runReset},
+	"top":    {"top [-window 5m|1h|24h] [-n 10]", "List the keys with the most checks and denials", runTop},
+	"usage":  {"usage [-window 1h] <key>", "Print the allowed and denied checks per minute of key", runUsage},
+}
+
+var commandOrder = []string{"check", "peek", "set", "delete", "list", "export", "import", "reset", "top", "usage"}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("ratelimitctl", flag.ContinueOnError)
+	fs.Usage = func() { usage(fs) }
+	configPath := fs.String("config", "", "config file (default ~/.config/ratelimitctl/config.yaml, or $RATELIMITCTL_CONFIG)")
+	server := fs.String("server", "", "server address (default http://localhost:8080)")
+	token := fs.String("token", "", "admin token sent as Authorization: Bearer")
+	namespace := fs.String("namespace", "", "namespace (default the default namespace, or the namespace of the token)")
+	output := fs.String("o", "", "output format: table or json (default table)")
+	timeout := fs.Duration("timeout", 10*time.Second, "request timeout")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		usage(fs)
+		return errors.New("command is required")
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		usage(fs)
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	override(&cfg.Server, os.Getenv("RATELIMITCTL_SERVER"), *server)
+	override(&cfg.Token, os.Getenv("RATELIMITCTL_TOKEN"), *token)
+	override(&cfg.Namespace, os.Getenv("RATELIMITCTL_NAMESPACE"), *namespace)
+	override(&cfg.Output, *output)
+	if cfg.Server == "" {
+		cfg.Server = "http://localhost:8080"
+	}
+	if cfg.Output == "" {
+		cfg.Output = "table"
+	}
+	if cfg.Output != "table" && cfg.Output != "json" {
+		return fmt.Errorf("invalid output format %q, must be table or json", cfg.Output)
+	}
+
+	opts := []client.Option{}
+	if cfg.Token != "" {
+		opts = append(opts, client.WithHeader("Authorization", "Bearer "+cfg.Token))
+	}
+	if cfg.Namespace != "" {
+		opts = append(opts, client.WithNamespace(cfg.Namespace))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	a := &app{
+		client: client.New(cfg.Server, opts...),
+		output: cfg.Output,
+		stdout: os.Stdout,
+	}
+	return cmd.run(ctx, a, fs.Args()[1:])
+}
+
+// loadConfig reads the config file. A missing default config file is not an error
+func loadConfig(path string) (*ctlConfig, error) {
+	cfg := &ctlConfig{}
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv("RATELIMITCTL_CONFIG")
+		explicit = path != ""
+	}
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return cfg, nil
+		}
+		path = filepath.Join(dir, "ratelimitctl", "config.yaml")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return cfg, nil
+}
+
+// override sets dst to the last non-empty value
+func override(dst *string, values ...string) {
+	for _, v := range values {
+		if v != "" {
+			*dst = v
+		}
+	}
+}
+
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintln(out, "Usage: ratelimitctl [global flags] <command> [args]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, name := range commandOrder {
+		cmd := commands[name]
+		fmt.Fprintf(out, "  %-40s %s\n", cmd.usage, cmd.help)
+	}
+	fmt.Fprintln(out, "\nGlobal flags:")
+	fs.PrintDefaults()
+}
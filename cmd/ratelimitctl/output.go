@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// print writes v as JSON, or header and rows as an aligned table
+func (a *app) print(v interface{}, header []string, rows [][]string) error {
+	if a.output == "json" {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// done reports a successful change
+func (a *app) done(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if a.output == "json" {
+		return a.print(map[string]string{"status": "success", "message": msg}, nil, nil)
+	}
+	_, err := fmt.Fprintln(a.stdout, msg)
+	return err
+}
@@ -5,19 +5,50 @@ import (
 )
 
 type Config struct {
-	Server  ServerConfig  `yaml:"server"`
-	Redis   RedisConfig   `yaml:"redis"`
-	Limiter LimiterConfig `yaml:"limiter"`
-	Log     LogConfig     `yaml:"log"`
+	Backend     string            `yaml:"backend" default:"redis"` // 存储后端: redis, memory (单机部署/测试)
+	Server      ServerConfig      `yaml:"server"`
+	Redis       RedisConfig       `yaml:"redis"`
+	Memory      MemoryConfig      `yaml:"memory"`
+	Limiter     LimiterConfig     `yaml:"limiter"`
+	RuleCache   RuleCacheConfig   `yaml:"rule_cache"`
+	Log         LogConfig         `yaml:"log"`
+	Envoy       EnvoyConfig       `yaml:"envoy"`
+	ForwardAuth ForwardAuthConfig `yaml:"forward_auth"`
+	Proxy       ProxyConfig       `yaml:"proxy"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Analytics   AnalyticsConfig   `yaml:"analytics"`
+	Usage       UsageConfig       `yaml:"usage"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks"`
+	Events      EventsConfig      `yaml:"events"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Namespaces  []NamespaceConfig `yaml:"namespaces"` // 租户命名空间，各自独立的规则、令牌桶与默认限流
+	Health      HealthConfig      `yaml:"health"`
 }
 
 type ServerConfig struct {
-	Port string `yaml:"port" default:":8080"`
+	Port            string        `yaml:"port" default:":8080"`
+	GRPCPort        string        `yaml:"grpc_port" default:":9090"`      // gRPC 监听地址，留空则不启动 gRPC 服务
+	TLS             TLSConfig     `yaml:"tls"`                            // HTTP、gRPC 与反向代理服务的 TLS/mTLS
+	ShutdownDelay   time.Duration `yaml:"shutdown_delay" default:"5s"`    // 收到停止信号后 /ready 返回 503，等待负载均衡摘除流量的时间
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" default:"30s"` // 等待进行中的请求完成的最长时间，超时后强制关闭连接
+}
+
+// TLSConfig configures TLS on the HTTP, gRPC and proxy servers. Certificate, key and client CA files
+// are reloaded when they change
+type TLSConfig struct {
+	Enabled        bool          `yaml:"enabled" default:"false"`
+	CertFile       string        `yaml:"cert_file"`                    // 证书文件 (PEM)，可包含中间证书
+	KeyFile        string        `yaml:"key_file"`                     // 私钥文件 (PEM)
+	MinVersion     string        `yaml:"min_version" default:"1.2"`    // 最低 TLS 版本: 1.2 或 1.3
+	ClientCAFile   string        `yaml:"client_ca_file"`               // 客户端 CA 文件 (PEM)，设置后要求并校验客户端证书 (mTLS)
+	ReloadInterval time.Duration `yaml:"reload_interval" default:"1m"` // 检查证书文件变化的间隔
 }
 
 type RedisConfig struct {
 	// Single node configuration
 	Addr         string        `yaml:"addr" default:"localhost:6379"`
+	Username     string        `yaml:"username"` // ACL 用户名，留空使用 default 用户
 	Password     string        `yaml:"password"`
 	DB           int           `yaml:"db" default:"0"`
 	PoolSize     int           `yaml:"pool_size" default:"10"`
@@ -26,17 +57,118 @@ type RedisConfig struct {
 	ReadTimeout  time.Duration `yaml:"read_timeout" default:"3s"`
 	WriteTimeout time.Duration `yaml:"write_timeout" default:"3s"`
 
+	// Retries of failed commands
+	MaxRetries      int           `yaml:"max_retries" default:"3"`           // 命令失败后的重试次数，-1 不重试
+	MinRetryBackoff time.Duration `yaml:"min_retry_backoff" default:"8ms"`   // 重试退避下限
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff" default:"512ms"` // 重试退避上限
+
+	TLS RedisTLSConfig `yaml:"tls"`
+
+	Breaker RedisBreakerConfig `yaml:"breaker"`
+
 	// Cluster configuration
 	Cluster *RedisClusterConfig `yaml:"cluster"`
+
+	// Sentinel configuration, exclusive with cluster
+	Sentinel *RedisSentinelConfig `yaml:"sentinel"`
+}
+
+// RedisBreakerConfig configures the circuit breaker failing Redis commands fast while Redis is unavailable
+type RedisBreakerConfig struct {
+	Enabled           bool          `yaml:"enabled" default:"true"`           // 是否启用熔断
+	FailureThreshold  int           `yaml:"failure_threshold" default:"5"`    // 连续失败多少次后熔断
+	SlowCallThreshold time.Duration `yaml:"slow_call_threshold" default:"1s"` // 超过该耗时的命令 (含重试) 计为失败，0 不计
+	OpenTimeout       time.Duration `yaml:"open_timeout" default:"5s"`        // 熔断多久后进入半开状态
+	HalfOpenMaxCalls  int           `yaml:"half_open_max_calls" default:"3"`  // 半开状态放行的探测命令数，全部成功后恢复
 }
 
 type RedisClusterConfig struct {
 	Nodes []string `yaml:"nodes"`
 }
 
+// RedisSentinelConfig connects to the master of a Sentinel monitored group, following failovers
+type RedisSentinelConfig struct {
+	MasterName string   `yaml:"master_name"` // Sentinel 监控的主节点名称
+	Addrs      []string `yaml:"addrs"`       // Sentinel 地址
+	Username   string   `yaml:"username"`    // Sentinel 自身的 ACL 用户名
+	Password   string   `yaml:"password"`    // Sentinel 自身的密码 (与 Redis 的密码分开配置)
+}
+
+// RedisTLSConfig configures TLS on the Redis connections, including the Sentinel connections
+type RedisTLSConfig struct {
+	Enabled            bool   `yaml:"enabled" default:"false"`
+	CAFile             string `yaml:"ca_file"`                   // 校验服务端证书的 CA 文件 (PEM)，留空使用系统 CA
+	CertFile           string `yaml:"cert_file"`                 // 客户端证书 (PEM)，服务端要求客户端证书时配置
+	KeyFile            string `yaml:"key_file"`                  // 客户端私钥 (PEM)
+	ServerName         string `yaml:"server_name"`               // 校验的服务端名称，留空使用地址中的主机名
+	MinVersion         string `yaml:"min_version" default:"1.2"` // 最低 TLS 版本: 1.2 或 1.3
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`      // 不校验服务端证书，仅用于测试
+}
+
+type MemoryConfig struct {
+	CleanupInterval time.Duration `yaml:"cleanup_interval" default:"1m"` // 过期令牌桶清理间隔
+}
+
 type LimiterConfig struct {
-	DefaultRate  int64 `yaml:"default_rate" default:"10"`  // 默认每秒令牌生成速率
-	DefaultBurst int64 `yaml:"default_burst" default:"50"` // 默认桶容量
+	DefaultRate     int64                 `yaml:"default_rate" default:"10"`      // 默认每秒令牌生成速率
+	DefaultBurst    int64                 `yaml:"default_burst" default:"50"`     // 默认桶容量
+	FailurePolicy   string                `yaml:"failure_policy" default:"error"` // 存储 (Redis) 不可用时的策略: error (返回错误), open (放行), closed (拒绝), local (退化为本地内存限流)
+	FailurePolicies []FailurePolicyConfig `yaml:"failure_policies"`               // 按 key 模式覆盖 failure_policy，按顺序首个匹配生效
+}
+
+// FailurePolicyConfig overrides the failure policy of the keys matching Pattern
+type FailurePolicyConfig struct {
+	Pattern string `yaml:"pattern"` // key 模式 (path.Match 语法)，匹配命名空间内的 key
+	Policy  string `yaml:"policy"`  // error, open, closed 或 local
+}
+
+// RuleCacheConfig configures the in-process cache of the rules read from Redis
+type RuleCacheConfig struct {
+	Enabled    bool          `yaml:"enabled" default:"false"`             // 是否在进程内缓存规则
+	TTL        time.Duration `yaml:"ttl" default:"10s"`                   // 缓存有效期，未收到失效通知时规则变更的最长生效延迟
+	MaxEntries int           `yaml:"max_entries" default:"100000"`        // 最多缓存的 key 数 (含无规则的 key)
+	Channel    string        `yaml:"channel" default:"ratelimiter:rules"` // 广播规则变更的 Redis Pub/Sub 频道
+}
+
+// EnvoyConfig configures the Envoy RLS v3 service served on the gRPC port
+type EnvoyConfig struct {
+	Enabled          bool   `yaml:"enabled" default:"true"`             // 是否在 gRPC 端口注册 envoy.service.ratelimit.v3.RateLimitService
+	DomainPrefix     bool   `yaml:"domain_prefix" default:"false"`      // 是否以请求的 domain 作为 key 前缀
+	IncludeEntryKeys bool   `yaml:"include_entry_keys" default:"false"` // 是否在 key 中包含描述符条目名 (key=value)
+	Separator        string `yaml:"separator" default:":"`              // 描述符条目之间的分隔符
+	DomainNamespace  bool   `yaml:"domain_namespace" default:"false"`   // 未携带 x-namespace 元数据时是否以请求的 domain 作为命名空间
+}
+
+// ForwardAuthConfig configures the nginx auth_request / forward-auth endpoint
+type ForwardAuthConfig struct {
+	KeyHeaders []string `yaml:"key_headers"`           // 组成限流 key 的请求头，按顺序拼接，默认 X-Api-Key, X-Model
+	Separator  string   `yaml:"separator" default:":"` // 请求头取值之间的分隔符
+}
+
+// ProxyConfig configures the rate limiting reverse proxy mode
+type ProxyConfig struct {
+	Enabled bool               `yaml:"enabled" default:"false"` // 是否启动反向代理
+	Port    string             `yaml:"port" default:":8081"`    // 反向代理监听地址
+	Routes  []ProxyRouteConfig `yaml:"routes"`                  // 路由列表，按最长路径前缀匹配
+}
+
+// ProxyRouteConfig maps a path prefix to an upstream and describes how requests are keyed
+type ProxyRouteConfig struct {
+	Name        string               `yaml:"name"`                  // 路由名称，用于日志
+	PathPrefix  string               `yaml:"path_prefix"`           // 匹配的路径前缀
+	Upstream    string               `yaml:"upstream"`              // 上游地址，如 http://backend:8000
+	StripPrefix bool                 `yaml:"strip_prefix"`          // 转发前是否去掉路径前缀
+	KeyPrefix   string               `yaml:"key_prefix"`            // 限流 key 前缀（可选）
+	Separator   string               `yaml:"separator" default:":"` // key 各部分之间的分隔符
+	Key         []KeyExtractorConfig `yaml:"key"`                   // 组成限流 key 的提取器，按顺序拼接
+}
+
+// KeyExtractorConfig describes how to extract one part of a rate limiting key from a request
+type KeyExtractorConfig struct {
+	Type   string `yaml:"type"`   // header, path_segment, client_ip, jwt_claim, static
+	Name   string `yaml:"name"`   // header: 请求头名; jwt_claim: claim 名 (支持 a.b 嵌套); static: 固定值
+	Index  int    `yaml:"index"`  // path_segment: 路径段下标 (从 0 开始)
+	Header string `yaml:"header"` // client_ip: 取 IP 的请求头 (如 X-Forwarded-For); jwt_claim: 携带 token 的请求头 (默认 Authorization)
 }
 
 type LogConfig struct {
@@ -49,3 +181,103 @@ type LogConfig struct {
 	MaxAge     int    `yaml:"max_age" default:"30"`     // 日志文件保留天数
 	Compress   bool   `yaml:"compress" default:"true"`  // 是否压缩旧日志文件
 }
+
+// MetricsConfig configures the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Enabled     bool     `yaml:"enabled" default:"true"`  // 是否在 HTTP 端口暴露 Prometheus 指标
+	Path        string   `yaml:"path" default:"/metrics"` // 指标路径
+	KeyPatterns []string `yaml:"key_patterns"`            // 限流决策指标的 key 模式 (path.Match 语法, 如 "*:gpt-4")，未匹配的 key 计入 "other"
+}
+
+// TracingConfig configures OpenTelemetry tracing
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled" default:"false"`           // 是否启用链路追踪
+	Exporter    string  `yaml:"exporter" default:"otlp"`           // 导出器: otlp, stdout
+	Protocol    string  `yaml:"protocol" default:"grpc"`           // OTLP 协议: grpc, http
+	Endpoint    string  `yaml:"endpoint" default:"localhost:4317"` // OTLP collector 地址 (host:port)
+	Insecure    bool    `yaml:"insecure" default:"true"`           // 是否不使用 TLS 连接 collector
+	SampleRatio float64 `yaml:"sample_ratio" default:"1"`          // 采样率 (0-1)，调用方已采样的请求始终跟随调用方
+	ServiceName string  `yaml:"service_name" default:"rate-limiter"`
+}
+
+// AnalyticsConfig configures the hot key and top denied key analytics
+type AnalyticsConfig struct {
+	Enabled          bool          `yaml:"enabled" default:"false"`            // 是否统计各 key 的检查/拒绝次数
+	FlushInterval    time.Duration `yaml:"flush_interval" default:"10s"`       // 本地计数写入存储的间隔
+	MaxKeysPerBucket int64         `yaml:"max_keys_per_bucket" default:"1000"` // 每个时间桶保留的最多 key 数 (按次数保留前 N 个)
+}
+
+// UsageConfig configures the per-key usage history
+type UsageConfig struct {
+	Enabled       bool          `yaml:"enabled" default:"false"`      // 是否记录每个 key 每分钟的允许/拒绝次数
+	Retention     time.Duration `yaml:"retention" default:"24h"`      // 历史保留时长，也是可查询的最大时间范围
+	FlushInterval time.Duration `yaml:"flush_interval" default:"10s"` // 本地计数写入存储的间隔
+}
+
+// WebhooksConfig configures the webhook notifications on limit events
+type WebhooksConfig struct {
+	Enabled     bool              `yaml:"enabled" default:"false"`   // 是否发送 webhook 通知
+	Endpoints   []WebhookEndpoint `yaml:"endpoints"`                 // 接收通知的地址
+	Thresholds  []float64         `yaml:"thresholds"`                // 配额使用比例阈值 (0-1]，默认 [0.8, 1]
+	DedupWindow time.Duration     `yaml:"dedup_window" default:"5m"` // 同一 key 的同一事件在窗口内只通知一次
+	Timeout     time.Duration     `yaml:"timeout" default:"5s"`      // 单次投递超时
+	MaxAttempts int               `yaml:"max_attempts" default:"8"`  // 最多投递次数，超过后移入死信队列
+	MinBackoff  time.Duration     `yaml:"min_backoff" default:"1s"`  // 首次重试间隔，之后指数增长
+	MaxBackoff  time.Duration     `yaml:"max_backoff" default:"5m"`  // 最大重试间隔
+	Workers     int               `yaml:"workers" default:"2"`       // 投递并发数
+}
+
+// WebhookEndpoint is a receiver of webhook notifications
+type WebhookEndpoint struct {
+	URL    string   `yaml:"url"`    // 接收地址
+	Secret string   `yaml:"secret"` // HMAC-SHA256 签名密钥
+	Events []string `yaml:"events"` // 订阅的事件: key.throttled, key.quota_threshold, rule.changed，留空订阅全部
+}
+
+// EventsConfig configures the live stream of rate limit decisions
+type EventsConfig struct {
+	Enabled    bool    `yaml:"enabled" default:"false"`              // 是否发布限流决策事件并提供 /v1/events
+	Channel    string  `yaml:"channel" default:"ratelimiter:events"` // Redis Pub/Sub 频道
+	SampleRate float64 `yaml:"sample_rate" default:"0"`              // 发布的允许决策比例 (0-1)，有订阅者时拒绝决策始终发布
+}
+
+// AuthConfig configures the authentication of the HTTP and gRPC APIs
+type AuthConfig struct {
+	Enabled bool           `yaml:"enabled" default:"false"`        // 是否要求认证
+	Header  string         `yaml:"header" default:"Authorization"` // 携带凭证的请求头，值为 "Bearer <token>" 或 token
+	APIKeys []APIKeyConfig `yaml:"api_keys"`                       // 静态 API key
+	JWT     JWTConfig      `yaml:"jwt"`
+}
+
+// APIKeyConfig is a static API key and its roles
+type APIKeyConfig struct {
+	Name      string   `yaml:"name"`      // 名称，用于日志
+	Key       string   `yaml:"key"`       // 密钥
+	Roles     []string `yaml:"roles"`     // 角色: check, viewer, admin
+	Namespace string   `yaml:"namespace"` // 限定可访问的命名空间，留空仅默认命名空间，"*" 可访问全部
+}
+
+// JWTConfig configures the verification of JWT bearer tokens
+type JWTConfig struct {
+	Enabled         bool          `yaml:"enabled" default:"false"`
+	JWKSFile        string        `yaml:"jwks_file"`                           // JWKS 文件路径，文件变化后自动重新加载
+	Issuer          string        `yaml:"issuer"`                              // 要求的 iss，留空不校验
+	Audience        string        `yaml:"audience"`                            // 要求的 aud，留空不校验
+	RolesClaim      string        `yaml:"roles_claim" default:"roles"`         // 角色所在的 claim (支持 a.b 嵌套)，值为数组或空格分隔的字符串
+	NamespaceClaim  string        `yaml:"namespace_claim" default:"namespace"` // 命名空间所在的 claim，值为 "*" 可访问全部；配置了 namespaces 时必须携带
+	RefreshInterval time.Duration `yaml:"refresh_interval" default:"1m"`       // 检查 JWKS 文件变化的间隔
+}
+
+// NamespaceConfig is a tenant namespace. Its rules and buckets live under the Redis key prefix
+// ns:<name>:, and its defaults override LimiterConfig
+type NamespaceConfig struct {
+	Name         string `yaml:"name"`          // 名称: 小写字母、数字、- 和 _
+	DefaultRate  int64  `yaml:"default_rate"`  // 默认每秒令牌数，0 则使用 limiter.default_rate
+	DefaultBurst int64  `yaml:"default_burst"` // 默认桶容量，0 则使用 limiter.default_burst
+}
+
+// HealthConfig configures the readiness checks of /ready
+type HealthConfig struct {
+	CacheTTL time.Duration `yaml:"cache_ttl" default:"2s"` // 检查结果的缓存时间，避免探针频繁访问 Redis
+	Timeout  time.Duration `yaml:"timeout" default:"1s"`   // 单次检查的超时时间
+}
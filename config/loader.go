@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -33,7 +34,13 @@ func LoadConfig(configPath string) error {
 }
 
 func setDefaults(config *Config) {
+	config.Backend = "redis"
 	config.Server.Port = ":8080"
+	config.Server.GRPCPort = ":9090"
+	config.Server.ShutdownDelay = 5 * time.Second
+	config.Server.ShutdownTimeout = 30 * time.Second
+	config.Server.TLS.MinVersion = "1.2"
+	config.Server.TLS.ReloadInterval = time.Minute
 	config.Redis.Addr = "localhost:6379"
 	config.Redis.DB = 0
 	config.Redis.PoolSize = 10
@@ -41,8 +48,53 @@ func setDefaults(config *Config) {
 	config.Redis.DialTimeout = 5 * time.Second
 	config.Redis.ReadTimeout = 3 * time.Second
 	config.Redis.WriteTimeout = 3 * time.Second
+	config.Redis.MaxRetries = 3
+	config.Redis.MinRetryBackoff = 8 * time.Millisecond
+	config.Redis.MaxRetryBackoff = 512 * time.Millisecond
+	config.Redis.TLS.MinVersion = "1.2"
+	config.Redis.Breaker.Enabled = true
+	config.Redis.Breaker.FailureThreshold = 5
+	config.Redis.Breaker.SlowCallThreshold = time.Second
+	config.Redis.Breaker.OpenTimeout = 5 * time.Second
+	config.Redis.Breaker.HalfOpenMaxCalls = 3
+	config.Memory.CleanupInterval = time.Minute
 	config.Limiter.DefaultRate = 10
 	config.Limiter.DefaultBurst = 50
+	config.Limiter.FailurePolicy = "error"
+	config.Envoy.Enabled = true
+	config.Envoy.Separator = ":"
+	config.ForwardAuth.KeyHeaders = []string{"X-Api-Key", "X-Model"}
+	config.ForwardAuth.Separator = ":"
+	config.Proxy.Port = ":8081"
+	config.Metrics.Enabled = true
+	config.Metrics.Path = "/metrics"
+	config.Tracing.Exporter = "otlp"
+	config.Tracing.Protocol = "grpc"
+	config.Tracing.Endpoint = "localhost:4317"
+	config.Tracing.Insecure = true
+	config.Tracing.SampleRatio = 1
+	config.Tracing.ServiceName = "rate-limiter"
+	config.Analytics.FlushInterval = 10 * time.Second
+	config.Analytics.MaxKeysPerBucket = 1000
+	config.Usage.Retention = 24 * time.Hour
+	config.Usage.FlushInterval = 10 * time.Second
+	config.Webhooks.Thresholds = []float64{0.8, 1}
+	config.Webhooks.DedupWindow = 5 * time.Minute
+	config.Webhooks.Timeout = 5 * time.Second
+	config.Webhooks.MaxAttempts = 8
+	config.Webhooks.MinBackoff = time.Second
+	config.Webhooks.MaxBackoff = 5 * time.Minute
+	config.Webhooks.Workers = 2
+	config.Events.Channel = "ratelimiter:events"
+	config.RuleCache.TTL = 10 * time.Second
+	config.RuleCache.MaxEntries = 100000
+	config.RuleCache.Channel = "ratelimiter:rules"
+	config.Auth.Header = "Authorization"
+	config.Auth.JWT.RolesClaim = "roles"
+	config.Auth.JWT.NamespaceClaim = "namespace"
+	config.Auth.JWT.RefreshInterval = time.Minute
+	config.Health.CacheTTL = 2 * time.Second
+	config.Health.Timeout = time.Second
 	config.Log.Level = "info"
 	config.Log.Format = "json"
 	config.Log.Output = "stdout"
@@ -63,15 +115,50 @@ func loadFromFile(configPath string, config *Config) error {
 }
 
 func loadFromEnv(config *Config) {
+	// Storage backend
+	if backend := os.Getenv("BACKEND"); backend != "" {
+		config.Backend = backend
+	}
+
 	// Server configuration
 	if port := os.Getenv("SERVER_PORT"); port != "" {
 		config.Server.Port = port
 	}
+	if grpcPort := os.Getenv("GRPC_PORT"); grpcPort != "" {
+		config.Server.GRPCPort = grpcPort
+	}
+	if delay := os.Getenv("SERVER_SHUTDOWN_DELAY"); delay != "" {
+		if delayDuration, err := time.ParseDuration(delay); err == nil {
+			config.Server.ShutdownDelay = delayDuration
+		}
+	}
+	if timeout := os.Getenv("SERVER_SHUTDOWN_TIMEOUT"); timeout != "" {
+		if timeoutDuration, err := time.ParseDuration(timeout); err == nil {
+			config.Server.ShutdownTimeout = timeoutDuration
+		}
+	}
+	if enabled := os.Getenv("SERVER_TLS_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Server.TLS.Enabled = enabledBool
+		}
+	}
+	if certFile := os.Getenv("SERVER_TLS_CERT_FILE"); certFile != "" {
+		config.Server.TLS.CertFile = certFile
+	}
+	if keyFile := os.Getenv("SERVER_TLS_KEY_FILE"); keyFile != "" {
+		config.Server.TLS.KeyFile = keyFile
+	}
+	if caFile := os.Getenv("SERVER_TLS_CLIENT_CA_FILE"); caFile != "" {
+		config.Server.TLS.ClientCAFile = caFile
+	}
 
 	// Redis configuration
 	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
 		config.Redis.Addr = addr
 	}
+	if username := os.Getenv("REDIS_USERNAME"); username != "" {
+		config.Redis.Username = username
+	}
 	if password := os.Getenv("REDIS_PASSWORD"); password != "" {
 		config.Redis.Password = password
 	}
@@ -85,6 +172,46 @@ func loadFromEnv(config *Config) {
 			config.Redis.PoolSize = poolSizeInt
 		}
 	}
+	if maxRetries := os.Getenv("REDIS_MAX_RETRIES"); maxRetries != "" {
+		if maxRetriesInt, err := strconv.Atoi(maxRetries); err == nil {
+			config.Redis.MaxRetries = maxRetriesInt
+		}
+	}
+	if enabled := os.Getenv("REDIS_BREAKER_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Redis.Breaker.Enabled = enabledBool
+		}
+	}
+	if threshold := os.Getenv("REDIS_BREAKER_FAILURE_THRESHOLD"); threshold != "" {
+		if thresholdInt, err := strconv.Atoi(threshold); err == nil {
+			config.Redis.Breaker.FailureThreshold = thresholdInt
+		}
+	}
+	if timeout := os.Getenv("REDIS_BREAKER_OPEN_TIMEOUT"); timeout != "" {
+		if timeoutDuration, err := time.ParseDuration(timeout); err == nil {
+			config.Redis.Breaker.OpenTimeout = timeoutDuration
+		}
+	}
+	if enabled := os.Getenv("REDIS_TLS_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Redis.TLS.Enabled = enabledBool
+		}
+	}
+	if caFile := os.Getenv("REDIS_TLS_CA_FILE"); caFile != "" {
+		config.Redis.TLS.CAFile = caFile
+	}
+	if master := os.Getenv("REDIS_SENTINEL_MASTER"); master != "" {
+		if config.Redis.Sentinel == nil {
+			config.Redis.Sentinel = &RedisSentinelConfig{}
+		}
+		config.Redis.Sentinel.MasterName = master
+	}
+	if addrs := os.Getenv("REDIS_SENTINEL_ADDRS"); addrs != "" {
+		if config.Redis.Sentinel == nil {
+			config.Redis.Sentinel = &RedisSentinelConfig{}
+		}
+		config.Redis.Sentinel.Addrs = strings.Split(addrs, ",")
+	}
 
 	// Limiter configuration
 	if rate := os.Getenv("DEFAULT_RATE"); rate != "" {
@@ -97,6 +224,123 @@ func loadFromEnv(config *Config) {
 			config.Limiter.DefaultBurst = burstInt
 		}
 	}
+	if policy := os.Getenv("LIMITER_FAILURE_POLICY"); policy != "" {
+		config.Limiter.FailurePolicy = policy
+	}
+
+	// Envoy configuration
+	if enabled := os.Getenv("ENVOY_RLS_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Envoy.Enabled = enabledBool
+		}
+	}
+
+	// Forward auth configuration
+	if headers := os.Getenv("FORWARD_AUTH_KEY_HEADERS"); headers != "" {
+		config.ForwardAuth.KeyHeaders = strings.Split(headers, ",")
+		for i, h := range config.ForwardAuth.KeyHeaders {
+			config.ForwardAuth.KeyHeaders[i] = strings.TrimSpace(h)
+		}
+	}
+
+	// Proxy configuration
+	if enabled := os.Getenv("PROXY_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Proxy.Enabled = enabledBool
+		}
+	}
+	if port := os.Getenv("PROXY_PORT"); port != "" {
+		config.Proxy.Port = port
+	}
+
+	// Metrics configuration
+	if enabled := os.Getenv("METRICS_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Metrics.Enabled = enabledBool
+		}
+	}
+
+	// Tracing configuration
+	if enabled := os.Getenv("TRACING_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Tracing.Enabled = enabledBool
+		}
+	}
+	if exporter := os.Getenv("TRACING_EXPORTER"); exporter != "" {
+		config.Tracing.Exporter = exporter
+	}
+	if endpoint := os.Getenv("TRACING_ENDPOINT"); endpoint != "" {
+		config.Tracing.Endpoint = endpoint
+	}
+	if ratio := os.Getenv("TRACING_SAMPLE_RATIO"); ratio != "" {
+		if ratioFloat, err := strconv.ParseFloat(ratio, 64); err == nil {
+			config.Tracing.SampleRatio = ratioFloat
+		}
+	}
+
+	// Analytics configuration
+	if enabled := os.Getenv("ANALYTICS_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Analytics.Enabled = enabledBool
+		}
+	}
+
+	// Usage history configuration
+	if enabled := os.Getenv("USAGE_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Usage.Enabled = enabledBool
+		}
+	}
+	if retention := os.Getenv("USAGE_RETENTION"); retention != "" {
+		if retentionDuration, err := time.ParseDuration(retention); err == nil {
+			config.Usage.Retention = retentionDuration
+		}
+	}
+
+	// Webhooks configuration
+	if enabled := os.Getenv("WEBHOOKS_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Webhooks.Enabled = enabledBool
+		}
+	}
+
+	// Rule cache configuration
+	if enabled := os.Getenv("RULE_CACHE_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.RuleCache.Enabled = enabledBool
+		}
+	}
+	if ttl := os.Getenv("RULE_CACHE_TTL"); ttl != "" {
+		if ttlDuration, err := time.ParseDuration(ttl); err == nil {
+			config.RuleCache.TTL = ttlDuration
+		}
+	}
+
+	// Events configuration
+	if enabled := os.Getenv("EVENTS_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Events.Enabled = enabledBool
+		}
+	}
+	if rate := os.Getenv("EVENTS_SAMPLE_RATE"); rate != "" {
+		if rateFloat, err := strconv.ParseFloat(rate, 64); err == nil {
+			config.Events.SampleRate = rateFloat
+		}
+	}
+
+	// Auth configuration
+	if enabled := os.Getenv("AUTH_ENABLED"); enabled != "" {
+		if enabledBool, err := strconv.ParseBool(enabled); err == nil {
+			config.Auth.Enabled = enabledBool
+		}
+	}
+	if adminKey := os.Getenv("AUTH_ADMIN_KEY"); adminKey != "" {
+		config.Auth.APIKeys = append(config.Auth.APIKeys, APIKeyConfig{Name: "env-admin", Key: adminKey, Roles: []string{"admin"}, Namespace: "*"})
+	}
+	if jwksFile := os.Getenv("AUTH_JWKS_FILE"); jwksFile != "" {
+		config.Auth.JWT.Enabled = true
+		config.Auth.JWT.JWKSFile = jwksFile
+	}
 
 	// Log configuration
 	if level := os.Getenv("LOG_LEVEL"); level != "" {
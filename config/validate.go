@@ -0,0 +1,122 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// failurePolicies are the valid limiter failure policies
+var failurePolicies = map[string]bool{"error": true, "open": true, "closed": true, "local": true}
+
+// Validate checks the values the service cannot run correctly with, returning all the problems
+// found joined in one error
+func Validate(config *Config) error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if config.Backend != "redis" && config.Backend != "memory" {
+		add("backend: unknown backend %q, must be redis or memory", config.Backend)
+	}
+	if config.Server.Port == "" {
+		add("server.port: is required")
+	}
+	if config.Server.ShutdownDelay < 0 || config.Server.ShutdownTimeout < 0 {
+		add("server: shutdown_delay and shutdown_timeout must not be negative")
+	}
+	if tls := config.Server.TLS; tls.Enabled && (tls.CertFile == "" || tls.KeyFile == "") {
+		add("server.tls: cert_file and key_file are required")
+	}
+
+	if config.Backend == "redis" {
+		redis := config.Redis
+		cluster := redis.Cluster != nil && len(redis.Cluster.Nodes) > 0
+		sentinel := redis.Sentinel != nil && (redis.Sentinel.MasterName != "" || len(redis.Sentinel.Addrs) > 0)
+		if cluster && sentinel {
+			add("redis: cluster and sentinel are exclusive")
+		}
+		if sentinel && (redis.Sentinel.MasterName == "" || len(redis.Sentinel.Addrs) == 0) {
+			add("redis.sentinel: master_name and addrs are required")
+		}
+		if !cluster && !sentinel && redis.Addr == "" {
+			add("redis.addr: is required")
+		}
+		if redis.PoolSize < 0 {
+			add("redis.pool_size: must not be negative")
+		}
+		if (redis.TLS.CertFile == "") != (redis.TLS.KeyFile == "") {
+			add("redis.tls: cert_file and key_file go together")
+		}
+		if b := redis.Breaker; b.Enabled && (b.FailureThreshold <= 0 || b.OpenTimeout <= 0 || b.HalfOpenMaxCalls <= 0) {
+			add("redis.breaker: failure_threshold, open_timeout and half_open_max_calls must be greater than 0")
+		}
+		if redis.Breaker.SlowCallThreshold < 0 {
+			add("redis.breaker.slow_call_threshold: must not be negative")
+		}
+	}
+
+	if config.Limiter.DefaultRate <= 0 {
+		add("limiter.default_rate: must be greater than 0")
+	}
+	if config.Limiter.DefaultBurst <= 0 {
+		add("limiter.default_burst: must be greater than 0")
+	}
+	if !failurePolicies[config.Limiter.FailurePolicy] {
+		add("limiter.failure_policy: unknown policy %q, must be error, open, closed or local", config.Limiter.FailurePolicy)
+	}
+	for i, p := range config.Limiter.FailurePolicies {
+		if _, err := path.Match(p.Pattern, ""); err != nil || p.Pattern == "" {
+			add("limiter.failure_policies[%d].pattern: invalid pattern %q", i, p.Pattern)
+		}
+		if !failurePolicies[p.Policy] {
+			add("limiter.failure_policies[%d].policy: unknown policy %q, must be error, open, closed or local", i, p.Policy)
+		}
+	}
+	// Forward auth is always served, an empty key would put every request in one bucket
+	if len(config.ForwardAuth.KeyHeaders) == 0 {
+		add("forward_auth.key_headers: at least one header is required")
+	}
+	for i, h := range config.ForwardAuth.KeyHeaders {
+		if strings.TrimSpace(h) == "" {
+			add("forward_auth.key_headers[%d]: must not be empty", i)
+		}
+	}
+	for i, ns := range config.Namespaces {
+		if ns.Name == "" {
+			add("namespaces[%d].name: is required", i)
+		}
+		if ns.DefaultRate < 0 || ns.DefaultBurst < 0 {
+			add("namespaces[%d]: default_rate and default_burst must not be negative", i)
+		}
+	}
+
+	if c := config.RuleCache; c.Enabled && (c.TTL <= 0 || c.MaxEntries <= 0 || c.Channel == "") {
+		add("rule_cache: ttl and max_entries must be greater than 0 and channel is required")
+	}
+
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio: must be between 0 and 1")
+	}
+	if config.Analytics.Enabled && (config.Analytics.FlushInterval <= 0 || config.Analytics.MaxKeysPerBucket <= 0) {
+		add("analytics: flush_interval and max_keys_per_bucket must be greater than 0")
+	}
+	if config.Usage.Enabled && (config.Usage.FlushInterval <= 0 || config.Usage.Retention <= 0) {
+		add("usage: flush_interval and retention must be greater than 0")
+	}
+	if config.Events.SampleRate < 0 || config.Events.SampleRate > 1 {
+		add("events.sample_rate: must be between 0 and 1")
+	}
+	for _, t := range config.Webhooks.Thresholds {
+		if t <= 0 || t > 1 {
+			add("webhooks.thresholds: %v must be in (0, 1]", t)
+		}
+	}
+	if config.Health.CacheTTL < 0 || config.Health.Timeout <= 0 {
+		add("health: cache_ttl must not be negative and timeout must be greater than 0")
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateForwardAuthKeyHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		wantErr string
+	}{
+		{name: "defaults", headers: []string{"X-Api-Key", "X-Model"}},
+		{name: "empty", headers: nil, wantErr: "forward_auth.key_headers: at least one header is required"},
+		{name: "blank header", headers: []string{"X-Api-Key", " "}, wantErr: "forward_auth.key_headers[1]: must not be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{}
+			setDefaults(cfg)
+			cfg.ForwardAuth.KeyHeaders = tt.headers
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
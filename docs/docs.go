@@ -26,6 +26,11 @@ const docTemplate = `{
     "paths": {
         "/v1/check_rate_limit": {
             "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "description": "Check if the current request is allowed based on rate limiting rules",
                 "consumes": [
                     "application/json"
@@ -46,13 +51,583 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handler.CheckReq"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Namespace, the default namespace or the namespace of the credentials when absent",
+                        "name": "X-Namespace",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CheckResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role or namespace not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Namespace not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/delete_rule": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete the rate limiting rule of a key, which falls back to the default rate and burst",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rate-limit"
+                ],
+                "summary": "Delete rate limiting rule",
+                "parameters": [
+                    {
+                        "description": "Rate limiting rule delete request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.DeleteRuleReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Namespace, the default namespace or the namespace of the credentials when absent",
+                        "name": "X-Namespace",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.DeleteRuleResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role or namespace not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Rule or namespace not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stream the rate limit decisions of all instances as server-sent events. Each \"decision\" event carries an events.Event in JSON. Denied decisions are always published; allowed decisions only with events.sample_rate \u003e 0, and only to subscribers passing allowed=true. A \"dropped\" event reports events skipped because the subscriber was too slow. Keys are qualified with their namespace prefix; in a named namespace only its keys are streamed and prefix applies within it",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Stream decision events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only keys starting with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the sampled allowed decisions (default false, denied only)",
+                        "name": "allowed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Share of the allowed decisions to keep, in (0, 1] (default 1)",
+                        "name": "sample",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Namespace, the default namespace or the namespace of the credentials when absent",
+                        "name": "X-Namespace",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/events.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role or namespace not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Event stream or namespace not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/forward_auth": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Check rate limit with the key derived from the configured request headers (default X-Api-Key and X-Model joined by \":\"). Designed for nginx auth_request and Traefik/Caddy forward-auth: no request body, 204 if allowed, 429 if rate limited, with RateLimit-* headers in both cases",
+                "tags": [
+                    "rate-limit"
+                ],
+                "summary": "Forward-auth rate limit check",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key (default key header)",
+                        "name": "X-Api-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Model (default key header)",
+                        "name": "X-Model",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Namespace, the default namespace or the namespace of the credentials when absent",
+                        "name": "X-Namespace",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Request allowed",
+                        "headers": {
+                            "RateLimit-Limit": {
+                                "type": "integer",
+                                "description": "Bucket capacity"
+                            },
+                            "RateLimit-Remaining": {
+                                "type": "integer",
+                                "description": "Remaining tokens"
+                            },
+                            "RateLimit-Reset": {
+                                "type": "integer",
+                                "description": "Seconds until the bucket is full again"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Missing key headers",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role or namespace not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Namespace not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "headers": {
+                            "RateLimit-Limit": {
+                                "type": "integer",
+                                "description": "Bucket capacity"
+                            },
+                            "RateLimit-Remaining": {
+                                "type": "integer",
+                                "description": "Remaining tokens"
+                            },
+                            "RateLimit-Reset": {
+                                "type": "integer",
+                                "description": "Seconds until the bucket is full again"
+                            },
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "Seconds until a token is available"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/reset_bucket": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Refill the token bucket of a key, e.g. after a rule change or an incident",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rate-limit"
+                ],
+                "summary": "Reset token bucket",
+                "parameters": [
+                    {
+                        "description": "Token bucket reset request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ResetBucketReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Namespace, the default namespace or the namespace of the credentials when absent",
+                        "name": "X-Namespace",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ResetBucketResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role or namespace not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/rule_stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get monitoring statistics for a specific rate limiting key",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Get specific rule statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rate limiting key",
+                        "name": "key",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Namespace, the default namespace or the namespace of the credentials when absent",
+                        "name": "X-Namespace",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Missing required parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role or namespace not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Namespace not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get comprehensive monitoring statistics for all rate limiting rules",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Get all monitoring statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace, the default namespace or the namespace of the credentials when absent",
+                        "name": "X-Namespace",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.StatsResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role or namespace not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Namespace not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/top_keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the keys with the most checks and the most denied checks over the last 5 minutes, hour or day. Counts are approximate: they are flushed periodically and each time bucket keeps its top keys only. Keys are qualified with their namespace prefix; in a named namespace only its keys are returned",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Get top keys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Time window: 5m, 1h or 24h (default 5m)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of keys, 1 to 100 (default 10)",
+                        "name": "n",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Namespace, the default namespace or the namespace of the credentials when absent",
+                        "name": "X-Namespace",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.CheckResp"
+                            "$ref": "#/definitions/handler.TopKeysResp"
                         }
                     },
                     "400": {
@@ -62,6 +637,27 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role or namespace not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Analytics or namespace not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -72,9 +668,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/rule_stats": {
-            "get": {
-                "description": "Get monitoring statistics for a specific rate limiting key",
+        "/v1/update_rule": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update or create a new rate limiting rule for the specified API key and model",
                 "consumes": [
                     "application/json"
                 ],
@@ -82,61 +683,59 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "monitoring"
+                    "rate-limit"
                 ],
-                "summary": "Get specific rule statistics",
+                "summary": "Update rate limiting rule",
                 "parameters": [
+                    {
+                        "description": "Rate limiting rule update request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateRuleReq"
+                        }
+                    },
                     {
                         "type": "string",
-                        "description": "Rate limiting key",
-                        "name": "key",
-                        "in": "query",
-                        "required": true
+                        "description": "Namespace, the default namespace or the namespace of the credentials when absent",
+                        "name": "X-Namespace",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateRuleResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Missing required parameters",
+                    "401": {
+                        "description": "Missing or invalid credentials",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Role or namespace not allowed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    }
-                }
-            }
-        },
-        "/v1/stats": {
-            "get": {
-                "description": "Get comprehensive monitoring statistics for all rate limiting rules",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "monitoring"
-                ],
-                "summary": "Get all monitoring statistics",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Namespace not found",
                         "schema": {
-                            "$ref": "#/definitions/handler.StatsResp"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
@@ -149,35 +748,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/update_rule": {
-            "post": {
-                "description": "Update or create a new rate limiting rule for the specified API key and model",
-                "consumes": [
-                    "application/json"
+        "/v1/usage": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Get the allowed and denied checks and the tokens taken per minute for a key over a window, together with its current rule. Minutes without checks have zero counts; the latest minute may lag by the flush interval",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rate-limit"
+                    "monitoring"
                 ],
-                "summary": "Update rate limiting rule",
+                "summary": "Get usage history",
                 "parameters": [
                     {
-                        "description": "Rate limiting rule update request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handler.UpdateRuleReq"
-                        }
+                        "type": "string",
+                        "description": "Rate limiting key",
+                        "name": "key",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time window as a duration, e.g. 15m or 6h (default 1h, at most the retention)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Namespace, the default namespace or the namespace of the credentials when absent",
+                        "name": "X-Namespace",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.UpdateRuleResp"
+                            "$ref": "#/definitions/handler.UsageResp"
                         }
                     },
                     "400": {
@@ -187,6 +798,27 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Role or namespace not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Usage history or namespace not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -199,6 +831,56 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "analytics.KeyCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "key": {
+                    "type": "string",
+                    "example": "your_api_key:gpt-4"
+                }
+            }
+        },
+        "events.Event": {
+            "type": "object",
+            "properties": {
+                "allowed": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "burst": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "degraded": {
+                    "type": "string",
+                    "example": ""
+                },
+                "key": {
+                    "type": "string",
+                    "example": "your_api_key:gpt-4"
+                },
+                "rate": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "remain": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "requested": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                }
+            }
+        },
         "handler.CheckReq": {
             "type": "object",
             "required": [
@@ -209,6 +891,11 @@ const docTemplate = `{
                     "description": "Rate limiting key (user-defined format)",
                     "type": "string",
                     "example": "your_api_key:gpt-4"
+                },
+                "tokens": {
+                    "description": "Number of tokens to take at once, optional (defaults to 1)",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
@@ -220,6 +907,11 @@ const docTemplate = `{
                     "type": "boolean",
                     "example": true
                 },
+                "degraded": {
+                    "description": "Failure policy (open, closed, local) that decided the check while the store was unavailable",
+                    "type": "string",
+                    "example": ""
+                },
                 "message": {
                     "description": "Error message (if any)",
                     "type": "string",
@@ -232,6 +924,62 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.DeleteRuleReq": {
+            "type": "object",
+            "required": [
+                "key"
+            ],
+            "properties": {
+                "key": {
+                    "description": "Rate limiting key",
+                    "type": "string",
+                    "example": "your_api_key:gpt-4"
+                }
+            }
+        },
+        "handler.DeleteRuleResp": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "description": "Response message",
+                    "type": "string",
+                    "example": "Rule deleted"
+                },
+                "status": {
+                    "description": "Status of the operation",
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
+        "handler.ResetBucketReq": {
+            "type": "object",
+            "required": [
+                "key"
+            ],
+            "properties": {
+                "key": {
+                    "description": "Rate limiting key",
+                    "type": "string",
+                    "example": "your_api_key:gpt-4"
+                }
+            }
+        },
+        "handler.ResetBucketResp": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "description": "Response message",
+                    "type": "string",
+                    "example": "Bucket reset"
+                },
+                "status": {
+                    "description": "Status of the operation",
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
         "handler.StatsResp": {
             "type": "object",
             "properties": {
@@ -253,6 +1001,30 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.TopKeysResp": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Keys with the most checks",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.KeyCount"
+                    }
+                },
+                "denied": {
+                    "description": "Keys with the most denied checks",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.KeyCount"
+                    }
+                },
+                "window": {
+                    "description": "Time window",
+                    "type": "string",
+                    "example": "5m"
+                }
+            }
+        },
         "handler.UpdateRuleReq": {
             "type": "object",
             "required": [
@@ -291,11 +1063,73 @@ const docTemplate = `{
                     "example": "success"
                 }
             }
+        },
+        "handler.UsageResp": {
+            "type": "object",
+            "properties": {
+                "burst": {
+                    "description": "Current rule: bucket capacity",
+                    "type": "integer",
+                    "example": 50
+                },
+                "key": {
+                    "description": "Rate limiting key",
+                    "type": "string",
+                    "example": "your_api_key:gpt-4"
+                },
+                "limit_per_minute": {
+                    "description": "Tokens the rule refills per minute",
+                    "type": "integer",
+                    "example": 600
+                },
+                "points": {
+                    "description": "Usage per minute, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usage.Point"
+                    }
+                },
+                "rate": {
+                    "description": "Current rule: tokens per second",
+                    "type": "integer",
+                    "example": 10
+                },
+                "window": {
+                    "description": "Time window, capped to the retention",
+                    "type": "string",
+                    "example": "1h0m0s"
+                }
+            }
+        },
+        "usage.Point": {
+            "type": "object",
+            "properties": {
+                "allowed": {
+                    "description": "Allowed checks",
+                    "type": "integer",
+                    "example": 120
+                },
+                "denied": {
+                    "description": "Denied checks",
+                    "type": "integer",
+                    "example": 3
+                },
+                "time": {
+                    "description": "Start of the minute",
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "tokens": {
+                    "description": "Tokens taken by allowed checks",
+                    "type": "integer",
+                    "example": 120
+                }
+            }
         }
     },
     "securityDefinitions": {
         "ApiKeyAuth": {
-            "description": "API key for authentication",
+            "description": "API key or JWT, sent as \"Bearer \u003ctoken\u003e\" (see auth in config.yaml)",
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
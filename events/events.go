@@ -0,0 +1,318 @@
+// Package events streams rate limit decisions to live subscribers, e.g. an ops dashboard.
+//
+// A Stream observes the limiter and publishes every denied decision, and a sample of the allowed
+// ones, to a Bus. Every instance with local subscribers subscribes to the Bus and fans the events
+// out to them, so a subscriber connected to any instance sees the decisions of all of them. While
+// no instance has subscribers, nothing is published
+package events
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+const (
+	// publishBuffer bounds the events waiting to be published, further events are dropped
+	publishBuffer = 10000
+	// publishBatch is the most events published in one round trip
+	publishBatch = 500
+	// subscriptionBuffer bounds the events waiting for a slow subscriber, further events are dropped
+	subscriptionBuffer = 256
+	// listenersInterval is how often the Bus is asked whether any instance has subscribers
+	listenersInterval = time.Second
+)
+
+// Event is a rate limit decision
+type Event struct {
+	Key       string    `json:"key" example:"your_api_key:gpt-4"`
+	Allowed   bool      `json:"allowed" example:"false"`
+	Requested int64     `json:"requested" example:"1"`
+	Remain    int64     `json:"remain" example:"0"`
+	Rate      int64     `json:"rate" example:"10"`
+	Burst     int64     `json:"burst" example:"50"`
+	Time      time.Time `json:"time" example:"2024-01-01T12:00:00Z"`
+	Degraded  string    `json:"degraded,omitempty" example:""`
+}
+
+// Bus carries events between instances
+type Bus interface {
+	// Publish sends events to the subscribers of all instances
+	Publish(ctx context.Context, events []Event) error
+
+	// Subscribe calls handle for every event published until ctx is done
+	Subscribe(ctx context.Context, handle func(Event)) error
+
+	// Listeners returns the number of Subscribe calls in progress on all instances
+	Listeners(ctx context.Context) (int64, error)
+}
+
+// Filter selects the events of a subscription
+type Filter struct {
+	Prefix  string  // Only keys starting with Prefix, all keys if empty
+	Allowed bool    // Include the published allowed decisions, denied decisions only otherwise
+	Sample  float64 // Share of the allowed decisions kept, in (0, 1]
+}
+
+func (f Filter) match(ev Event) bool {
+	if !strings.HasPrefix(ev.Key, f.Prefix) {
+		return false
+	}
+	if ev.Allowed {
+		return f.Allowed && (f.Sample >= 1 || rand.Float64() < f.Sample)
+	}
+	return true
+}
+
+// Subscription receives the events matching its filter
+type Subscription struct {
+	filter Filter
+	c      chan Event
+
+	mu      sync.Mutex
+	dropped int64
+}
+
+// C returns the channel of events. It is never closed, stop reading when the subscription is canceled
+func (s *Subscription) C() <-chan Event {
+	return s.c
+}
+
+// Dropped returns and resets the number of events dropped because the subscriber was too slow
+func (s *Subscription) Dropped() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dropped := s.dropped
+	s.dropped = 0
+	return dropped
+}
+
+// Stream publishes the decisions of this instance and serves the events of all instances
+type Stream struct {
+	bus        Bus
+	sampleRate float64
+
+	pending chan Event
+	// listening is whether any instance has subscribers, decisions are not published otherwise
+	listening atomic.Bool
+
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	dropped       int64
+	unsubscribe   context.CancelFunc // stops the Bus subscription, nil without local subscriptions
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// NewStream starts publishing to bus. It subscribes to bus while it has subscriptions.
+// cfg.SampleRate is the share of the allowed decisions published. Call Close to stop
+func NewStream(bus Bus, cfg *config.EventsConfig) *Stream {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Stream{
+		bus:           bus,
+		sampleRate:    cfg.SampleRate,
+		pending:       make(chan Event, publishBuffer),
+		subscriptions: make(map[*Subscription]struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.publish(ctx)
+	}()
+	return s
+}
+
+// Observe publishes denied decisions and a sample of the allowed ones while any instance has
+// subscribers, it is a limiter.Observer
+func (s *Stream) Observe(_ context.Context, d limiter.Decision) {
+	if !s.listening.Load() {
+		return
+	}
+	if d.Allowed && (s.sampleRate <= 0 || rand.Float64() >= s.sampleRate) {
+		return
+	}
+
+	ev := Event{
+		Key:       d.Key,
+		Allowed:   d.Allowed,
+		Requested: d.Requested,
+		Remain:    d.Remain,
+		Rate:      d.Rate,
+		Burst:     d.Burst,
+		Time:      d.Time.UTC(),
+		Degraded:  string(d.Degraded),
+	}
+	select {
+	case s.pending <- ev:
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+	}
+}
+
+// Subscribe registers a subscription matching filter. Call Unsubscribe when done
+func (s *Stream) Subscribe(filter Filter) *Subscription {
+	if filter.Sample <= 0 || filter.Sample > 1 {
+		filter.Sample = 1
+	}
+	sub := &Subscription{filter: filter, c: make(chan Event, subscriptionBuffer)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscriptions[sub] = struct{}{}
+	if s.unsubscribe == nil && s.ctx.Err() == nil {
+		// First local subscription: receive the events of all instances, and publish ours at once
+		// rather than at the next listeners poll
+		ctx, cancel := context.WithCancel(s.ctx)
+		s.unsubscribe = cancel
+		s.listening.Store(true)
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.subscribe(ctx)
+		}()
+	}
+	return sub
+}
+
+// Unsubscribe stops delivering events to sub
+func (s *Stream) Unsubscribe(sub *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscriptions, sub)
+	if len(s.subscriptions) == 0 && s.unsubscribe != nil {
+		s.unsubscribe()
+		s.unsubscribe = nil
+	}
+}
+
+// Close stops publishing and subscribing. Events not yet published are lost
+func (s *Stream) Close() {
+	s.once.Do(func() {
+		s.cancel()
+		s.wg.Wait()
+	})
+}
+
+// pollListeners updates whether any instance has subscribers. On errors the previous state is kept
+func (s *Stream) pollListeners(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, listenersInterval)
+	defer cancel()
+
+	n, err := s.bus.Listeners(ctx)
+	if err != nil {
+		logger.Debug("Failed to count decision event listeners", logger.ErrorField(err))
+		return
+	}
+	s.mu.Lock()
+	local := len(s.subscriptions) > 0
+	s.mu.Unlock()
+	s.listening.Store(n > 0 || local)
+}
+
+// publish sends the pending events to the bus in batches, and polls whether any instance listens
+func (s *Stream) publish(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	listeners := time.NewTicker(listenersInterval)
+	defer listeners.Stop()
+
+	s.pollListeners(ctx)
+	batch := make([]Event, 0, publishBatch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-listeners.C:
+			s.pollListeners(ctx)
+			continue
+		case <-ticker.C:
+			s.mu.Lock()
+			dropped := s.dropped
+			s.dropped = 0
+			s.mu.Unlock()
+			if dropped > 0 {
+				logger.Warn("Decision events dropped, too many events waiting to be published",
+					logger.Int64("dropped", dropped),
+				)
+			}
+			continue
+		case ev := <-s.pending:
+			batch = append(batch, ev)
+		}
+
+		// Take what is already waiting, so a burst of denials is one round trip
+	drain:
+		for len(batch) < publishBatch {
+			select {
+			case ev := <-s.pending:
+				batch = append(batch, ev)
+			default:
+				break drain
+			}
+		}
+
+		pubCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		if err := s.bus.Publish(pubCtx, batch); err != nil {
+			logger.Error("Failed to publish decision events",
+				logger.Int("events", len(batch)),
+				logger.ErrorField(err),
+			)
+		}
+		cancel()
+		batch = batch[:0]
+	}
+}
+
+// subscribe fans the events of the bus out to the local subscriptions until ctx is done,
+// resubscribing on errors
+func (s *Stream) subscribe(ctx context.Context) {
+	for {
+		err := s.bus.Subscribe(ctx, s.dispatch)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Error("Decision event subscription failed, resubscribing", logger.ErrorField(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (s *Stream) dispatch(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subscriptions {
+		if !sub.filter.match(ev) {
+			continue
+		}
+		select {
+		case sub.c <- ev:
+		default:
+			sub.mu.Lock()
+			sub.dropped++
+			sub.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+)
+
+// countingBus counts the events published to a MemoryBus
+type countingBus struct {
+	*MemoryBus
+	published atomic.Int64
+}
+
+func (b *countingBus) Publish(ctx context.Context, events []Event) error {
+	b.published.Add(int64(len(events)))
+	return b.MemoryBus.Publish(ctx, events)
+}
+
+func denied(key string) limiter.Decision {
+	return limiter.Decision{Key: key, Allowed: false, Requested: 1, Rate: 1, Burst: 1, Time: time.Now()}
+}
+
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case ev := <-sub.C():
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+// waitListening polls the listeners of s until its listening state is want. Bus subscriptions are
+// made and canceled in the background
+func waitListening(t *testing.T, s *Stream, bus Bus, want bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.pollListeners(context.Background())
+		n, _ := bus.Listeners(context.Background())
+		if s.listening.Load() == want && (n > 0) == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("listening did not become %v", want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStreamPublishesOnlyWithListeners(t *testing.T) {
+	bus := &countingBus{MemoryBus: NewMemoryBus()}
+	s := NewStream(bus, &config.EventsConfig{})
+	defer s.Close()
+	ctx := context.Background()
+
+	s.Observe(ctx, denied("before"))
+	time.Sleep(10 * time.Millisecond)
+	if got := bus.published.Load(); got != 0 {
+		t.Fatalf("published %d events without listeners", got)
+	}
+
+	sub := s.Subscribe(Filter{})
+	waitListening(t, s, bus, true)
+	s.Observe(ctx, denied("during"))
+	if ev := receive(t, sub); ev.Key != "during" {
+		t.Fatalf("got event of %q, want during", ev.Key)
+	}
+
+	s.Unsubscribe(sub)
+	waitListening(t, s, bus, false)
+	published := bus.published.Load()
+	s.Observe(ctx, denied("after"))
+	time.Sleep(10 * time.Millisecond)
+	if got := bus.published.Load(); got != published {
+		t.Fatalf("published %d events after the last listener left", got-published)
+	}
+}
+
+func TestStreamPublishesForRemoteListeners(t *testing.T) {
+	bus := &countingBus{MemoryBus: NewMemoryBus()}
+	publisher := NewStream(bus, &config.EventsConfig{})
+	defer publisher.Close()
+	server := NewStream(bus, &config.EventsConfig{})
+	defer server.Close()
+	ctx := context.Background()
+
+	sub := server.Subscribe(Filter{})
+	defer server.Unsubscribe(sub)
+
+	// The publisher learns of the listener of the other instance from the bus
+	waitListening(t, publisher, bus, true)
+
+	publisher.Observe(ctx, denied("remote"))
+	if ev := receive(t, sub); ev.Key != "remote" {
+		t.Fatalf("got event of %q, want remote", ev.Key)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		event  Event
+		want   bool
+	}{
+		{name: "denied matches", filter: Filter{Sample: 1}, event: Event{Key: "a:b"}, want: true},
+		{name: "allowed excluded by default", filter: Filter{Sample: 1}, event: Event{Key: "a:b", Allowed: true}, want: false},
+		{name: "allowed included", filter: Filter{Allowed: true, Sample: 1}, event: Event{Key: "a:b", Allowed: true}, want: true},
+		{name: "prefix matches", filter: Filter{Prefix: "a:", Sample: 1}, event: Event{Key: "a:b"}, want: true},
+		{name: "prefix excludes", filter: Filter{Prefix: "b:", Sample: 1}, event: Event{Key: "a:b"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.match(tt.event); got != tt.want {
+				t.Errorf("match: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBus carries events inside the current process, for the memory backend
+type MemoryBus struct {
+	mu       sync.Mutex
+	handlers map[int]func(Event)
+	next     int
+}
+
+// NewMemoryBus creates a bus without subscribers
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{handlers: make(map[int]func(Event))}
+}
+
+// Publish calls the handlers of the subscribers
+func (b *MemoryBus) Publish(_ context.Context, events []Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ev := range events {
+		for _, handle := range b.handlers {
+			handle(ev)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers handle until ctx is done
+func (b *MemoryBus) Subscribe(ctx context.Context, handle func(Event)) error {
+	b.mu.Lock()
+	id := b.next
+	b.next++
+	b.handlers[id] = handle
+	b.mu.Unlock()
+
+	<-ctx.Done()
+
+	b.mu.Lock()
+	delete(b.handlers, id)
+	b.mu.Unlock()
+	return ctx.Err()
+}
+
+// Listeners returns the number of subscribers
+func (b *MemoryBus) Listeners(context.Context) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return int64(len(b.handlers)), nil
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// PubSubClient is a Redis client able to subscribe, *redis.Client and *redis.ClusterClient
+type PubSubClient interface {
+	redis.Cmdable
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// RedisBus carries events over a Redis Pub/Sub channel as JSON messages. Pub/Sub does not keep
+// messages, events published while an instance is disconnected are not delivered to it
+type RedisBus struct {
+	client  PubSubClient
+	channel string
+}
+
+// NewRedisBus creates a bus on channel. client must be a *redis.Client or *redis.ClusterClient
+func NewRedisBus(client redis.Cmdable, channel string) (*RedisBus, error) {
+	psc, ok := client.(PubSubClient)
+	if !ok {
+		return nil, fmt.Errorf("redis client %T does not support Pub/Sub", client)
+	}
+	return &RedisBus{client: psc, channel: channel}, nil
+}
+
+// Publish publishes events in one pipeline
+func (b *RedisBus) Publish(ctx context.Context, events []Event) error {
+	_, err := b.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, ev := range events {
+			data, err := json.Marshal(ev)
+			if err != nil {
+				return err
+			}
+			pipe.Publish(ctx, b.channel, data)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish events: %w", err)
+	}
+	return nil
+}
+
+// Listeners returns the number of subscriptions to the channel. On a cluster, subscriptions are
+// made on the master of the slot of the channel, which is asked
+func (b *RedisBus) Listeners(ctx context.Context) (int64, error) {
+	var client redis.Cmdable = b.client
+	if cluster, ok := b.client.(*redis.ClusterClient); ok {
+		master, err := cluster.MasterForKey(ctx, b.channel)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count event subscriptions: %w", err)
+		}
+		client = master
+	}
+	counts, err := client.PubSubNumSub(ctx, b.channel).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count event subscriptions: %w", err)
+	}
+	return counts[b.channel], nil
+}
+
+// Subscribe subscribes to the channel. go-redis reconnects and resubscribes by itself, so it only
+// returns when ctx is done or the subscription cannot be established
+func (b *RedisBus) Subscribe(ctx context.Context, handle func(Event)) error {
+	ps := b.client.Subscribe(ctx, b.channel)
+	defer ps.Close()
+
+	if _, err := ps.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to events: %w", err)
+	}
+
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return fmt.Errorf("event subscription closed")
+			}
+			var ev Event
+			if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+				logger.Warn("Ignoring invalid decision event", logger.ErrorField(err))
+				continue
+			}
+			handle(ev)
+		}
+	}
+}
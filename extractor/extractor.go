@@ -0,0 +1,159 @@
+package extractor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/your-org/rate-limiter/config"
+)
+
+// Func extracts part of a rate limiting key from a request.
+// ok is false if the value is not present in the request
+type Func func(r *http.Request) (value string, ok bool)
+
+// Header extracts the value of a request header
+func Header(name string) Func {
+	return func(r *http.Request) (string, bool) {
+		value := strings.TrimSpace(r.Header.Get(name))
+		return value, value != ""
+	}
+}
+
+// PathSegment extracts the index-th (0 based) non-empty segment of the URL path,
+// e.g. index 1 of "/v1/models/gpt-4" is "models"
+func PathSegment(index int) Func {
+	return func(r *http.Request) (string, bool) {
+		segments := strings.FieldsFunc(r.URL.Path, func(c rune) bool { return c == '/' })
+		if index < 0 || index >= len(segments) {
+			return "", false
+		}
+		return segments[index], true
+	}
+}
+
+// ClientIP extracts the client IP address. If header is set (e.g. X-Forwarded-For or X-Real-IP)
+// and present, its first address is used; otherwise the remote address of the connection.
+// Only set header when the service runs behind a proxy that overwrites it
+func ClientIP(header string) Func {
+	return func(r *http.Request) (string, bool) {
+		if header != "" {
+			if value := r.Header.Get(header); value != "" {
+				first, _, _ := strings.Cut(value, ",")
+				if ip := strings.TrimSpace(first); ip != "" {
+					return ip, true
+				}
+			}
+		}
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		return host, host != ""
+	}
+}
+
+// JWTClaim extracts a claim from the JWT carried in header (default Authorization, with an
+// optional "Bearer " prefix). Nested claims are addressed with dots, e.g. "org.id".
+// The token signature is NOT verified: only use this behind a component that verifies tokens,
+// otherwise callers can pick arbitrary keys
+func JWTClaim(header, claim string) Func {
+	if header == "" {
+		header = "Authorization"
+	}
+	path := strings.Split(claim, ".")
+	return func(r *http.Request) (string, bool) {
+		token := strings.TrimSpace(r.Header.Get(header))
+		if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+			token = strings.TrimSpace(token[7:])
+		}
+		parts := strings.Split(token, ".")
+		if len(parts) != 3 {
+			return "", false
+		}
+		payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+		if err != nil {
+			return "", false
+		}
+		var claims map[string]interface{}
+		if err := json.Unmarshal(payload, &claims); err != nil {
+			return "", false
+		}
+
+		var value interface{} = claims
+		for _, name := range path {
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				return "", false
+			}
+			if value, ok = m[name]; !ok {
+				return "", false
+			}
+		}
+
+		switch v := value.(type) {
+		case string:
+			return v, v != ""
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case bool:
+			return strconv.FormatBool(v), true
+		default:
+			return "", false
+		}
+	}
+}
+
+// Static always returns value, useful as a fixed key prefix
+func Static(value string) Func {
+	return func(*http.Request) (string, bool) {
+		return value, true
+	}
+}
+
+// Join combines extractors into one joining their values with sep.
+// The result is missing if any of the extractors is missing
+func Join(sep string, funcs ...Func) Func {
+	return func(r *http.Request) (string, bool) {
+		parts := make([]string, 0, len(funcs))
+		for _, f := range funcs {
+			value, ok := f(r)
+			if !ok {
+				return "", false
+			}
+			parts = append(parts, value)
+		}
+		return strings.Join(parts, sep), true
+	}
+}
+
+// FromConfig builds an extractor from its configuration
+func FromConfig(cfg config.KeyExtractorConfig) (Func, error) {
+	switch cfg.Type {
+	case "header":
+		if cfg.Name == "" {
+			return nil, fmt.Errorf("header extractor requires name")
+		}
+		return Header(cfg.Name), nil
+	case "path_segment":
+		if cfg.Index < 0 {
+			return nil, fmt.Errorf("path_segment extractor requires a non-negative index")
+		}
+		return PathSegment(cfg.Index), nil
+	case "client_ip":
+		return ClientIP(cfg.Header), nil
+	case "jwt_claim":
+		if cfg.Name == "" {
+			return nil, fmt.Errorf("jwt_claim extractor requires name")
+		}
+		return JWTClaim(cfg.Header, cfg.Name), nil
+	case "static":
+		return Static(cfg.Name), nil
+	default:
+		return nil, fmt.Errorf("unknown key extractor type %q", cfg.Type)
+	}
+}
@@ -0,0 +1,104 @@
+package extractor
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/your-org/rate-limiter/config"
+)
+
+// token returns an unsigned JWT with payload, the extractors do not verify signatures
+func token(payload string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"none"}`)) + "." + enc([]byte(payload)) + ".sig"
+}
+
+func TestExtractors(t *testing.T) {
+	claims := token(`{"sub":"alice","org":{"id":"acme","tier":3,"paid":true},"empty":"","roles":["a"]}`)
+
+	tests := []struct {
+		name    string
+		f       Func
+		headers map[string]string
+		remote  string
+		want    string
+		wantOK  bool
+	}{
+		{name: "header", f: Header("X-Api-Key"), headers: map[string]string{"X-Api-Key": " abc "}, want: "abc", wantOK: true},
+		{name: "header, missing", f: Header("X-Api-Key")},
+		{name: "header, blank", f: Header("X-Api-Key"), headers: map[string]string{"X-Api-Key": "  "}},
+
+		{name: "ip, remote address", f: ClientIP(""), remote: "10.0.0.1:1234", want: "10.0.0.1", wantOK: true},
+		{name: "ip, ipv6", f: ClientIP(""), remote: "[::1]:1234", want: "::1", wantOK: true},
+		{name: "ip, forwarded", f: ClientIP("X-Forwarded-For"), remote: "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": " 203.0.113.7, 10.0.0.2"}, want: "203.0.113.7", wantOK: true},
+		{name: "ip, forwarded missing", f: ClientIP("X-Forwarded-For"), remote: "10.0.0.1:1234", want: "10.0.0.1", wantOK: true},
+		{name: "ip, header ignored", f: ClientIP(""), remote: "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "203.0.113.7"}, want: "10.0.0.1", wantOK: true},
+
+		{name: "claim", f: JWTClaim("", "sub"), headers: map[string]string{"Authorization": "Bearer " + claims}, want: "alice", wantOK: true},
+		{name: "claim, bare token", f: JWTClaim("", "sub"), headers: map[string]string{"Authorization": claims}, want: "alice", wantOK: true},
+		{name: "claim, other header", f: JWTClaim("X-Token", "sub"), headers: map[string]string{"X-Token": claims}, want: "alice", wantOK: true},
+		{name: "claim, nested", f: JWTClaim("", "org.id"), headers: map[string]string{"Authorization": "Bearer " + claims}, want: "acme", wantOK: true},
+		{name: "claim, number", f: JWTClaim("", "org.tier"), headers: map[string]string{"Authorization": "Bearer " + claims}, want: "3", wantOK: true},
+		{name: "claim, bool", f: JWTClaim("", "org.paid"), headers: map[string]string{"Authorization": "Bearer " + claims}, want: "true", wantOK: true},
+		{name: "claim, missing", f: JWTClaim("", "tenant"), headers: map[string]string{"Authorization": "Bearer " + claims}},
+		{name: "claim, missing nested", f: JWTClaim("", "org.name"), headers: map[string]string{"Authorization": "Bearer " + claims}},
+		{name: "claim, path through a string", f: JWTClaim("", "sub.id"), headers: map[string]string{"Authorization": "Bearer " + claims}},
+		{name: "claim, empty", f: JWTClaim("", "empty"), headers: map[string]string{"Authorization": "Bearer " + claims}},
+		{name: "claim, array", f: JWTClaim("", "roles"), headers: map[string]string{"Authorization": "Bearer " + claims}},
+		{name: "claim, no token", f: JWTClaim("", "sub")},
+		{name: "claim, not a jwt", f: JWTClaim("", "sub"), headers: map[string]string{"Authorization": "Bearer abc"}},
+		{name: "claim, bad base64", f: JWTClaim("", "sub"), headers: map[string]string{"Authorization": "Bearer a.!!!.c"}},
+		{name: "claim, bad json", f: JWTClaim("", "sub"), headers: map[string]string{"Authorization": "Bearer " + token(`{"sub":`)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			got, ok := tt.f(r)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("got %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/models/gpt", nil)
+	r.Header.Set("X-Api-Key", "abc")
+
+	if got, ok := Join(":", Static("api"), Header("X-Api-Key"), PathSegment(2))(r); !ok || got != "api:abc:gpt" {
+		t.Errorf("got %q, %v, want api:abc:gpt", got, ok)
+	}
+	if _, ok := Join(":", Header("X-Api-Key"), PathSegment(3))(r); ok {
+		t.Error("a missing part must make the key missing")
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	tests := []struct {
+		cfg     config.KeyExtractorConfig
+		wantErr bool
+	}{
+		{cfg: config.KeyExtractorConfig{Type: "header", Name: "X-Api-Key"}},
+		{cfg: config.KeyExtractorConfig{Type: "header"}, wantErr: true},
+		{cfg: config.KeyExtractorConfig{Type: "path_segment", Index: 1}},
+		{cfg: config.KeyExtractorConfig{Type: "path_segment", Index: -1}, wantErr: true},
+		{cfg: config.KeyExtractorConfig{Type: "client_ip"}},
+		{cfg: config.KeyExtractorConfig{Type: "jwt_claim", Name: "sub"}},
+		{cfg: config.KeyExtractorConfig{Type: "jwt_claim"}, wantErr: true},
+		{cfg: config.KeyExtractorConfig{Type: "static", Name: "api"}},
+		{cfg: config.KeyExtractorConfig{Type: "cookie"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if _, err := FromConfig(tt.cfg); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: got %v, want error %v", tt.cfg, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package grpcserver
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	commonv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// EnvoyServer implements envoy.service.ratelimit.v3.RateLimitService so that
+// Envoy can use this service as its external rate limit service (RLS).
+// A request runs in the namespace of its x-namespace metadata, or of its domain with
+// envoy.domain_namespace, or else the namespace of its credentials or the default namespace.
+// Credentials scoped to a namespace cannot use another one
+type EnvoyServer struct {
+	rlsv3.UnimplementedRateLimitServiceServer
+
+	namespaces *limiter.Namespaces
+	cfg        *config.EnvoyConfig
+}
+
+// NewEnvoyServer creates an Envoy RLS implementation on l
+func NewEnvoyServer(l *limiter.Limiter, cfg *config.EnvoyConfig) *EnvoyServer {
+	return NewEnvoyServerWithNamespaces(limiter.NewNamespaces(l), cfg)
+}
+
+// NewEnvoyServerWithNamespaces is NewEnvoyServer serving the namespaces of ns
+func NewEnvoyServerWithNamespaces(ns *limiter.Namespaces, cfg *config.EnvoyConfig) *EnvoyServer {
+	return &EnvoyServer{namespaces: ns, cfg: cfg}
+}
+
+// ShouldRateLimit checks every descriptor of the request against its rule.
+// Each descriptor is mapped to a rate limiting key and consumes hits_addend tokens;
+// the overall code is OVER_LIMIT if any descriptor is over its limit
+func (s *EnvoyServer) ShouldRateLimit(ctx context.Context, req *rlsv3.RateLimitRequest) (*rlsv3.RateLimitResponse, error) {
+	startTime := time.Now()
+
+	if len(req.GetDescriptors()) == 0 {
+		logger.Error("Invalid Envoy rate limit request, no descriptors",
+			logger.String("domain", req.GetDomain()),
+		)
+		return nil, status.Error(codes.InvalidArgument, "descriptors are required")
+	}
+
+	logger.Info("Envoy rate limit request",
+		logger.String("domain", req.GetDomain()),
+		logger.Int("descriptors", len(req.GetDescriptors())),
+		logger.String("client_ip", peerAddr(ctx)),
+	)
+
+	requested := requestedNamespace(ctx)
+	if requested == "" && s.cfg.DomainNamespace {
+		requested = req.GetDomain()
+	}
+	l, err := namespaceLimiter(ctx, s.namespaces, requested)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &rlsv3.RateLimitResponse{
+		OverallCode: rlsv3.RateLimitResponse_OK,
+		Statuses:    make([]*rlsv3.RateLimitResponse_DescriptorStatus, 0, len(req.GetDescriptors())),
+	}
+
+	for _, descriptor := range req.GetDescriptors() {
+		key := s.descriptorKey(req.GetDomain(), descriptor)
+		if key == "" {
+			return nil, status.Error(codes.InvalidArgument, "descriptor entries are required")
+		}
+
+		// Descriptor level hits_addend overrides the request level, 0 at either level means 1
+		hits := int64(descriptor.GetHitsAddend().GetValue())
+		if hits == 0 {
+			hits = int64(req.GetHitsAddend())
+		}
+		if hits == 0 {
+			hits = 1
+		}
+
+		if descriptor.GetLimit() != nil {
+			logger.Debug("Ignoring descriptor limit override, rules are managed by this service",
+				logger.String("key", key),
+			)
+		}
+
+		d, err := l.CheckKey(ctx, key, hits)
+		if err != nil {
+			logger.Error("Rate limit check failed",
+				logger.String("key", key),
+				logger.ErrorField(err),
+			)
+			return nil, status.Errorf(errorCode(err), "rate limit check failed: %v", err)
+		}
+
+		// The response tells the client the limits were not enforced as configured
+		if d.Degraded != "" && len(resp.ResponseHeadersToAdd) == 0 {
+			resp.ResponseHeadersToAdd = append(resp.ResponseHeadersToAdd, &corev3.HeaderValue{
+				Key:   strings.ToLower(limiter.DegradedHeader),
+				Value: string(d.Degraded),
+			})
+		}
+
+		code := rlsv3.RateLimitResponse_OK
+		if !d.Allowed {
+			code = rlsv3.RateLimitResponse_OVER_LIMIT
+			resp.OverallCode = rlsv3.RateLimitResponse_OVER_LIMIT
+			logger.Warn("Rate limit exceeded",
+				logger.String("key", key),
+			)
+		}
+
+		resp.Statuses = append(resp.Statuses, &rlsv3.RateLimitResponse_DescriptorStatus{
+			Code: code,
+			CurrentLimit: &rlsv3.RateLimitResponse_RateLimit{
+				Name:            key,
+				RequestsPerUnit: clampUint32(d.Rate),
+				Unit:            rlsv3.RateLimitResponse_RateLimit_SECOND,
+			},
+			LimitRemaining:     clampUint32(d.Remain),
+			DurationUntilReset: durationpb.New(d.Rule().ResetAfter(d.Remain)),
+		})
+	}
+
+	duration := time.Since(startTime)
+	logger.Info("Envoy rate limit check completed",
+		logger.String("domain", req.GetDomain()),
+		logger.String("overall_code", resp.OverallCode.String()),
+		logger.Duration("duration", duration),
+	)
+
+	return resp, nil
+}
+
+// descriptorKey maps a descriptor to a rate limiting key, e.g. the entries
+// [{api_key, abc}, {model, gpt-4}] map to "abc:gpt-4" with the default settings
+func (s *EnvoyServer) descriptorKey(domain string, descriptor *commonv3.RateLimitDescriptor) string {
+	if len(descriptor.GetEntries()) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(descriptor.GetEntries())+1)
+	if s.cfg.DomainPrefix && domain != "" {
+		parts = append(parts, domain)
+	}
+	for _, entry := range descriptor.GetEntries() {
+		if s.cfg.IncludeEntryKeys {
+			parts = append(parts, entry.GetKey()+"="+entry.GetValue())
+		} else {
+			parts = append(parts, entry.GetValue())
+		}
+	}
+	return strings.Join(parts, s.cfg.Separator)
+}
+
+// clampUint32 converts a token count to the uint32 used by the RLS protocol
+func clampUint32(v int64) uint32 {
+	if v < 0 {
+		return 0
+	}
+	if v > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(v)
+}
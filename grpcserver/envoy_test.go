@@ -0,0 +1,142 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	commonv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"github.com/your-org/rate-limiter/auth"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/limiter/memory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// newNamespaces returns the default namespace and team-a, team-a allows a single request
+func newNamespaces(t *testing.T) *limiter.Namespaces {
+	t.Helper()
+	store := memory.New(0)
+	t.Cleanup(func() { store.Close() })
+	ns := limiter.NewNamespaces(limiter.New(store, config.LimiterConfig{DefaultRate: 1, DefaultBurst: 10}))
+	if err := ns.Add(limiter.New(store, config.LimiterConfig{DefaultRate: 1, DefaultBurst: 1},
+		limiter.WithNamespace("team-a"))); err != nil {
+		t.Fatal(err)
+	}
+	return ns
+}
+
+func TestEnvoyNamespace(t *testing.T) {
+	a, err := auth.New(&config.AuthConfig{
+		Enabled: true,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "ops", Key: "ops-key", Roles: []string{auth.RoleCheck}, Namespace: auth.AnyNamespace},
+			{Name: "checker", Key: "check-key", Roles: []string{auth.RoleCheck}},
+			{Name: "team-a", Key: "team-a-key", Roles: []string{auth.RoleCheck}, Namespace: "team-a"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor := a.UnaryServerInterceptor(MethodRoles)
+	info := &grpc.UnaryServerInfo{FullMethod: rlsv3.RateLimitService_ShouldRateLimit_FullMethodName}
+
+	tests := []struct {
+		name            string
+		key             string
+		namespace       string // x-namespace metadata
+		domain          string
+		domainNamespace bool
+		wantCode        codes.Code
+		wantRemain      uint32
+	}{
+		{name: "default namespace", key: "ops-key", wantRemain: 9},
+		{name: "metadata", key: "ops-key", namespace: "team-a", wantRemain: 0},
+		{name: "domain", key: "ops-key", domain: "team-a", domainNamespace: true, wantRemain: 0},
+		{name: "domain ignored", key: "ops-key", domain: "team-a", wantRemain: 9},
+		{name: "metadata before domain", key: "ops-key", namespace: "team-a", domain: "other", domainNamespace: true, wantRemain: 0},
+		{name: "unknown namespace", key: "ops-key", namespace: "team-b", wantCode: codes.NotFound},
+		{name: "unscoped, default", key: "check-key", wantRemain: 9},
+		{name: "unscoped, other metadata", key: "check-key", namespace: "team-a", wantCode: codes.PermissionDenied},
+		{name: "scoped, implicit", key: "team-a-key", wantRemain: 0},
+		{name: "scoped, own domain", key: "team-a-key", domain: "team-a", domainNamespace: true, wantRemain: 0},
+		{name: "scoped, other metadata", key: "team-a-key", namespace: "team-b", wantCode: codes.PermissionDenied},
+		{name: "scoped, default domain", key: "team-a-key", domain: "shared", domainNamespace: true, wantCode: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewEnvoyServerWithNamespaces(newNamespaces(t), &config.EnvoyConfig{
+				Separator:       ":",
+				DomainNamespace: tt.domainNamespace,
+			})
+			md := metadata.Pairs("authorization", tt.key)
+			if tt.namespace != "" {
+				md.Set(NamespaceMetadata, tt.namespace)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+			req := &rlsv3.RateLimitRequest{
+				Domain: tt.domain,
+				Descriptors: []*commonv3.RateLimitDescriptor{{
+					Entries: []*commonv3.RateLimitDescriptor_Entry{{Key: "api_key", Value: "abc"}},
+				}},
+			}
+
+			out, err := interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.ShouldRateLimit(ctx, req.(*rlsv3.RateLimitRequest))
+			})
+			if tt.wantCode != codes.OK {
+				if status.Code(err) != tt.wantCode {
+					t.Fatalf("got %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp := out.(*rlsv3.RateLimitResponse)
+			if resp.OverallCode != rlsv3.RateLimitResponse_OK {
+				t.Fatalf("got %s, want OK", resp.OverallCode)
+			}
+			if got := resp.Statuses[0].LimitRemaining; got != tt.wantRemain {
+				t.Errorf("remaining: got %d, want %d", got, tt.wantRemain)
+			}
+		})
+	}
+}
+
+func TestEnvoyHitsAddend(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestHits    uint32
+		descriptorHits *wrapperspb.UInt64Value
+		wantRemain     uint32
+	}{
+		{name: "default", wantRemain: 9},
+		{name: "request", requestHits: 3, wantRemain: 7},
+		{name: "descriptor", requestHits: 3, descriptorHits: wrapperspb.UInt64(2), wantRemain: 8},
+		{name: "descriptor 0", descriptorHits: wrapperspb.UInt64(0), wantRemain: 9},
+		{name: "descriptor 0, request", requestHits: 3, descriptorHits: wrapperspb.UInt64(0), wantRemain: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewEnvoyServerWithNamespaces(newNamespaces(t), &config.EnvoyConfig{Separator: ":"})
+			resp, err := s.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+				HitsAddend: tt.requestHits,
+				Descriptors: []*commonv3.RateLimitDescriptor{{
+					Entries:    []*commonv3.RateLimitDescriptor_Entry{{Key: "api_key", Value: "abc"}},
+					HitsAddend: tt.descriptorHits,
+				}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.Statuses[0].LimitRemaining; got != tt.wantRemain {
+				t.Errorf("remaining: got %d, want %d", got, tt.wantRemain)
+			}
+		})
+	}
+}
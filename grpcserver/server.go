@@ -0,0 +1,466 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"github.com/your-org/rate-limiter/auth"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+	ratelimiterv1 "github.com/your-org/rate-limiter/proto/ratelimiter/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the ratelimiter.v1.RateLimiter gRPC service
+// on top of the same limiter logic used by the HTTP handlers
+type Server struct {
+	ratelimiterv1.UnimplementedRateLimiterServer
+
+	namespaces *limiter.Namespaces
+}
+
+// MethodRoles are the roles the gRPC methods require when authentication is enabled
+var MethodRoles = auth.MethodRoles{
+	ratelimiterv1.RateLimiter_CheckRateLimit_FullMethodName: auth.RoleCheck,
+	ratelimiterv1.RateLimiter_UpdateRule_FullMethodName:     auth.RoleAdmin,
+	ratelimiterv1.RateLimiter_DeleteRule_FullMethodName:     auth.RoleAdmin,
+	ratelimiterv1.RateLimiter_ResetBucket_FullMethodName:    auth.RoleAdmin,
+	ratelimiterv1.RateLimiter_GetStats_FullMethodName:       auth.RoleViewer,
+	ratelimiterv1.RateLimiter_GetRuleStats_FullMethodName:   auth.RoleViewer,
+	rlsv3.RateLimitService_ShouldRateLimit_FullMethodName:   auth.RoleCheck,
+}
+
+// New creates a gRPC server on l with the rate limiter service registered,
+// plus the Envoy RLS v3 service when it is enabled in envoy
+func New(l *limiter.Limiter, envoy *config.EnvoyConfig, opts ...grpc.ServerOption) *grpc.Server {
+	return NewWithNamespaces(limiter.NewNamespaces(l), envoy, opts...)
+}
+
+// NewWithNamespaces is New serving the namespaces of ns. Both services select the namespace with
+// the x-namespace metadata, see EnvoyServer for the domain of the Envoy RLS requests
+func NewWithNamespaces(ns *limiter.Namespaces, envoy *config.EnvoyConfig, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	ratelimiterv1.RegisterRateLimiterServer(s, &Server{namespaces: ns})
+	if envoy.Enabled {
+		rlsv3.RegisterRateLimitServiceServer(s, NewEnvoyServerWithNamespaces(ns, envoy))
+	}
+	return s
+}
+
+// CheckRateLimit checks if rate limit is exceeded
+func (s *Server) CheckRateLimit(ctx context.Context, req *ratelimiterv1.CheckRateLimitRequest) (*ratelimiterv1.CheckRateLimitResponse, error) {
+	startTime := time.Now()
+
+	if req.GetKey() == "" {
+		logger.Error("Invalid request parameters for rate limit check",
+			logger.String("key", req.GetKey()),
+		)
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+
+	// Each request consumes 1 token unless more are requested
+	tokens := req.GetTokens()
+	if tokens == 0 {
+		tokens = 1
+	}
+	if tokens < 0 {
+		logger.Warn("Invalid tokens value",
+			logger.String("key", req.GetKey()),
+			logger.Int64("tokens", tokens),
+		)
+		return nil, status.Error(codes.InvalidArgument, "tokens must be greater than 0")
+	}
+
+	logger.Info("Rate limit check request",
+		logger.String("key", req.GetKey()),
+		logger.Int64("tokens", tokens),
+		logger.String("client_ip", peerAddr(ctx)),
+		logger.String("transport", "grpc"),
+	)
+
+	l, err := s.limiterFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check rate limit under the rule of the key, read in the same round trip
+	d, err := l.CheckKey(ctx, req.GetKey(), tokens)
+	if err != nil {
+		logger.Error("Rate limit check failed",
+			logger.String("key", req.GetKey()),
+			logger.ErrorField(err),
+		)
+		return nil, status.Errorf(errorCode(err), "rate limit check failed: %v", err)
+	}
+
+	resp := &ratelimiterv1.CheckRateLimitResponse{
+		Allowed:  d.Allowed,
+		Remain:   d.Remain,
+		Degraded: string(d.Degraded),
+	}
+	if !d.Allowed {
+		resp.Message = "Rate limit exceeded"
+		logger.Warn("Rate limit exceeded",
+			logger.String("key", req.GetKey()),
+		)
+	}
+
+	duration := time.Since(startTime)
+	logger.Info("Rate limit check completed",
+		logger.String("key", req.GetKey()),
+		logger.Bool("allowed", d.Allowed),
+		logger.Int64("remain", d.Remain),
+		logger.Duration("duration", duration),
+	)
+
+	return resp, nil
+}
+
+// UpdateRule updates rate limiting rule
+func (s *Server) UpdateRule(ctx context.Context, req *ratelimiterv1.UpdateRuleRequest) (*ratelimiterv1.UpdateRuleResponse, error) {
+	startTime := time.Now()
+
+	if req.GetKey() == "" {
+		logger.Error("Invalid request parameters for update rule",
+			logger.String("key", req.GetKey()),
+		)
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+
+	burst := req.GetBurst()
+	// Use default value if burst is not set
+	if burst == 0 {
+		burst = 50 // Default bucket capacity
+		logger.Debug("Using default burst value", logger.Int64("burst", burst))
+	}
+
+	// Validate parameters
+	if req.GetRateLimit() <= 0 {
+		logger.Warn("Invalid rate limit value",
+			logger.String("key", req.GetKey()),
+			logger.Int64("rate", req.GetRateLimit()),
+		)
+		return nil, status.Error(codes.InvalidArgument, "rate limit must be greater than 0")
+	}
+	if burst <= 0 {
+		logger.Warn("Invalid burst value",
+			logger.String("key", req.GetKey()),
+			logger.Int64("burst", burst),
+		)
+		return nil, status.Error(codes.InvalidArgument, "burst must be greater than 0")
+	}
+
+	logger.Info("Updating rate limit rule",
+		logger.String("key", req.GetKey()),
+		logger.Int64("rate", req.GetRateLimit()),
+		logger.Int64("burst", burst),
+		logger.String("client_ip", peerAddr(ctx)),
+		logger.String("transport", "grpc"),
+	)
+
+	l, err := s.limiterFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update rule to Redis
+	if err := l.SetRule(ctx, req.GetKey(), req.GetRateLimit(), burst); err != nil {
+		logger.Error("Failed to update rate limit rule",
+			logger.String("key", req.GetKey()),
+			logger.ErrorField(err),
+		)
+		return nil, status.Errorf(errorCode(err), "failed to update rate limit rule: %v", err)
+	}
+
+	duration := time.Since(startTime)
+	logger.Info("Rate limit rule updated successfully",
+		logger.String("key", req.GetKey()),
+		logger.Int64("rate", req.GetRateLimit()),
+		logger.Int64("burst", burst),
+		logger.Duration("duration", duration),
+	)
+
+	return &ratelimiterv1.UpdateRuleResponse{
+		Status:  "success",
+		Message: "Rate limit rule updated successfully",
+	}, nil
+}
+
+// DeleteRule deletes rate limiting rule
+func (s *Server) DeleteRule(ctx context.Context, req *ratelimiterv1.DeleteRuleRequest) (*ratelimiterv1.DeleteRuleResponse, error) {
+	startTime := time.Now()
+
+	if req.GetKey() == "" {
+		logger.Error("Invalid request parameters for delete rule")
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+
+	logger.Info("Deleting rate limit rule",
+		logger.String("key", req.GetKey()),
+		logger.String("client_ip", peerAddr(ctx)),
+		logger.String("transport", "grpc"),
+	)
+
+	l, err := s.limiterFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existed, err := l.DeleteRule(ctx, req.GetKey())
+	if err != nil {
+		logger.Error("Failed to delete rate limit rule",
+			logger.String("key", req.GetKey()),
+			logger.ErrorField(err),
+		)
+		return nil, status.Errorf(errorCode(err), "failed to delete rate limit rule: %v", err)
+	}
+	if !existed {
+		return nil, status.Error(codes.NotFound, "rule not found")
+	}
+
+	duration := time.Since(startTime)
+	logger.Info("Rate limit rule deleted successfully",
+		logger.String("key", req.GetKey()),
+		logger.Duration("duration", duration),
+	)
+
+	return &ratelimiterv1.DeleteRuleResponse{
+		Status:  "success",
+		Message: "Rate limit rule deleted successfully",
+	}, nil
+}
+
+// ResetBucket resets a token bucket
+func (s *Server) ResetBucket(ctx context.Context, req *ratelimiterv1.ResetBucketRequest) (*ratelimiterv1.ResetBucketResponse, error) {
+	startTime := time.Now()
+
+	if req.GetKey() == "" {
+		logger.Error("Invalid request parameters for reset bucket")
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+
+	logger.Info("Resetting token bucket",
+		logger.String("key", req.GetKey()),
+		logger.String("client_ip", peerAddr(ctx)),
+		logger.String("transport", "grpc"),
+	)
+
+	l, err := s.limiterFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := l.ResetBucket(ctx, req.GetKey()); err != nil {
+		logger.Error("Failed to reset token bucket",
+			logger.String("key", req.GetKey()),
+			logger.ErrorField(err),
+		)
+		return nil, status.Errorf(errorCode(err), "failed to reset token bucket: %v", err)
+	}
+
+	duration := time.Since(startTime)
+	logger.Info("Token bucket reset successfully",
+		logger.String("key", req.GetKey()),
+		logger.Duration("duration", duration),
+	)
+
+	return &ratelimiterv1.ResetBucketResponse{
+		Status:  "success",
+		Message: "Token bucket reset successfully",
+	}, nil
+}
+
+// GetStats gets monitoring statistics
+func (s *Server) GetStats(ctx context.Context, _ *ratelimiterv1.GetStatsRequest) (*ratelimiterv1.GetStatsResponse, error) {
+	startTime := time.Now()
+
+	logger.Info("Getting stats request",
+		logger.String("client_ip", peerAddr(ctx)),
+		logger.String("transport", "grpc"),
+	)
+
+	l, err := s.limiterFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all rules
+	rules, err := l.GetAllRules(ctx)
+	if err != nil {
+		logger.Error("Failed to get rules", logger.ErrorField(err))
+		return nil, status.Errorf(codes.Internal, "failed to get rules: %v", err)
+	}
+
+	resp := &ratelimiterv1.GetStatsResponse{
+		Rules: make(map[string]*ratelimiterv1.Rule, len(rules)),
+		Stats: make(map[string]*ratelimiterv1.RuleStats, len(rules)),
+	}
+	for key, rule := range rules {
+		resp.Rules[key] = &ratelimiterv1.Rule{
+			Rate:      toInt64(rule["rate"]),
+			Burst:     toInt64(rule["burst"]),
+			UpdatedAt: toInt64(rule["updated_at"]),
+		}
+
+		stat, err := l.GetStats(ctx, key)
+		if err != nil {
+			// Skip this key if getting statistics fails
+			logger.Warn("Failed to get stats for key",
+				logger.String("key", key),
+				logger.ErrorField(err),
+			)
+			continue
+		}
+		resp.Stats[key] = toRuleStats(stat)
+	}
+
+	duration := time.Since(startTime)
+	logger.Info("Stats retrieved successfully",
+		logger.Int("rules_count", len(resp.Rules)),
+		logger.Int("stats_count", len(resp.Stats)),
+		logger.Duration("duration", duration),
+	)
+
+	return resp, nil
+}
+
+// GetRuleStats gets statistics for specific rule
+func (s *Server) GetRuleStats(ctx context.Context, req *ratelimiterv1.GetRuleStatsRequest) (*ratelimiterv1.GetRuleStatsResponse, error) {
+	startTime := time.Now()
+
+	if req.GetKey() == "" {
+		logger.Warn("Missing required parameter for rule stats",
+			logger.String("key", req.GetKey()),
+		)
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+
+	logger.Info("Getting rule stats",
+		logger.String("key", req.GetKey()),
+		logger.String("client_ip", peerAddr(ctx)),
+		logger.String("transport", "grpc"),
+	)
+
+	l, err := s.limiterFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := l.GetStats(ctx, req.GetKey())
+	if err != nil {
+		logger.Error("Failed to get stats",
+			logger.String("key", req.GetKey()),
+			logger.ErrorField(err),
+		)
+		return nil, status.Errorf(errorCode(err), "failed to get stats: %v", err)
+	}
+
+	duration := time.Since(startTime)
+	logger.Info("Rule stats retrieved successfully",
+		logger.String("key", req.GetKey()),
+		logger.Duration("duration", duration),
+	)
+
+	return &ratelimiterv1.GetRuleStatsResponse{
+		Key:   req.GetKey(),
+		Stats: toRuleStats(stats),
+	}, nil
+}
+
+// toRuleStats converts the loosely typed stats map returned by limiter.GetStats
+// into its protobuf representation. Unknown values mean no rule exists for the key
+func toRuleStats(stats map[string]interface{}) *ratelimiterv1.RuleStats {
+	if stats["rate"] == "unknown" {
+		return &ratelimiterv1.RuleStats{Found: false}
+	}
+	return &ratelimiterv1.RuleStats{
+		Found:         true,
+		Rate:          toInt64(stats["rate"]),
+		Burst:         toInt64(stats["burst"]),
+		CurrentTokens: toInt64(stats["current_tokens"]),
+	}
+}
+
+// toInt64 converts values read from Redis (strings or integers) to int64
+func toInt64(v interface{}) int64 {
+	switch val := v.(type) {
+	case int64:
+		return val
+	case int:
+		return int64(val)
+	case string:
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			// Token counts may be stored as floats by the Lua script
+			f, ferr := strconv.ParseFloat(val, 64)
+			if ferr != nil {
+				return 0
+			}
+			return int64(f)
+		}
+		return n
+	default:
+		return 0
+	}
+}
+
+// NamespaceMetadata is the metadata key selecting the namespace of a call, see handler.NamespaceHeader
+const NamespaceMetadata = "x-namespace"
+
+// limiterFor returns the Limiter of the namespace of the call
+func (s *Server) limiterFor(ctx context.Context) (*limiter.Limiter, error) {
+	return namespaceLimiter(ctx, s.namespaces, requestedNamespace(ctx))
+}
+
+// requestedNamespace returns the namespace of the x-namespace metadata of the call, "" if absent
+func requestedNamespace(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(NamespaceMetadata); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// namespaceLimiter returns the Limiter of the namespace requested by the caller, or of its own
+// namespace when requested is "". Principals scoped to another namespace are denied
+func namespaceLimiter(ctx context.Context, ns *limiter.Namespaces, requested string) (*limiter.Limiter, error) {
+	namespace, err := auth.Namespace(auth.FromContext(ctx), requested)
+	if err != nil {
+		logger.Warn("Namespace not allowed",
+			logger.String("namespace", requested),
+			logger.String("client_ip", peerAddr(ctx)),
+		)
+		return nil, status.Errorf(codes.PermissionDenied, "namespace %s not allowed", requested)
+	}
+	l, ok := ns.Get(namespace)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "namespace %s not found", namespace)
+	}
+	return l, nil
+}
+
+// peerAddr returns the remote address of the gRPC caller
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return "unknown"
+}
+
+// errorCode returns the code of an error of a Limiter: InvalidArgument for a key reserved for the
+// named namespaces, Internal otherwise
+func errorCode(err error) codes.Code {
+	if errors.Is(err, limiter.ErrReservedKey) {
+		return codes.InvalidArgument
+	}
+	return codes.Internal
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/your-org/rate-limiter/analytics"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// maxTopKeys bounds the n parameter of the top keys endpoint
+const maxTopKeys = 100
+
+// TopKeysResp represents the response for getting the top keys
+type TopKeysResp struct {
+	Window string               `json:"window" example:"5m"` // Time window
+	Checks []analytics.KeyCount `json:"checks"`              // Keys with the most checks
+	Denied []analytics.KeyCount `json:"denied"`              // Keys with the most denied checks
+}
+
+// TopKeys gets the keys with the most checks and denials
+// @Summary Get top keys
+// @Description Get the keys with the most checks and the most denied checks over the last 5 minutes, hour or day. Counts are approximate: they are flushed periodically and each time bucket keeps its top keys only. Keys are qualified with their namespace prefix; in a named namespace only its keys are returned
+// @Tags monitoring
+// @Produce json
+// @Param window query string false "Time window: 5m, 1h or 24h (default 5m)"
+// @Param n query int false "Number of keys, 1 to 100 (default 10)"
+// @Param X-Namespace header string false "Namespace, the default namespace or the namespace of the credentials when absent"
+// @Success 200 {object} TopKeysResp
+// @Failure 400 {object} map[string]interface{} "Invalid request parameters"
+// @Failure 404 {object} map[string]interface{} "Analytics or namespace not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Role or namespace not allowed"
+// @Security ApiKeyAuth
+// @Router /v1/top_keys [get]
+func (h *Handler) TopKeys(c *gin.Context) {
+	startTime := time.Now()
+
+	if h.analytics == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Analytics disabled",
+		})
+		return
+	}
+
+	window, err := analytics.ParseWindow(c.DefaultQuery("window", "5m"))
+	if err != nil {
+		logger.Warn("Invalid window for top keys", logger.ErrorField(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	n, err := strconv.Atoi(c.DefaultQuery("n", "10"))
+	if err != nil || n < 1 || n > maxTopKeys {
+		logger.Warn("Invalid n for top keys", logger.String("n", c.Query("n")))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "n must be between 1 and 100",
+		})
+		return
+	}
+
+	l, ok := h.limiterFor(c)
+	if !ok {
+		return
+	}
+
+	logger.Info("Getting top keys",
+		logger.String("window", window.Name),
+		logger.Int("n", n),
+		logger.String("client_ip", c.ClientIP()),
+	)
+
+	checks, denied, err := h.analytics.TopWithPrefix(c.Request.Context(), window, limiter.NamespacePrefix(l.Namespace()), n)
+	if err != nil {
+		logger.Error("Failed to get top keys", logger.ErrorField(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get top keys",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	duration := time.Since(startTime)
+	logger.Info("Top keys retrieved successfully",
+		logger.String("window", window.Name),
+		logger.Duration("duration", duration),
+	)
+
+	c.JSON(http.StatusOK, TopKeysResp{
+		Window: window.Name,
+		Checks: checks,
+		Denied: denied,
+	})
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/your-org/rate-limiter/events"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// eventsHeartbeat is how often an idle event stream sends a comment, so proxies keep it open
+const eventsHeartbeat = 15 * time.Second
+
+// StreamEvents streams rate limit decisions as server-sent events
+// @Summary Stream decision events
+// @Description Stream the rate limit decisions of all instances as server-sent events. Each "decision" event carries an events.Event in JSON. Denied decisions are always published; allowed decisions only with events.sample_rate > 0, and only to subscribers passing allowed=true. A "dropped" event reports events skipped because the subscriber was too slow. Keys are qualified with their namespace prefix; in a named namespace only its keys are streamed and prefix applies within it
+// @Tags monitoring
+// @Produce text/event-stream
+// @Param prefix query string false "Only keys starting with this prefix"
+// @Param allowed query bool false "Include the sampled allowed decisions (default false, denied only)"
+// @Param sample query number false "Share of the allowed decisions to keep, in (0, 1] (default 1)"
+// @Param X-Namespace header string false "Namespace, the default namespace or the namespace of the credentials when absent"
+// @Success 200 {object} events.Event
+// @Failure 400 {object} map[string]interface{} "Invalid request parameters"
+// @Failure 404 {object} map[string]interface{} "Event stream or namespace not found"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Role or namespace not allowed"
+// @Security ApiKeyAuth
+// @Router /v1/events [get]
+func (h *Handler) StreamEvents(c *gin.Context) {
+	startTime := time.Now()
+
+	if h.events == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Event stream disabled",
+		})
+		return
+	}
+
+	l, ok := h.limiterFor(c)
+	if !ok {
+		return
+	}
+
+	// Keys of the events are qualified with the namespace prefix
+	filter := events.Filter{Prefix: limiter.NamespacePrefix(l.Namespace()) + c.Query("prefix"), Sample: 1}
+	if allowed := c.Query("allowed"); allowed != "" {
+		allowedBool, err := strconv.ParseBool(allowed)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "allowed must be a boolean",
+			})
+			return
+		}
+		filter.Allowed = allowedBool
+	}
+	if sample := c.Query("sample"); sample != "" {
+		sampleFloat, err := strconv.ParseFloat(sample, 64)
+		if err != nil || sampleFloat <= 0 || sampleFloat > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "sample must be a number in (0, 1]",
+			})
+			return
+		}
+		filter.Sample = sampleFloat
+	}
+
+	logger.Info("Event stream subscribed",
+		logger.String("prefix", filter.Prefix),
+		logger.Bool("allowed", filter.Allowed),
+		logger.String("client_ip", c.ClientIP()),
+	)
+
+	sub := h.events.Subscribe(filter)
+	defer h.events.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx buffering
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	var sent int64
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-h.closing:
+			return false
+		case ev := <-sub.C():
+			c.SSEvent("decision", ev)
+			sent++
+		case <-heartbeat.C:
+			if dropped := sub.Dropped(); dropped > 0 {
+				c.SSEvent("dropped", gin.H{"dropped": dropped})
+			} else if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+
+	logger.Info("Event stream closed",
+		logger.String("prefix", filter.Prefix),
+		logger.Int64("events", sent),
+		logger.Duration("duration", time.Since(startTime)),
+	)
+}
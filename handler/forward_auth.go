@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// ForwardAuth checks rate limit for nginx auth_request and Traefik/Caddy forward-auth
+// @Summary Forward-auth rate limit check
+// @Description Check rate limit with the key derived from the configured request headers (default X-Api-Key and X-Model joined by ":"). Designed for nginx auth_request and Traefik/Caddy forward-auth: no request body, 204 if allowed, 429 if rate limited, with RateLimit-* headers in both cases
+// @Tags rate-limit
+// @Param X-Api-Key header string false "API key (default key header)"
+// @Param X-Model header string false "Model (default key header)"
+// @Param X-Namespace header string false "Namespace, the default namespace or the namespace of the credentials when absent"
+// @Success 204 "Request allowed"
+// @Failure 400 {object} map[string]interface{} "Missing key headers"
+// @Failure 404 {object} map[string]interface{} "Namespace not found"
+// @Failure 429 "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Role or namespace not allowed"
+// @Header 204,429 {integer} RateLimit-Limit "Bucket capacity"
+// @Header 204,429 {integer} RateLimit-Remaining "Remaining tokens"
+// @Header 204,429 {integer} RateLimit-Reset "Seconds until the bucket is full again"
+// @Header 429 {integer} Retry-After "Seconds until a token is available"
+// @Security ApiKeyAuth
+// @Router /v1/forward_auth [get]
+func (h *Handler) ForwardAuth(c *gin.Context) {
+	startTime := time.Now()
+
+	cfg := &h.cfg.ForwardAuth
+	key, missing := forwardAuthKey(c, cfg)
+	if missing != "" {
+		logger.Error("Missing key header for forward auth",
+			logger.String("header", missing),
+			logger.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request parameters",
+			"details": "missing header " + missing,
+		})
+		return
+	}
+
+	logger.Info("Forward auth request",
+		logger.String("key", key),
+		logger.String("client_ip", c.ClientIP()),
+		logger.String("original_uri", c.GetHeader("X-Original-URI")),
+	)
+
+	l, ok := h.limiterFor(c)
+	if !ok {
+		return
+	}
+
+	// Check rate limit under the rule of the key, read in the same round trip
+	d, err := l.CheckKey(c.Request.Context(), key, 1)
+	if err != nil {
+		logger.Error("Rate limit check failed",
+			logger.String("key", key),
+			logger.ErrorField(err),
+		)
+		c.JSON(errorStatus(err), gin.H{
+			"error":   "Rate limit check failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	limiter.SetDecisionHeaders(c.Writer.Header(), d)
+
+	duration := time.Since(startTime)
+	logger.Info("Forward auth check completed",
+		logger.String("key", key),
+		logger.Bool("allowed", d.Allowed),
+		logger.Int64("remain", d.Remain),
+		logger.Duration("duration", duration),
+	)
+
+	if !d.Allowed {
+		logger.Warn("Rate limit exceeded",
+			logger.String("key", key),
+		)
+		c.Status(http.StatusTooManyRequests)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// forwardAuthKey builds the rate limiting key from the configured headers.
+// It returns the name of the first missing header if any of them is empty
+func forwardAuthKey(c *gin.Context, cfg *config.ForwardAuthConfig) (key string, missing string) {
+	parts := make([]string, 0, len(cfg.KeyHeaders))
+	for _, header := range cfg.KeyHeaders {
+		value := strings.TrimSpace(c.GetHeader(header))
+		if value == "" {
+			return "", header
+		}
+		parts = append(parts, value)
+	}
+	return strings.Join(parts, cfg.Separator), ""
+}
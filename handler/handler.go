@@ -2,24 +2,81 @@ package handler
 
 import (
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/your-org/rate-limiter/analytics"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/events"
 	"github.com/your-org/rate-limiter/limiter"
 	"github.com/your-org/rate-limiter/logger"
-	"github.com/your-org/rate-limiter/redis"
+	"github.com/your-org/rate-limiter/usage"
 )
 
+// Handler serves the HTTP API on an explicitly constructed limiter
+type Handler struct {
+	namespaces *limiter.Namespaces
+	cfg        *config.Config
+	analytics  *analytics.Recorder
+	usage      *usage.Recorder
+	events     *events.Stream
+
+	closing   chan struct{} // closed by Close, ends the long-lived responses
+	closeOnce sync.Once
+}
+
+// Option customizes a Handler
+type Option func(*Handler)
+
+// WithAnalytics enables the top keys endpoint on the counters of r
+func WithAnalytics(r *analytics.Recorder) Option {
+	return func(h *Handler) { h.analytics = r }
+}
+
+// WithUsage enables the usage history endpoint on the history of r
+func WithUsage(r *usage.Recorder) Option {
+	return func(h *Handler) { h.usage = r }
+}
+
+// WithEvents enables the decision event stream endpoint on s
+func WithEvents(s *events.Stream) Option {
+	return func(h *Handler) { h.events = s }
+}
+
+// New creates the HTTP handlers, serving the default namespace with l
+func New(l *limiter.Limiter, cfg *config.Config, opts ...Option) *Handler {
+	h := &Handler{
+		cfg:     cfg,
+		closing: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.namespaces == nil {
+		h.namespaces = limiter.NewNamespaces(l)
+	}
+	return h
+}
+
+// Close ends the long-lived responses, such as event streams, so the server can drain its
+// connections on shutdown. Other requests are not affected
+func (h *Handler) Close() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
 // CheckReq represents the request for checking rate limit
 type CheckReq struct {
-	Key string `json:"key" binding:"required" example:"your_api_key:gpt-4"` // Rate limiting key (user-defined format)
+	Key    string `json:"key" binding:"required" example:"your_api_key:gpt-4"` // Rate limiting key (user-defined format)
+	Tokens int64  `json:"tokens,omitempty" example:"1"`                        // Number of tokens to take at once, optional (defaults to 1)
 }
 
 // CheckResp represents the response for rate limit check
 type CheckResp struct {
-	Allowed bool   `json:"allowed" example:"true"`       // Whether the request is allowed
-	Message string `json:"message,omitempty" example:""` // Error message (if any)
-	Remain  int64  `json:"remain" example:"45"`          // Number of remaining tokens
+	Allowed  bool   `json:"allowed" example:"true"`        // Whether the request is allowed
+	Message  string `json:"message,omitempty" example:""`  // Error message (if any)
+	Remain   int64  `json:"remain" example:"45"`           // Number of remaining tokens
+	Degraded string `json:"degraded,omitempty" example:""` // Failure policy (open, closed, local) that decided the check while the store was unavailable
 }
 
 // UpdateRuleReq represents the request for updating rate limiting rule
@@ -35,6 +92,28 @@ type UpdateRuleResp struct {
 	Message string `json:"message,omitempty" example:"Rule updated"` // Response message
 }
 
+// DeleteRuleReq represents the request for deleting rate limiting rule
+type DeleteRuleReq struct {
+	Key string `json:"key" binding:"required" example:"your_api_key:gpt-4"` // Rate limiting key
+}
+
+// DeleteRuleResp represents the response for deleting rate limiting rule
+type DeleteRuleResp struct {
+	Status  string `json:"status" example:"success"`                 // Status of the operation
+	Message string `json:"message,omitempty" example:"Rule deleted"` // Response message
+}
+
+// ResetBucketReq represents the request for resetting a token bucket
+type ResetBucketReq struct {
+	Key string `json:"key" binding:"required" example:"your_api_key:gpt-4"` // Rate limiting key
+}
+
+// ResetBucketResp represents the response for resetting a token bucket
+type ResetBucketResp struct {
+	Status  string `json:"status" example:"success"`                 // Status of the operation
+	Message string `json:"message,omitempty" example:"Bucket reset"` // Response message
+}
+
 // StatsResp represents the response for getting statistics
 type StatsResp struct {
 	Rules map[string]map[string]interface{} `json:"rules"` // Rate limiting rules
@@ -48,11 +127,16 @@ type StatsResp struct {
 // @Accept json
 // @Produce json
 // @Param request body CheckReq true "Rate limit check request"
+// @Param X-Namespace header string false "Namespace, the default namespace or the namespace of the credentials when absent"
 // @Success 200 {object} CheckResp
 // @Failure 400 {object} map[string]interface{} "Invalid request parameters"
+// @Failure 404 {object} map[string]interface{} "Namespace not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Role or namespace not allowed"
+// @Security ApiKeyAuth
 // @Router /v1/check_rate_limit [post]
-func CheckRateLimit(c *gin.Context) {
+func (h *Handler) CheckRateLimit(c *gin.Context) {
 	startTime := time.Now()
 
 	var req CheckReq
@@ -68,34 +152,41 @@ func CheckRateLimit(c *gin.Context) {
 		return
 	}
 
+	// Each request consumes 1 token unless more are requested
+	if req.Tokens == 0 {
+		req.Tokens = 1
+	}
+	if req.Tokens < 0 {
+		logger.Warn("Invalid tokens value",
+			logger.String("key", req.Key),
+			logger.Int64("tokens", req.Tokens),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tokens must be greater than 0",
+		})
+		return
+	}
+
 	logger.Info("Rate limit check request",
 		logger.String("key", req.Key),
+		logger.Int64("tokens", req.Tokens),
 		logger.String("client_ip", c.ClientIP()),
 		logger.String("user_agent", c.GetHeader("User-Agent")),
 	)
 
-	// Get rule from Redis
-	rule, err := limiter.GetRuleFromRedis(c.Request.Context(), req.Key)
-	if err != nil {
-		logger.Error("Failed to get rate limit rule",
-			logger.String("key", req.Key),
-			logger.ErrorField(err),
-		)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get rate limit rule",
-			"details": err.Error(),
-		})
+	l, ok := h.limiterFor(c)
+	if !ok {
 		return
 	}
 
-	// Check rate limit
-	allowed, remain, err := limiter.AllowWithRemain(c.Request.Context(), rule)
+	// Check rate limit under the rule of the key, read in the same round trip
+	d, err := l.CheckKey(c.Request.Context(), req.Key, req.Tokens)
 	if err != nil {
 		logger.Error("Rate limit check failed",
 			logger.String("key", req.Key),
 			logger.ErrorField(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(errorStatus(err), gin.H{
 			"error":   "Rate limit check failed",
 			"details": err.Error(),
 		})
@@ -103,10 +194,14 @@ func CheckRateLimit(c *gin.Context) {
 	}
 
 	resp := CheckResp{
-		Allowed: allowed,
-		Remain:  remain,
+		Allowed:  d.Allowed,
+		Remain:   d.Remain,
+		Degraded: string(d.Degraded),
 	}
-	if !allowed {
+	if d.Degraded != "" {
+		c.Header(limiter.DegradedHeader, resp.Degraded)
+	}
+	if !d.Allowed {
 		resp.Message = "Rate limit exceeded"
 		logger.Warn("Rate limit exceeded",
 			logger.String("key", req.Key),
@@ -116,8 +211,8 @@ func CheckRateLimit(c *gin.Context) {
 	duration := time.Since(startTime)
 	logger.Info("Rate limit check completed",
 		logger.String("key", req.Key),
-		logger.Bool("allowed", allowed),
-		logger.Int64("remain", remain),
+		logger.Bool("allowed", d.Allowed),
+		logger.Int64("remain", d.Remain),
 		logger.Duration("duration", duration),
 	)
 
@@ -131,11 +226,16 @@ func CheckRateLimit(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body UpdateRuleReq true "Rate limiting rule update request"
+// @Param X-Namespace header string false "Namespace, the default namespace or the namespace of the credentials when absent"
 // @Success 200 {object} UpdateRuleResp
 // @Failure 400 {object} map[string]interface{} "Invalid request parameters"
+// @Failure 404 {object} map[string]interface{} "Namespace not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Role or namespace not allowed"
+// @Security ApiKeyAuth
 // @Router /v1/update_rule [post]
-func UpdateRule(c *gin.Context) {
+func (h *Handler) UpdateRule(c *gin.Context) {
 	startTime := time.Now()
 
 	var req UpdateRuleReq
@@ -186,14 +286,19 @@ func UpdateRule(c *gin.Context) {
 		logger.String("client_ip", c.ClientIP()),
 	)
 
+	l, ok := h.limiterFor(c)
+	if !ok {
+		return
+	}
+
 	// Update rule to Redis
-	err := limiter.SetRuleToRedis(c.Request.Context(), req.Key, req.RateLimit, req.Burst)
+	err := l.SetRule(c.Request.Context(), req.Key, req.RateLimit, req.Burst)
 	if err != nil {
 		logger.Error("Failed to update rate limit rule",
 			logger.String("key", req.Key),
 			logger.ErrorField(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(errorStatus(err), gin.H{
 			"error":   "Failed to update rate limit rule",
 			"details": err.Error(),
 		})
@@ -216,16 +321,158 @@ func UpdateRule(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// DeleteRule deletes rate limiting rule
+// @Summary Delete rate limiting rule
+// @Description Delete the rate limiting rule of a key, which falls back to the default rate and burst
+// @Tags rate-limit
+// @Accept json
+// @Produce json
+// @Param request body DeleteRuleReq true "Rate limiting rule delete request"
+// @Param X-Namespace header string false "Namespace, the default namespace or the namespace of the credentials when absent"
+// @Success 200 {object} DeleteRuleResp
+// @Failure 400 {object} map[string]interface{} "Invalid request parameters"
+// @Failure 404 {object} map[string]interface{} "Rule or namespace not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Role or namespace not allowed"
+// @Security ApiKeyAuth
+// @Router /v1/delete_rule [post]
+func (h *Handler) DeleteRule(c *gin.Context) {
+	startTime := time.Now()
+
+	var req DeleteRuleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request parameters for delete rule",
+			logger.ErrorField(err),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	logger.Info("Deleting rate limit rule",
+		logger.String("key", req.Key),
+		logger.String("client_ip", c.ClientIP()),
+	)
+
+	l, ok := h.limiterFor(c)
+	if !ok {
+		return
+	}
+
+	existed, err := l.DeleteRule(c.Request.Context(), req.Key)
+	if err != nil {
+		logger.Error("Failed to delete rate limit rule",
+			logger.String("key", req.Key),
+			logger.ErrorField(err),
+		)
+		c.JSON(errorStatus(err), gin.H{
+			"error":   "Failed to delete rate limit rule",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !existed {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Rule not found",
+			"details": req.Key,
+		})
+		return
+	}
+
+	duration := time.Since(startTime)
+	logger.Info("Rate limit rule deleted successfully",
+		logger.String("key", req.Key),
+		logger.Duration("duration", duration),
+	)
+
+	c.JSON(http.StatusOK, DeleteRuleResp{
+		Status:  "success",
+		Message: "Rate limit rule deleted successfully",
+	})
+}
+
+// ResetBucket resets a token bucket
+// @Summary Reset token bucket
+// @Description Refill the token bucket of a key, e.g. after a rule change or an incident
+// @Tags rate-limit
+// @Accept json
+// @Produce json
+// @Param request body ResetBucketReq true "Token bucket reset request"
+// @Param X-Namespace header string false "Namespace, the default namespace or the namespace of the credentials when absent"
+// @Success 200 {object} ResetBucketResp
+// @Failure 400 {object} map[string]interface{} "Invalid request parameters"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Role or namespace not allowed"
+// @Security ApiKeyAuth
+// @Router /v1/reset_bucket [post]
+func (h *Handler) ResetBucket(c *gin.Context) {
+	startTime := time.Now()
+
+	var req ResetBucketReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request parameters for reset bucket",
+			logger.ErrorField(err),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	logger.Info("Resetting token bucket",
+		logger.String("key", req.Key),
+		logger.String("client_ip", c.ClientIP()),
+	)
+
+	l, ok := h.limiterFor(c)
+	if !ok {
+		return
+	}
+
+	if err := l.ResetBucket(c.Request.Context(), req.Key); err != nil {
+		logger.Error("Failed to reset token bucket",
+			logger.String("key", req.Key),
+			logger.ErrorField(err),
+		)
+		c.JSON(errorStatus(err), gin.H{
+			"error":   "Failed to reset token bucket",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	duration := time.Since(startTime)
+	logger.Info("Token bucket reset successfully",
+		logger.String("key", req.Key),
+		logger.Duration("duration", duration),
+	)
+
+	c.JSON(http.StatusOK, ResetBucketResp{
+		Status:  "success",
+		Message: "Token bucket reset successfully",
+	})
+}
+
 // GetStats gets monitoring statistics
 // @Summary Get all monitoring statistics
 // @Description Get comprehensive monitoring statistics for all rate limiting rules
 // @Tags monitoring
 // @Accept json
 // @Produce json
+// @Param X-Namespace header string false "Namespace, the default namespace or the namespace of the credentials when absent"
 // @Success 200 {object} StatsResp
+// @Failure 404 {object} map[string]interface{} "Namespace not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Role or namespace not allowed"
+// @Security ApiKeyAuth
 // @Router /v1/stats [get]
-func GetStats(c *gin.Context) {
+func (h *Handler) GetStats(c *gin.Context) {
 	startTime := time.Now()
 
 	logger.Info("Getting stats request",
@@ -233,10 +480,15 @@ func GetStats(c *gin.Context) {
 		logger.String("user_agent", c.GetHeader("User-Agent")),
 	)
 
+	l, ok := h.limiterFor(c)
+	if !ok {
+		return
+	}
+
 	ctx := c.Request.Context()
 
 	// Get all rules
-	rules, err := redis.GetAllRules(ctx)
+	rules, err := l.GetAllRules(ctx)
 	if err != nil {
 		logger.Error("Failed to get rules", logger.ErrorField(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -249,7 +501,7 @@ func GetStats(c *gin.Context) {
 	// Get statistics
 	stats := make(map[string]map[string]interface{})
 	for key := range rules {
-		stat, err := limiter.GetStats(c.Request.Context(), key)
+		stat, err := l.GetStats(c.Request.Context(), key)
 		if err != nil {
 			// Skip this key if getting statistics fails
 			logger.Warn("Failed to get stats for key",
@@ -283,11 +535,16 @@ func GetStats(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param key query string true "Rate limiting key"
+// @Param X-Namespace header string false "Namespace, the default namespace or the namespace of the credentials when absent"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{} "Missing required parameters"
+// @Failure 404 {object} map[string]interface{} "Namespace not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Role or namespace not allowed"
+// @Security ApiKeyAuth
 // @Router /v1/rule_stats [get]
-func GetRuleStats(c *gin.Context) {
+func (h *Handler) GetRuleStats(c *gin.Context) {
 	startTime := time.Now()
 
 	key := c.Query("key")
@@ -307,13 +564,18 @@ func GetRuleStats(c *gin.Context) {
 		logger.String("client_ip", c.ClientIP()),
 	)
 
-	stats, err := limiter.GetStats(c.Request.Context(), key)
+	l, ok := h.limiterFor(c)
+	if !ok {
+		return
+	}
+
+	stats, err := l.GetStats(c.Request.Context(), key)
 	if err != nil {
 		logger.Error("Failed to get stats",
 			logger.String("key", key),
 			logger.ErrorField(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(errorStatus(err), gin.H{
 			"error":   "Failed to get stats",
 			"details": err.Error(),
 		})
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/your-org/rate-limiter/auth"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// NamespaceHeader selects the namespace of a request, the default namespace when absent.
+// Credentials scoped to a namespace use theirs when the header is absent
+const NamespaceHeader = "X-Namespace"
+
+// WithNamespaces serves the namespaces of ns, by default only the default namespace is served
+func WithNamespaces(ns *limiter.Namespaces) Option {
+	return func(h *Handler) { h.namespaces = ns }
+}
+
+// limiterFor returns the Limiter of the namespace of the request. On failure it writes the
+// error response and returns false
+func (h *Handler) limiterFor(c *gin.Context) (*limiter.Limiter, bool) {
+	requested := c.GetHeader(NamespaceHeader)
+	namespace, err := auth.Namespace(auth.FromGin(c), requested)
+	if errors.Is(err, auth.ErrNamespaceForbidden) {
+		logger.Warn("Namespace not allowed",
+			logger.String("namespace", requested),
+			logger.String("principal", auth.FromGin(c).Name),
+			logger.String("path", c.FullPath()),
+		)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Forbidden",
+			"details": "namespace " + requested + " not allowed",
+		})
+		return nil, false
+	}
+
+	l, ok := h.namespaces.Get(namespace)
+	if !ok {
+		logger.Warn("Namespace not found",
+			logger.String("namespace", namespace),
+			logger.String("path", c.FullPath()),
+		)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Namespace not found",
+			"details": namespace,
+		})
+		return nil, false
+	}
+	return l, true
+}
+
+// errorStatus returns the status of an error of a Limiter: 400 for a key reserved for the named
+// namespaces, 500 otherwise
+func errorStatus(err error) int {
+	if errors.Is(err, limiter.ErrReservedKey) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+	"github.com/your-org/rate-limiter/usage"
+)
+
+// UsageResp represents the response for getting the usage history of a key
+type UsageResp struct {
+	Key            string        `json:"key" example:"your_api_key:gpt-4"` // Rate limiting key
+	Window         string        `json:"window" example:"1h0m0s"`          // Time window, capped to the retention
+	Rate           int64         `json:"rate" example:"10"`                // Current rule: tokens per second
+	Burst          int64         `json:"burst" example:"50"`               // Current rule: bucket capacity
+	LimitPerMinute int64         `json:"limit_per_minute" example:"600"`   // Tokens the rule refills per minute
+	Points         []usage.Point `json:"points"`                           // Usage per minute, oldest first
+}
+
+// GetUsage gets the usage history of a key
+// @Summary Get usage history
+// @Description Get the allowed and denied checks and the tokens taken per minute for a key over a window, together with its current rule. Minutes without checks have zero counts; the latest minute may lag by the flush interval
+// @Tags monitoring
+// @Produce json
+// @Param key query string true "Rate limiting key"
+// @Param window query string false "Time window as a duration, e.g. 15m or 6h (default 1h, at most the retention)"
+// @Param X-Namespace header string false "Namespace, the default namespace or the namespace of the credentials when absent"
+// @Success 200 {object} UsageResp
+// @Failure 400 {object} map[string]interface{} "Invalid request parameters"
+// @Failure 404 {object} map[string]interface{} "Usage history or namespace not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Role or namespace not allowed"
+// @Security ApiKeyAuth
+// @Router /v1/usage [get]
+func (h *Handler) GetUsage(c *gin.Context) {
+	startTime := time.Now()
+
+	if h.usage == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Usage history disabled",
+		})
+		return
+	}
+
+	key := c.Query("key")
+	if key == "" {
+		logger.Warn("Missing required parameter for usage history")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "key is required",
+		})
+		return
+	}
+
+	window, err := time.ParseDuration(c.DefaultQuery("window", "1h"))
+	if err != nil || window < time.Minute {
+		logger.Warn("Invalid window for usage history", logger.String("window", c.Query("window")))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "window must be a duration of at least 1m",
+		})
+		return
+	}
+	if window > h.usage.Retention() {
+		window = h.usage.Retention()
+	}
+
+	logger.Info("Getting usage history",
+		logger.String("key", key),
+		logger.Duration("window", window),
+		logger.String("client_ip", c.ClientIP()),
+	)
+
+	l, ok := h.limiterFor(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	rule, err := l.GetRule(ctx, key)
+	if err != nil {
+		logger.Error("Failed to get rate limit rule",
+			logger.String("key", key),
+			logger.ErrorField(err),
+		)
+		c.JSON(errorStatus(err), gin.H{
+			"error":   "Failed to get rate limit rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	points, err := h.usage.History(ctx, limiter.NamespacePrefix(l.Namespace())+key, window)
+	if err != nil {
+		logger.Error("Failed to get usage history",
+			logger.String("key", key),
+			logger.ErrorField(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get usage history",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	duration := time.Since(startTime)
+	logger.Info("Usage history retrieved successfully",
+		logger.String("key", key),
+		logger.Int("points", len(points)),
+		logger.Duration("duration", duration),
+	)
+
+	c.JSON(http.StatusOK, UsageResp{
+		Key:            key,
+		Window:         window.String(),
+		Rate:           rule.Rate,
+		Burst:          rule.Burst,
+		LimitPerMinute: rule.Rate * 60,
+		Points:         points,
+	})
+}
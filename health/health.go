@@ -0,0 +1,149 @@
+// Package health runs the readiness checks of the service components, such as Redis, and caches
+// their outcome so frequent probes from several load balancers do not hammer the dependencies
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// Component statuses
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded" // an optional component is down, the service still takes traffic
+)
+
+// CheckFunc checks a component. It returns details reported with its status, and an error if the
+// component is down
+type CheckFunc func(ctx context.Context) (details interface{}, err error)
+
+// Component is the status of a component in a Report
+type Component struct {
+	Status     string      `json:"status"`
+	Error      string      `json:"error,omitempty"`
+	Details    interface{} `json:"details,omitempty"`
+	DurationMS float64     `json:"duration_ms"`
+}
+
+// Report is the outcome of the checks
+type Report struct {
+	Status     string               `json:"status"` // up when every component is up, degraded when only optional ones are not
+	Components map[string]Component `json:"components"`
+	CheckedAt  time.Time            `json:"checked_at"`
+}
+
+// Up reports whether every required component is up
+func (r *Report) Up() bool {
+	return r.Status != StatusDown
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	optional bool
+}
+
+// Checker runs the checks of the components and caches their report
+type Checker struct {
+	checks   []check
+	cacheTTL time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex // held while checking, concurrent callers wait for the same report
+	report  *Report
+	expires time.Time
+}
+
+// New creates a Checker caching its report for cacheTTL, each check being given timeout
+func New(cacheTTL, timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	return &Checker{
+		cacheTTL: cacheTTL,
+		timeout:  timeout,
+		now:      time.Now,
+	}
+}
+
+// Add adds the check of the component name. Checks must be added before the first Check
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// AddOptional is Add for a component the service can run without: while it is down, it is reported
+// degraded and the report is degraded rather than down
+func (c *Checker) AddOptional(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn, optional: true})
+}
+
+// Check returns the report of the components, running the checks concurrently unless the cached
+// report is still fresh
+func (c *Checker) Check(ctx context.Context) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.report != nil && now.Before(c.expires) {
+		return c.report
+	}
+
+	// The checks do not use ctx for cancellation: a probe giving up must not cache a failure
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	defer cancel()
+
+	components := make(map[string]Component, len(c.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ch := range c.checks {
+		wg.Add(1)
+		go func(ch check) {
+			defer wg.Done()
+			start := time.Now()
+			details, err := ch.fn(checkCtx)
+			comp := Component{
+				Status:     StatusUp,
+				Details:    details,
+				DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				comp.Status = StatusDown
+				if ch.optional {
+					comp.Status = StatusDegraded
+				}
+				comp.Error = err.Error()
+			}
+			mu.Lock()
+			components[ch.name] = comp
+			mu.Unlock()
+		}(ch)
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusUp, Components: components, CheckedAt: now}
+	for name, comp := range components {
+		switch comp.Status {
+		case StatusDown:
+			report.Status = StatusDown
+		case StatusDegraded:
+			if report.Status == StatusUp {
+				report.Status = StatusDegraded
+			}
+		}
+		if comp.Status != StatusUp {
+			logger.Warn("Readiness check failed",
+				logger.String("component", name),
+				logger.String("error", comp.Error),
+			)
+		}
+	}
+
+	c.report = report
+	c.expires = now.Add(c.cacheTTL)
+	return report
+}
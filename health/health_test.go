@@ -0,0 +1,124 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("connection refused")
+
+func up(context.Context) (interface{}, error)   { return "ok", nil }
+func down(context.Context) (interface{}, error) { return nil, errDown }
+
+func TestCheckAggregation(t *testing.T) {
+	tests := []struct {
+		name     string
+		required []CheckFunc
+		optional []CheckFunc
+		want     string
+		wantUp   bool
+	}{
+		{name: "no checks", want: StatusUp, wantUp: true},
+		{name: "all up", required: []CheckFunc{up, up}, optional: []CheckFunc{up}, want: StatusUp, wantUp: true},
+		{name: "optional down", required: []CheckFunc{up}, optional: []CheckFunc{down}, want: StatusDegraded, wantUp: true},
+		{name: "required down", required: []CheckFunc{up, down}, optional: []CheckFunc{up}, want: StatusDown},
+		{name: "both down", required: []CheckFunc{down}, optional: []CheckFunc{down}, want: StatusDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(0, time.Second)
+			for i, fn := range tt.required {
+				c.Add(fmt.Sprintf("required-%d", i), fn)
+			}
+			for i, fn := range tt.optional {
+				c.AddOptional(fmt.Sprintf("optional-%d", i), fn)
+			}
+
+			report := c.Check(context.Background())
+			if report.Status != tt.want || report.Up() != tt.wantUp {
+				t.Fatalf("got %s (up %v), want %s (up %v)", report.Status, report.Up(), tt.want, tt.wantUp)
+			}
+			if len(report.Components) != len(tt.required)+len(tt.optional) {
+				t.Fatalf("got %d components", len(report.Components))
+			}
+			for name, comp := range report.Components {
+				optional := strings.HasPrefix(name, "optional")
+				switch {
+				case comp.Status == StatusUp && (comp.Error != "" || comp.Details != "ok"):
+					t.Errorf("%s: got %+v, want the details of the check", name, comp)
+				case comp.Status == StatusDown && (optional || comp.Error != errDown.Error()):
+					t.Errorf("%s: got %+v", name, comp)
+				case comp.Status == StatusDegraded && (!optional || comp.Error != errDown.Error()):
+					t.Errorf("%s: got %+v", name, comp)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckCache(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var calls atomic.Int64
+	var failing atomic.Bool
+	c := New(2*time.Second, time.Second)
+	c.now = func() time.Time { return now }
+	c.Add("redis", func(context.Context) (interface{}, error) {
+		calls.Add(1)
+		if failing.Load() {
+			return nil, errDown
+		}
+		return nil, nil
+	})
+
+	tests := []struct {
+		name      string
+		advance   time.Duration
+		failing   bool
+		want      string
+		wantCalls int64
+	}{
+		{name: "first check", want: StatusUp, wantCalls: 1},
+		{name: "cached", advance: time.Second, failing: true, want: StatusUp, wantCalls: 1},
+		{name: "just before expiry", advance: time.Second - time.Nanosecond, failing: true, want: StatusUp, wantCalls: 1},
+		{name: "expired", advance: time.Nanosecond, failing: true, want: StatusDown, wantCalls: 2},
+		{name: "failure cached", advance: time.Second, want: StatusDown, wantCalls: 2},
+		{name: "recovered", advance: time.Second, want: StatusUp, wantCalls: 3},
+	}
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+		failing.Store(tt.failing)
+		report := c.Check(context.Background())
+		if report.Status != tt.want || calls.Load() != tt.wantCalls {
+			t.Errorf("%s: got %s after %d checks, want %s after %d", tt.name, report.Status, calls.Load(), tt.want, tt.wantCalls)
+		}
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	c := New(0, 20*time.Millisecond)
+	c.Add("slow", func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	c.AddOptional("fast", up)
+
+	// A canceled caller does not cut the checks short
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	report := c.Check(ctx)
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > time.Second {
+		t.Errorf("checked in %s, want the 20ms timeout", elapsed)
+	}
+	if comp := report.Components["slow"]; comp.Status != StatusDown || comp.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("slow: got %+v, want down on the deadline", comp)
+	}
+	if comp := report.Components["fast"]; comp.Status != StatusUp {
+		t.Errorf("fast: got %+v, want up", comp)
+	}
+}
@@ -0,0 +1,85 @@
+package limiter
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+)
+
+// FailurePolicy decides the checks the Store fails, e.g. while Redis is unavailable
+type FailurePolicy string
+
+// Failure policies
+const (
+	FailError  FailurePolicy = "error"  // the check returns the error
+	FailOpen   FailurePolicy = "open"   // the request is allowed
+	FailClosed FailurePolicy = "closed" // the request is denied
+	FailLocal  FailurePolicy = "local"  // the request is checked against the fallback Store of this process
+)
+
+// WithFallback sets the Store checked by the local failure policy, usually a memory.Store.
+// Its buckets are not shared with other instances, so each of them allows the rate of the rule.
+// Without a fallback, the local policy denies the requests
+func WithFallback(store Store) Option {
+	return func(l *Limiter) { l.fallback = store }
+}
+
+// FailurePolicy returns the failure policy of key: the policy of the first failure_policies
+// pattern matching it, or failure_policy. Unknown policies are treated as FailError
+func (l *Limiter) FailurePolicy(key string) FailurePolicy {
+	policy := l.defaults.FailurePolicy
+	for _, p := range l.defaults.FailurePolicies {
+		if ok, _ := path.Match(p.Pattern, key); ok {
+			policy = p.Policy
+			break
+		}
+	}
+
+	switch FailurePolicy(policy) {
+	case FailOpen, FailClosed, FailLocal:
+		return FailurePolicy(policy)
+	default:
+		return FailError
+	}
+}
+
+// Degrades reports whether the failure policies of defaults decide every check the Store fails,
+// none of them being error. The service then keeps taking traffic while the Store is unavailable
+func Degrades(defaults *config.LimiterConfig) bool {
+	if !degrades(defaults.FailurePolicy) {
+		return false
+	}
+	for _, p := range defaults.FailurePolicies {
+		if !degrades(p.Policy) {
+			return false
+		}
+	}
+	return true
+}
+
+func degrades(policy string) bool {
+	switch FailurePolicy(policy) {
+	case FailOpen, FailClosed, FailLocal:
+		return true
+	default:
+		return false
+	}
+}
+
+// degrade decides a check of rule the Store failed according to policy. The remaining tokens
+// are unknown with the open and closed policies and reported as 0
+func (l *Limiter) degrade(ctx context.Context, policy FailurePolicy, rule Rule, now time.Time, n int64) (bool, int64, error) {
+	switch policy {
+	case FailOpen:
+		return true, 0, nil
+	case FailLocal:
+		if l.fallback != nil {
+			return l.fallback.TakeTokens(ctx, rule.Key, rule.Rate, rule.Burst, now.Unix(), n)
+		}
+		return false, 0, nil
+	default:
+		return false, 0, nil
+	}
+}
@@ -0,0 +1,132 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/limiter/memory"
+)
+
+var errUnavailable = errors.New("store unavailable")
+
+// failingStore is a RuleTaker whose operations fail, with rules cached in the process
+type failingStore struct {
+	limiter.Store
+	cached   map[string][2]int64
+	getRules int
+}
+
+func (s *failingStore) TakeTokensWithRule(context.Context, string, int64, int64, int64, int64) (limiter.TakeResult, error) {
+	return limiter.TakeResult{}, errUnavailable
+}
+
+func (s *failingStore) GetRule(context.Context, string) (int64, int64, error) {
+	s.getRules++
+	return 0, 0, errUnavailable
+}
+
+func (s *failingStore) CachedRule(key string) (int64, int64, bool) {
+	rule, ok := s.cached[key]
+	return rule[0], rule[1], ok
+}
+
+func TestFailurePolicies(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     string
+		key        string
+		wantErr    bool
+		allowed    bool
+		remain     int64
+		burst      int64
+		noFallback bool
+	}{
+		{name: "error", policy: "error", key: "key", wantErr: true},
+		{name: "open", policy: "open", key: "key", allowed: true, burst: 3},
+		{name: "closed", policy: "closed", key: "key", allowed: false, burst: 3},
+		{name: "local, defaults", policy: "local", key: "key", allowed: true, remain: 2, burst: 3},
+		{name: "local, cached rule", policy: "local", key: "cached", allowed: true, remain: 9, burst: 10},
+		{name: "local without fallback", policy: "local", key: "key", allowed: false, burst: 3, noFallback: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := memory.New(0)
+			t.Cleanup(func() { inner.Close() })
+			store := &failingStore{Store: inner, cached: map[string][2]int64{"cached": {1, 10}}}
+			defaults := config.LimiterConfig{DefaultRate: 1, DefaultBurst: 3, FailurePolicy: tt.policy}
+			var opts []limiter.Option
+			if !tt.noFallback {
+				fallback := memory.New(0)
+				t.Cleanup(func() { fallback.Close() })
+				opts = append(opts, limiter.WithFallback(fallback))
+			}
+			l := limiter.New(store, defaults, opts...)
+
+			d, err := l.CheckKey(context.Background(), tt.key, 1)
+			if tt.wantErr {
+				if !errors.Is(err, errUnavailable) {
+					t.Fatalf("got %v, want the store error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Allowed != tt.allowed || d.Remain != tt.remain || d.Burst != tt.burst {
+				t.Errorf("got allowed=%v remain=%d burst=%d, want %v %d %d", d.Allowed, d.Remain, d.Burst, tt.allowed, tt.remain, tt.burst)
+			}
+			if d.Degraded != limiter.FailurePolicy(tt.policy) {
+				t.Errorf("degraded: got %q, want %q", d.Degraded, tt.policy)
+			}
+			if store.getRules != 0 {
+				t.Errorf("the failed store was asked for the rule %d times", store.getRules)
+			}
+		})
+	}
+}
+
+func TestFailurePolicyPatterns(t *testing.T) {
+	l := limiter.New(nil, config.LimiterConfig{
+		FailurePolicy: "open",
+		FailurePolicies: []config.FailurePolicyConfig{
+			{Pattern: "login:*", Policy: "closed"},
+			{Pattern: "*", Policy: "local"},
+		},
+	})
+
+	tests := map[string]limiter.FailurePolicy{
+		"login:alice": limiter.FailClosed,
+		"api:alice":   limiter.FailLocal,
+	}
+	for key, want := range tests {
+		if got := l.FailurePolicy(key); got != want {
+			t.Errorf("%s: got %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestDegrades(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		policies []config.FailurePolicyConfig
+		want     bool
+	}{
+		{name: "error", policy: "error", want: false},
+		{name: "open", policy: "open", want: true},
+		{name: "local", policy: "local", want: true},
+		{name: "override to error", policy: "open", policies: []config.FailurePolicyConfig{{Pattern: "a:*", Policy: "error"}}, want: false},
+		{name: "overrides", policy: "closed", policies: []config.FailurePolicyConfig{{Pattern: "a:*", Policy: "local"}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.LimiterConfig{FailurePolicy: tt.policy, FailurePolicies: tt.policies}
+			if got := limiter.Degrades(cfg); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
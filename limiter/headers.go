@@ -0,0 +1,30 @@
+package limiter
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DegradedHeader carries the failure policy that decided a check the store failed, see Decision.Degraded
+const DegradedHeader = "X-RateLimit-Degraded"
+
+// SetHeaders sets the RateLimit-* headers from the IETF RateLimit header fields draft,
+// plus Retry-After when the request was not allowed
+func SetHeaders(h http.Header, rule Rule, remain int64, allowed bool) {
+	h.Set("RateLimit-Limit", strconv.FormatInt(rule.Burst, 10))
+	h.Set("RateLimit-Remaining", strconv.FormatInt(remain, 10))
+	h.Set("RateLimit-Reset", strconv.FormatInt(int64(rule.ResetAfter(remain)/time.Second), 10))
+	if !allowed {
+		h.Set("Retry-After", strconv.FormatInt(int64(rule.RetryAfter(remain)/time.Second), 10))
+	}
+}
+
+// SetDecisionHeaders sets the headers of SetHeaders for decision d, plus DegradedHeader when d
+// is degraded
+func SetDecisionHeaders(h http.Header, d Decision) {
+	SetHeaders(h, d.Rule(), d.Remain, d.Allowed)
+	if d.Degraded != "" {
+		h.Set(DegradedHeader, string(d.Degraded))
+	}
+}
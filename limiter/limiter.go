@@ -2,197 +2,412 @@ package limiter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/your-org/rate-limiter/config"
 	"github.com/your-org/rate-limiter/logger"
-	"github.com/your-org/rate-limiter/redis"
+	"github.com/your-org/rate-limiter/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
+// tracer creates the limiter spans. Keys are not recorded, they may contain API keys;
+// spans carry the metrics key pattern instead
+var tracer = otel.Tracer("github.com/your-org/rate-limiter/limiter")
+
 type Rule struct {
 	Key   string // apikey:model
 	Rate  int64  // tokens generated per second
 	Burst int64  // bucket capacity
 }
 
+// ResetAfter returns how long it takes for a bucket holding remain tokens to refill to Burst
+func (r Rule) ResetAfter(remain int64) time.Duration {
+	if r.Rate <= 0 || remain >= r.Burst {
+		return 0
+	}
+	missing := r.Burst - remain
+	seconds := (missing + r.Rate - 1) / r.Rate // The script refills in whole seconds
+	return time.Duration(seconds) * time.Second
+}
+
+// RetryAfter returns how long it takes for a bucket holding remain tokens to have one token available
+func (r Rule) RetryAfter(remain int64) time.Duration {
+	if r.Rate <= 0 || remain >= 1 {
+		return 0
+	}
+	seconds := (1 - remain + r.Rate - 1) / r.Rate
+	return time.Duration(seconds) * time.Second
+}
+
+// Limiter checks rate limits against the rules and token buckets held by its Store.
+// With a redis.Store, rules and buckets use the same Redis keys as the service,
+// so a Limiter embedded in another process enforces the same limits
+type Limiter struct {
+	store         Store
+	defaults      config.LimiterConfig
+	now           func() time.Time
+	log           *zap.Logger
+	observers     []Observer
+	ruleObservers []RuleObserver
+	namespace     string
+	fallback      Store
+}
+
+// Decision is the outcome of a rate limit check
+type Decision struct {
+	Key       string // Qualified with the namespace prefix, see NamespacePrefix
+	Namespace string
+	Rate      int64
+	Burst     int64
+	Requested int64
+	Remain    int64
+	Allowed   bool
+	Time      time.Time
+	Degraded  FailurePolicy // Policy that decided the check the Store failed, "" otherwise
+}
+
+// Rule returns the rule the decision was made with, keyed with the qualified key
+func (d Decision) Rule() Rule {
+	return Rule{Key: d.Key, Rate: d.Rate, Burst: d.Burst}
+}
+
+// Observer is notified of every check decision. It is called synchronously on the
+// request path, so it must be fast and must not block
+type Observer func(ctx context.Context, d Decision)
+
+// RuleChange is a rule being set or deleted
+type RuleChange struct {
+	Key       string // Qualified with the namespace prefix, see NamespacePrefix
+	Namespace string
+	Rate      int64 // 0 when Deleted
+	Burst     int64 // 0 when Deleted
+	Deleted   bool
+	Time      time.Time
+}
+
+// RuleObserver is notified after a rule is set or deleted through the Limiter
+type RuleObserver func(ctx context.Context, c RuleChange)
+
+// Option customizes a Limiter
+type Option func(*Limiter)
+
+// WithClock sets the clock used to refill token buckets, time.Now by default
+func WithClock(now func() time.Time) Option {
+	return func(l *Limiter) { l.now = now }
+}
+
+// WithLogger sets the logger, the global logger by default
+func WithLogger(log *zap.Logger) Option {
+	return func(l *Limiter) { l.log = log }
+}
+
+// WithObserver adds an observer notified of every check decision
+func WithObserver(o Observer) Option {
+	return func(l *Limiter) { l.observers = append(l.observers, o) }
+}
+
+// WithRuleObserver adds an observer notified of every rule change
+func WithRuleObserver(o RuleObserver) Option {
+	return func(l *Limiter) { l.ruleObservers = append(l.ruleObservers, o) }
+}
+
+// New creates a Limiter on store. defaults provides the rate and burst used for keys without a rule
+func New(store Store, defaults config.LimiterConfig, opts ...Option) *Limiter {
+	l := &Limiter{
+		store:    store,
+		defaults: defaults,
+		now:      time.Now,
+		log:      logger.Logger,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.log == nil {
+		l.log = zap.NewNop()
+	}
+	return l
+}
+
+// ErrNoDefault is returned by the package-level functions before SetDefault is called
+var ErrNoDefault = errors.New("limiter: no default limiter, call SetDefault")
+
+// defaultLimiter backs the package-level functions
+var defaultLimiter atomic.Pointer[Limiter]
+
+// SetDefault sets the Limiter used by the package-level functions, the service sets the Limiter of
+// the default namespace
+func SetDefault(l *Limiter) {
+	defaultLimiter.Store(l)
+}
+
+func getDefault() (*Limiter, error) {
+	l := defaultLimiter.Load()
+	if l == nil {
+		return nil, ErrNoDefault
+	}
+	return l, nil
+}
+
 // Allow determines if the current request is allowed
-// Uses the officially recommended token bucket Redis Lua script
 func Allow(ctx context.Context, rule Rule) (bool, error) {
-	// Use default values if no rule is specified
-	if rule.Rate == 0 {
-		rule.Rate = config.GlobalConfig.Limiter.DefaultRate
-		logger.Debug("Using default rate", logger.Int64("rate", rule.Rate))
+	l, err := getDefault()
+	if err != nil {
+		return false, err
 	}
-	if rule.Burst == 0 {
-		rule.Burst = config.GlobalConfig.Limiter.DefaultBurst
-		logger.Debug("Using default burst", logger.Int64("burst", rule.Burst))
+	return l.Allow(ctx, rule)
+}
+
+// AllowWithRemain determines if the current request is allowed and returns remaining tokens
+func AllowWithRemain(ctx context.Context, rule Rule) (bool, int64, error) {
+	l, err := getDefault()
+	if err != nil {
+		return false, 0, err
 	}
+	return l.AllowWithRemain(ctx, rule)
+}
 
-	logger.Debug("Checking rate limit",
-		logger.String("key", rule.Key),
-		logger.Int64("rate", rule.Rate),
-		logger.Int64("burst", rule.Burst),
-	)
+// AllowNWithRemain determines if n tokens can be taken at once and returns remaining tokens
+func AllowNWithRemain(ctx context.Context, rule Rule, n int64) (bool, int64, error) {
+	l, err := getDefault()
+	if err != nil {
+		return false, 0, err
+	}
+	return l.AllowNWithRemain(ctx, rule, n)
+}
 
-	const script = `
-local key     = KEYS[1]
-local rate    = tonumber(ARGV[1])
-local burst   = tonumber(ARGV[2])
-local now     = tonumber(ARGV[3])
-local requested = tonumber(ARGV[4])
-
-local fill_time = burst/rate
-local ttl = math.floor(fill_time*2)
-
-local last_tokens = tonumber(redis.call("get", key) or burst)
-local last_refreshed = tonumber(redis.call("get", key .. ":last_refreshed") or now)
-
-local delta = math.max(0, now-last_refreshed)
-local filled_tokens = math.min(burst, last_tokens + (delta*rate))
-local allowed = filled_tokens >= requested
-local new_tokens = filled_tokens
-if allowed then
-    new_tokens = filled_tokens - requested
-end
-
-redis.call("setex", key, ttl, new_tokens)
-redis.call("setex", key .. ":last_refreshed", ttl, now)
-return allowed and 1 or 0
-`
-	keys := []string{rule.Key}
-	args := []interface{}{
-		rule.Rate,
-		rule.Burst,
-		time.Now().Unix(),
-		1, // Each request consumes 1 token
-	}
-
-	result, err := redis.Client.Eval(ctx, script, keys, args...).Int()
+// GetRuleFromRedis gets rate limiting rule from the store of the default Limiter
+func GetRuleFromRedis(ctx context.Context, key string) (Rule, error) {
+	l, err := getDefault()
 	if err != nil {
-		logger.Error("Rate limit check failed",
-			logger.String("key", rule.Key),
-			logger.ErrorField(err),
-		)
-		return false, fmt.Errorf("rate limit check failed: %w", err)
+		return Rule{}, err
 	}
+	return l.GetRule(ctx, key)
+}
 
-	allowed := result == 1
-	logger.Info("Rate limit check result",
-		logger.String("key", rule.Key),
-		logger.Bool("allowed", allowed),
-		logger.Int64("rate", rule.Rate),
-		logger.Int64("burst", rule.Burst),
-	)
+// SetRuleToRedis sets rate limiting rule in the store of the default Limiter
+func SetRuleToRedis(ctx context.Context, key string, rate, burst int64) error {
+	l, err := getDefault()
+	if err != nil {
+		return err
+	}
+	return l.SetRule(ctx, key, rate, burst)
+}
+
+// GetStats gets rate limiting statistics
+func GetStats(ctx context.Context, key string) (map[string]interface{}, error) {
+	l, err := getDefault()
+	if err != nil {
+		return nil, err
+	}
+	return l.GetStats(ctx, key)
+}
 
-	return allowed, nil
+// Allow determines if the current request is allowed
+func (l *Limiter) Allow(ctx context.Context, rule Rule) (bool, error) {
+	allowed, _, err := l.AllowNWithRemain(ctx, rule, 1)
+	return allowed, err
 }
 
 // AllowWithRemain determines if the current request is allowed and returns remaining tokens
+func (l *Limiter) AllowWithRemain(ctx context.Context, rule Rule) (bool, int64, error) {
+	return l.AllowNWithRemain(ctx, rule, 1)
+}
+
+// AllowNWithRemain determines if n tokens can be taken at once and returns remaining tokens
 // Uses the officially recommended token bucket Redis Lua script
-func AllowWithRemain(ctx context.Context, rule Rule) (bool, int64, error) {
+func (l *Limiter) AllowNWithRemain(ctx context.Context, rule Rule, n int64) (bool, int64, error) {
+	d, err := l.Check(ctx, rule, n)
+	return d.Allowed, d.Remain, err
+}
+
+// Check determines if n tokens can be taken at once from the bucket of rule and returns the decision.
+// When the Store fails, the failure policy of the key decides and the decision is Degraded
+func (l *Limiter) Check(ctx context.Context, rule Rule, n int64) (Decision, error) {
+	if err := l.validKey(rule.Key); err != nil {
+		return Decision{}, err
+	}
+
 	// Use default values if no rule is specified
 	if rule.Rate == 0 {
-		rule.Rate = config.GlobalConfig.Limiter.DefaultRate
-		logger.Debug("Using default rate", logger.Int64("rate", rule.Rate))
+		rule.Rate = l.defaults.DefaultRate
+		l.log.Debug("Using default rate", logger.Int64("rate", rule.Rate))
 	}
 	if rule.Burst == 0 {
-		rule.Burst = config.GlobalConfig.Limiter.DefaultBurst
-		logger.Debug("Using default burst", logger.Int64("burst", rule.Burst))
+		rule.Burst = l.defaults.DefaultBurst
+		l.log.Debug("Using default burst", logger.Int64("burst", rule.Burst))
+	}
+
+	return l.decide(ctx, rule.Key, n, func(ctx context.Context, now time.Time) (Rule, bool, int64, error) {
+		allowed, remain, err := l.store.TakeTokens(ctx, rule.Key, rule.Rate, rule.Burst, now.Unix(), n)
+		return rule, allowed, remain, err
+	})
+}
+
+// CheckKey determines if n tokens can be taken at once from the bucket of key under its rule, or the
+// defaults, and returns the decision. With a RuleTaker Store, such as redis.Store, the rule is read
+// and the tokens taken in one operation, so a rule change cannot land in between. Otherwise it is
+// GetRule followed by Check
+func (l *Limiter) CheckKey(ctx context.Context, key string, n int64) (Decision, error) {
+	if err := l.validKey(key); err != nil {
+		return Decision{}, err
+	}
+
+	rt, ok := l.store.(RuleTaker)
+	if !ok {
+		rule, err := l.GetRule(ctx, key)
+		if err != nil {
+			return Decision{}, err
+		}
+		return l.Check(ctx, rule, n)
 	}
 
-	logger.Debug("Checking rate limit",
-		logger.String("key", rule.Key),
-		logger.Int64("rate", rule.Rate),
-		logger.Int64("burst", rule.Burst),
+	return l.decide(ctx, key, n, func(ctx context.Context, now time.Time) (Rule, bool, int64, error) {
+		res, err := rt.TakeTokensWithRule(ctx, key, l.defaults.DefaultRate, l.defaults.DefaultBurst, now.Unix(), n)
+		if err != nil {
+			// Only the local policy takes tokens, with the rule cached in the process if any. The
+			// Store that just failed is not asked again
+			rule := Rule{Key: key, Rate: l.defaults.DefaultRate, Burst: l.defaults.DefaultBurst}
+			if cr, ok := l.store.(CachedRuler); ok && l.FailurePolicy(key) == FailLocal {
+				if rate, burst, ok := cr.CachedRule(key); ok {
+					rule.Rate, rule.Burst = rate, burst
+				}
+			}
+			return rule, false, 0, err
+		}
+		return Rule{Key: key, Rate: res.Rate, Burst: res.Burst}, res.Allowed, res.Remain, nil
+	})
+}
+
+// takeFunc takes the tokens of a check at now and returns the rule applied, whether they were taken
+// and the tokens left. On error the rule is the one the failure policy applies
+type takeFunc func(ctx context.Context, now time.Time) (Rule, bool, int64, error)
+
+// decide takes n tokens of the bucket of key with take, applying the failure policy when it fails,
+// and reports the decision to metrics, traces and observers
+func (l *Limiter) decide(ctx context.Context, key string, n int64, take takeFunc) (Decision, error) {
+	// Metrics, traces and observers see the key qualified with the namespace
+	qualifiedKey := NamespacePrefix(l.namespace) + key
+
+	ctx, span := tracer.Start(ctx, "limiter.AllowN", trace.WithAttributes(
+		attribute.String("ratelimit.namespace", l.namespace),
+		attribute.String("ratelimit.key_pattern", metrics.Pattern(qualifiedKey)),
+		attribute.Int64("ratelimit.requested", n),
+	))
+	defer span.End()
+
+	l.log.Debug("Checking rate limit",
+		logger.String("key", key),
+		logger.Int64("requested", n),
 	)
 
-	const script = `
-local key     = KEYS[1]
-local rate    = tonumber(ARGV[1])
-local burst   = tonumber(ARGV[2])
-local now     = tonumber(ARGV[3])
-local requested = tonumber(ARGV[4])
-
-local fill_time = burst/rate
-local ttl = math.floor(fill_time*2)
-
-local last_tokens = tonumber(redis.call("get", key) or burst)
-local last_refreshed = tonumber(redis.call("get", key .. ":last_refreshed") or now)
-
-local delta = math.max(0, now-last_refreshed)
-local filled_tokens = math.min(burst, last_tokens + (delta*rate))
-local allowed = filled_tokens >= requested
-local new_tokens = filled_tokens
-if allowed then
-    new_tokens = filled_tokens - requested
-end
-
-redis.call("setex", key, ttl, new_tokens)
-redis.call("setex", key .. ":last_refreshed", ttl, now)
-return {allowed and 1 or 0, new_tokens}
-`
-	keys := []string{rule.Key}
-	args := []interface{}{
-		rule.Rate,
-		rule.Burst,
-		time.Now().Unix(),
-		1, // Each request consumes 1 token
-	}
-
-	result, err := redis.Client.Eval(ctx, script, keys, args...).Result()
+	now := l.now()
+	rule, allowed, remain, err := take(ctx, now)
+	span.SetAttributes(
+		attribute.Int64("ratelimit.rate", rule.Rate),
+		attribute.Int64("ratelimit.burst", rule.Burst),
+	)
+	d := Decision{
+		Key:       qualifiedKey,
+		Namespace: l.namespace,
+		Rate:      rule.Rate,
+		Burst:     rule.Burst,
+		Requested: n,
+		Allowed:   allowed,
+		Remain:    remain,
+		Time:      now,
+	}
+	if err != nil {
+		span.RecordError(err)
+
+		// A canceled caller gets the error, no decision is needed
+		policy := l.FailurePolicy(key)
+		if policy != FailError && ctx.Err() == nil {
+			l.log.Warn("Rate limit store failed, applying failure policy",
+				logger.String("key", key),
+				logger.String("policy", string(policy)),
+				logger.ErrorField(err),
+			)
+			d.Allowed, d.Remain, err = l.degrade(ctx, policy, rule, now, n)
+			d.Degraded = policy
+		}
+	}
 	if err != nil {
-		logger.Error("Rate limit check failed",
-			logger.String("key", rule.Key),
+		span.SetStatus(codes.Error, "rate limit check failed")
+		metrics.ObserveCheck(qualifiedKey, metrics.ResultError)
+		l.log.Error("Rate limit check failed",
+			logger.String("key", key),
 			logger.ErrorField(err),
 		)
-		return false, 0, fmt.Errorf("rate limit check failed: %w", err)
+		return Decision{}, fmt.Errorf("rate limit check failed: %w", err)
 	}
 
-	// Parse result array
-	resultArray, ok := result.([]interface{})
-	if !ok || len(resultArray) != 2 {
-		logger.Error("Invalid result format from Lua script",
-			logger.String("key", rule.Key),
-			logger.Any("result", result),
-		)
-		return false, 0, fmt.Errorf("invalid result format from Lua script")
+	span.SetAttributes(
+		attribute.Bool("ratelimit.allowed", d.Allowed),
+		attribute.Int64("ratelimit.remain", d.Remain),
+	)
+	result := metrics.ResultDenied
+	if d.Allowed {
+		result = metrics.ResultAllowed
+	}
+	metrics.ObserveCheck(qualifiedKey, result)
+	if d.Degraded != "" {
+		span.SetAttributes(attribute.String("ratelimit.degraded", string(d.Degraded)))
+		metrics.ObserveDegraded(qualifiedKey, string(d.Degraded), result)
+	}
+	for _, o := range l.observers {
+		o(ctx, d)
 	}
 
-	allowed := resultArray[0].(int64) == 1
-	remain := resultArray[1].(int64)
-
-	logger.Info("Rate limit check result",
-		logger.String("key", rule.Key),
-		logger.Bool("allowed", allowed),
-		logger.Int64("remain", remain),
-		logger.Int64("rate", rule.Rate),
-		logger.Int64("burst", rule.Burst),
+	l.log.Info("Rate limit check result",
+		logger.String("key", qualifiedKey),
+		logger.Bool("allowed", d.Allowed),
+		logger.Int64("remain", d.Remain),
+		logger.Int64("requested", n),
+		logger.Int64("rate", d.Rate),
+		logger.Int64("burst", d.Burst),
+		logger.String("degraded", string(d.Degraded)),
 	)
 
-	return allowed, remain, nil
+	return d, nil
 }
 
-// GetRuleFromRedis gets rate limiting rule from Redis
-func GetRuleFromRedis(ctx context.Context, key string) (Rule, error) {
-	logger.Debug("Getting rule from Redis",
+// GetRule gets the rate limiting rule of key, or the default rule if none is set
+func (l *Limiter) GetRule(ctx context.Context, key string) (Rule, error) {
+	if err := l.validKey(key); err != nil {
+		return Rule{}, err
+	}
+
+	ctx, span := tracer.Start(ctx, "limiter.GetRule", trace.WithAttributes(
+		attribute.String("ratelimit.key_pattern", metrics.Pattern(key)),
+	))
+	defer span.End()
+
+	l.log.Debug("Getting rule from Redis",
 		logger.String("key", key),
 	)
 
-	rate, burst, err := redis.GetRule(ctx, key)
+	rate, burst, err := l.store.GetRule(ctx, key)
 	if err != nil {
 		// If rule doesn't exist, return default rule
-		logger.Info("Rule not found, using defaults",
+		l.log.Info("Rule not found, using defaults",
 			logger.String("key", key),
 		)
+		span.SetAttributes(attribute.Bool("ratelimit.default_rule", true))
 		return Rule{
 			Key:   key,
-			Rate:  config.GlobalConfig.Limiter.DefaultRate,
-			Burst: config.GlobalConfig.Limiter.DefaultBurst,
+			Rate:  l.defaults.DefaultRate,
+			Burst: l.defaults.DefaultBurst,
 		}, nil
 	}
 
-	logger.Debug("Retrieved rule from Redis",
+	l.log.Debug("Retrieved rule from Redis",
 		logger.String("key", key),
 		logger.Int64("rate", rate),
 		logger.Int64("burst", burst),
@@ -205,22 +420,81 @@ func GetRuleFromRedis(ctx context.Context, key string) (Rule, error) {
 	}, nil
 }
 
-// SetRuleToRedis sets rate limiting rule to Redis
-func SetRuleToRedis(ctx context.Context, key string, rate, burst int64) error {
-	logger.Info("Setting rule to Redis",
+// SetRule sets the rate limiting rule of key
+func (l *Limiter) SetRule(ctx context.Context, key string, rate, burst int64) error {
+	if err := l.validKey(key); err != nil {
+		return err
+	}
+
+	l.log.Info("Setting rule to Redis",
 		logger.String("key", key),
 		logger.Int64("rate", rate),
 		logger.Int64("burst", burst),
 	)
 
-	return redis.SetRule(ctx, key, rate, burst)
+	if err := l.store.SetRule(ctx, key, rate, burst); err != nil {
+		return err
+	}
+	l.notifyRuleChange(ctx, RuleChange{Key: key, Rate: rate, Burst: burst, Time: l.now()})
+	return nil
+}
+
+// DeleteRule deletes the rate limiting rule of key, which falls back to the defaults.
+// It reports whether a rule existed
+func (l *Limiter) DeleteRule(ctx context.Context, key string) (bool, error) {
+	if err := l.validKey(key); err != nil {
+		return false, err
+	}
+
+	l.log.Info("Deleting rule",
+		logger.String("key", key),
+	)
+
+	deleted, err := l.store.DeleteRule(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if deleted {
+		l.notifyRuleChange(ctx, RuleChange{Key: key, Deleted: true, Time: l.now()})
+	}
+	return deleted, nil
+}
+
+func (l *Limiter) notifyRuleChange(ctx context.Context, c RuleChange) {
+	c.Key = NamespacePrefix(l.namespace) + c.Key
+	c.Namespace = l.namespace
+	for _, o := range l.ruleObservers {
+		o(ctx, c)
+	}
+}
+
+// ResetBucket refills the token bucket of key
+func (l *Limiter) ResetBucket(ctx context.Context, key string) error {
+	if err := l.validKey(key); err != nil {
+		return err
+	}
+
+	l.log.Info("Resetting token bucket",
+		logger.String("key", key),
+	)
+
+	return l.store.ResetBucket(ctx, key)
+}
+
+// GetAllRules gets all rate limiting rules
+func (l *Limiter) GetAllRules(ctx context.Context) (map[string]map[string]interface{}, error) {
+	return l.store.GetAllRules(ctx)
 }
 
 // GetStats gets rate limiting statistics
-func GetStats(ctx context.Context, key string) (map[string]interface{}, error) {
-	logger.Debug("Getting stats",
+func (l *Limiter) GetStats(ctx context.Context, key string) (map[string]interface{}, error) {
+	if err := l.validKey(key); err != nil {
+		return nil, err
+	}
+
+	l.log.Debug("Getting stats",
 		logger.String("key", key),
 	)
 
-	return redis.GetStats(ctx, key)
+	return l.store.GetStats(ctx, key)
 }
@@ -0,0 +1,190 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/limiter/memory"
+	"github.com/your-org/rate-limiter/redis"
+)
+
+var defaults = config.LimiterConfig{DefaultRate: 1, DefaultBurst: 3}
+
+// fixedClock returns a stopped clock, advanced through the returned pointer
+func fixedClock() (func() time.Time, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	return func() time.Time { return now }, &now
+}
+
+func newLimiter(t *testing.T, opts ...limiter.Option) (*limiter.Limiter, *memory.Store) {
+	t.Helper()
+	store := memory.New(0)
+	t.Cleanup(func() { store.Close() })
+	return limiter.New(store, defaults, opts...), store
+}
+
+func TestCheckKey(t *testing.T) {
+	clock, _ := fixedClock()
+	l, _ := newLimiter(t, limiter.WithClock(clock))
+	ctx := context.Background()
+
+	if err := l.SetRule(ctx, "limited", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key     string
+		n       int64
+		allowed bool
+		remain  int64
+		rate    int64
+		burst   int64
+	}{
+		{key: "default", n: 1, allowed: true, remain: 2, rate: 1, burst: 3},
+		{key: "default", n: 2, allowed: true, remain: 0, rate: 1, burst: 3},
+		{key: "default", n: 1, allowed: false, remain: 0, rate: 1, burst: 3},
+		{key: "limited", n: 2, allowed: true, remain: 0, rate: 1, burst: 2},
+		{key: "limited", n: 1, allowed: false, remain: 0, rate: 1, burst: 2},
+	}
+	for i, tt := range tests {
+		d, err := l.CheckKey(ctx, tt.key, tt.n)
+		if err != nil {
+			t.Fatalf("check %d: %v", i, err)
+		}
+		if d.Allowed != tt.allowed || d.Remain != tt.remain || d.Rate != tt.rate || d.Burst != tt.burst {
+			t.Errorf("check %d of %s: got allowed=%v remain=%d rate=%d burst=%d, want %v %d %d %d",
+				i, tt.key, d.Allowed, d.Remain, d.Rate, d.Burst, tt.allowed, tt.remain, tt.rate, tt.burst)
+		}
+		if d.Degraded != "" {
+			t.Errorf("check %d: unexpected degraded decision %q", i, d.Degraded)
+		}
+	}
+}
+
+func TestCheckRefillsWithClock(t *testing.T) {
+	clock, now := fixedClock()
+	l, _ := newLimiter(t, limiter.WithClock(clock))
+	ctx := context.Background()
+	rule := limiter.Rule{Key: "key", Rate: 2, Burst: 4}
+
+	if d, _ := l.Check(ctx, rule, 4); !d.Allowed || d.Remain != 0 {
+		t.Fatalf("draining check: got allowed=%v remain=%d", d.Allowed, d.Remain)
+	}
+	if d, _ := l.Check(ctx, rule, 1); d.Allowed {
+		t.Fatal("check of an empty bucket allowed")
+	}
+
+	*now = now.Add(time.Second)
+	if d, _ := l.Check(ctx, rule, 1); !d.Allowed || d.Remain != 1 {
+		t.Fatalf("check after 1s: got allowed=%v remain=%d, want true 1", d.Allowed, d.Remain)
+	}
+}
+
+func TestObserversSeeQualifiedKey(t *testing.T) {
+	var decisions []limiter.Decision
+	var changes []limiter.RuleChange
+	l, _ := newLimiter(t,
+		limiter.WithNamespace("team-a"),
+		limiter.WithObserver(func(_ context.Context, d limiter.Decision) { decisions = append(decisions, d) }),
+		limiter.WithRuleObserver(func(_ context.Context, c limiter.RuleChange) { changes = append(changes, c) }),
+	)
+	ctx := context.Background()
+
+	if err := l.SetRule(ctx, "key", 5, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.CheckKey(ctx, "key", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.DeleteRule(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decisions) != 1 || decisions[0].Key != "ns:team-a:key" || decisions[0].Namespace != "team-a" {
+		t.Errorf("decisions: got %+v", decisions)
+	}
+	if len(changes) != 2 || changes[0].Key != "ns:team-a:key" || changes[0].Deleted || !changes[1].Deleted {
+		t.Errorf("rule changes: got %+v", changes)
+	}
+}
+
+func TestPackageFunctionsUseDefault(t *testing.T) {
+	limiter.SetDefault(nil)
+	if _, err := limiter.Allow(context.Background(), limiter.Rule{Key: "key"}); err != limiter.ErrNoDefault {
+		t.Fatalf("Allow without default: got %v, want ErrNoDefault", err)
+	}
+
+	l, _ := newLimiter(t)
+	limiter.SetDefault(l)
+	t.Cleanup(func() { limiter.SetDefault(nil) })
+	if allowed, err := limiter.Allow(context.Background(), limiter.Rule{Key: "key"}); err != nil || !allowed {
+		t.Fatalf("Allow: got %v, %v, want true, nil", allowed, err)
+	}
+}
+
+func TestDefaultNamespaceCannotReachNamedNamespace(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	def := limiter.New(redis.NewStore(client), defaults)
+	team := limiter.New(redis.NewPrefixedStore(client, limiter.NamespacePrefix("team")), defaults,
+		limiter.WithNamespace("team"))
+	ctx := context.Background()
+
+	if err := team.SetRule(ctx, "foo", 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := team.CheckKey(ctx, "foo", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// ns:team:foo of the default namespace is the Redis key of the bucket of foo in team
+	key := "ns:team:foo"
+	if _, err := def.CheckKey(ctx, key, 1); !errors.Is(err, limiter.ErrReservedKey) {
+		t.Errorf("CheckKey: got %v, want ErrReservedKey", err)
+	}
+	if _, err := def.Check(ctx, limiter.Rule{Key: key}, 1); !errors.Is(err, limiter.ErrReservedKey) {
+		t.Errorf("Check: got %v, want ErrReservedKey", err)
+	}
+	if err := def.ResetBucket(ctx, key); !errors.Is(err, limiter.ErrReservedKey) {
+		t.Errorf("ResetBucket: got %v, want ErrReservedKey", err)
+	}
+	if _, err := def.GetStats(ctx, key); !errors.Is(err, limiter.ErrReservedKey) {
+		t.Errorf("GetStats: got %v, want ErrReservedKey", err)
+	}
+
+	// ns:team:rule:foo of the default namespace has the bucket key of the rule of foo in team
+	ruleKey := "ns:team:rule:foo"
+	if _, err := def.CheckKey(ctx, ruleKey, 1); !errors.Is(err, limiter.ErrReservedKey) {
+		t.Errorf("CheckKey of the rule key: got %v, want ErrReservedKey", err)
+	}
+	if err := def.SetRule(ctx, key, 100, 100); !errors.Is(err, limiter.ErrReservedKey) {
+		t.Errorf("SetRule: got %v, want ErrReservedKey", err)
+	}
+	if _, err := def.GetRule(ctx, key); !errors.Is(err, limiter.ErrReservedKey) {
+		t.Errorf("GetRule: got %v, want ErrReservedKey", err)
+	}
+	if _, err := def.DeleteRule(ctx, key); !errors.Is(err, limiter.ErrReservedKey) {
+		t.Errorf("DeleteRule: got %v, want ErrReservedKey", err)
+	}
+
+	// The rule and the bucket of team are untouched
+	d, err := team.CheckKey(ctx, "foo", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Burst != 5 || d.Remain != 3 {
+		t.Errorf("team: got burst %d and %d left, want 5 and 3", d.Burst, d.Remain)
+	}
+
+	// Named namespaces may use any key, they are under their own prefix
+	if _, err := team.CheckKey(ctx, "ns:other", 1); err != nil {
+		t.Errorf("named namespace: %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+// Package memory implements limiter.Store in the current process, for single node
+// deployments and tests that run without Redis
+package memory
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+const shardCount = 32
+
+// Store keeps rules and token buckets in sharded maps. Buckets expire like their
+// Redis counterparts (twice the time to fill up) and are evicted by a background janitor
+type Store struct {
+	shards [shardCount]*shard
+	stop   chan struct{}
+	once   sync.Once
+}
+
+type shard struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	rules   map[string]rule
+}
+
+type bucket struct {
+	tokens        int64
+	lastRefreshed int64 // unix seconds
+	expiresAt     int64 // unix seconds
+}
+
+type rule struct {
+	rate      int64
+	burst     int64
+	updatedAt int64
+}
+
+var _ limiter.Store = (*Store)(nil)
+
+// New creates a memory store evicting expired buckets every cleanupInterval (1 minute if not positive).
+// Call Close to stop the janitor
+func New(cleanupInterval time.Duration) *Store {
+	if cleanupInterval <= 0 {
+		cleanupInterval = time.Minute
+	}
+
+	s := &Store{stop: make(chan struct{})}
+	for i := range s.shards {
+		s.shards[i] = &shard{
+			buckets: make(map[string]*bucket),
+			rules:   make(map[string]rule),
+		}
+	}
+
+	go s.janitor(cleanupInterval)
+	return s
+}
+
+// Close stops the janitor
+func (s *Store) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *Store) shard(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return s.shards[h.Sum32()%shardCount]
+}
+
+// TakeTokens takes requested tokens from the bucket of key if enough are available at now (unix seconds)
+// and returns whether they were taken and the tokens left in the bucket
+func (s *Store) TakeTokens(_ context.Context, key string, rate, burst, now, requested int64) (bool, int64, error) {
+	if rate <= 0 {
+		return false, 0, fmt.Errorf("invalid rate %d", rate)
+	}
+
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	lastTokens, lastRefreshed := burst, now
+	if b, ok := sh.buckets[key]; ok && b.expiresAt > now {
+		lastTokens, lastRefreshed = b.tokens, b.lastRefreshed
+	}
+
+	delta := max(0, now-lastRefreshed)
+	filled := min(burst, lastTokens+delta*rate)
+	allowed := filled >= requested
+	tokens := filled
+	if allowed {
+		tokens = filled - requested
+	}
+
+	ttl := max(1, 2*burst/rate)
+	sh.buckets[key] = &bucket{
+		tokens:        tokens,
+		lastRefreshed: now,
+		expiresAt:     now + ttl,
+	}
+
+	return allowed, tokens, nil
+}
+
+// GetRule gets rate limiting rule
+func (s *Store) GetRule(_ context.Context, key string) (rate, burst int64, err error) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	r, ok := sh.rules[key]
+	if !ok {
+		return 0, 0, limiter.ErrRuleNotFound
+	}
+	return r.rate, r.burst, nil
+}
+
+// SetRule sets rate limiting rule
+func (s *Store) SetRule(_ context.Context, key string, rate, burst int64) error {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.rules[key] = rule{
+		rate:      rate,
+		burst:     burst,
+		updatedAt: time.Now().Unix(),
+	}
+	logger.Info("Rate limit rule set successfully", logger.String("key", key))
+	return nil
+}
+
+// DeleteRule deletes the rate limiting rule of key and reports whether it existed
+func (s *Store) DeleteRule(_ context.Context, key string) (bool, error) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	_, ok := sh.rules[key]
+	delete(sh.rules, key)
+	return ok, nil
+}
+
+// ResetBucket deletes the token bucket of key, so the next check starts with a full bucket
+func (s *Store) ResetBucket(_ context.Context, key string) error {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	delete(sh.buckets, key)
+	return nil
+}
+
+// GetAllRules gets all rate limiting rules. Values are strings, as read from Redis
+func (s *Store) GetAllRules(_ context.Context) (map[string]map[string]interface{}, error) {
+	rules := make(map[string]map[string]interface{})
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		for key, r := range sh.rules {
+			rules[key] = map[string]interface{}{
+				"rate":       strconv.FormatInt(r.rate, 10),
+				"burst":      strconv.FormatInt(r.burst, 10),
+				"updated_at": strconv.FormatInt(r.updatedAt, 10),
+			}
+		}
+		sh.mu.Unlock()
+	}
+	return rules, nil
+}
+
+// GetStats gets rate limiting statistics, in the same format as redis.Store
+func (s *Store) GetStats(_ context.Context, key string) (map[string]interface{}, error) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	stats := make(map[string]interface{})
+	r, ok := sh.rules[key]
+	if !ok {
+		stats["rate"] = "unknown"
+		stats["burst"] = "unknown"
+		stats["current_tokens"] = "unknown"
+		return stats, nil
+	}
+
+	if b, ok := sh.buckets[key]; ok && b.expiresAt > time.Now().Unix() {
+		stats["current_tokens"] = strconv.FormatInt(b.tokens, 10)
+	} else {
+		// No check has been performed yet, the bucket is full
+		stats["current_tokens"] = r.burst
+	}
+	stats["rate"] = r.rate
+	stats["burst"] = r.burst
+	return stats, nil
+}
+
+// janitor evicts expired buckets until the store is closed
+func (s *Store) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			now := time.Now().Unix()
+			evicted := 0
+			for _, sh := range s.shards {
+				sh.mu.Lock()
+				for key, b := range sh.buckets {
+					if b.expiresAt <= now {
+						delete(sh.buckets, key)
+						evicted++
+					}
+				}
+				sh.mu.Unlock()
+			}
+			if evicted > 0 {
+				logger.Debug("Evicted expired buckets", logger.Int("count", evicted))
+			}
+		}
+	}
+}
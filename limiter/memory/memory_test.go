@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/your-org/rate-limiter/limiter"
+)
+
+func TestTakeTokens(t *testing.T) {
+	type take struct {
+		now       int64
+		requested int64
+		allowed   bool
+		remain    int64
+	}
+	tests := []struct {
+		name  string
+		rate  int64
+		burst int64
+		takes []take
+	}{
+		{
+			name: "new bucket starts full", rate: 1, burst: 5,
+			takes: []take{{now: 100, requested: 1, allowed: true, remain: 4}},
+		},
+		{
+			name: "drains then denies", rate: 1, burst: 3,
+			takes: []take{
+				{now: 100, requested: 1, allowed: true, remain: 2},
+				{now: 100, requested: 1, allowed: true, remain: 1},
+				{now: 100, requested: 1, allowed: true, remain: 0},
+				{now: 100, requested: 1, allowed: false, remain: 0},
+			},
+		},
+		{
+			name: "denied take keeps tokens", rate: 1, burst: 5,
+			takes: []take{
+				{now: 100, requested: 4, allowed: true, remain: 1},
+				{now: 100, requested: 3, allowed: false, remain: 1},
+				{now: 100, requested: 1, allowed: true, remain: 0},
+			},
+		},
+		{
+			name: "refills rate tokens per second", rate: 2, burst: 10,
+			takes: []take{
+				{now: 100, requested: 10, allowed: true, remain: 0},
+				{now: 101, requested: 1, allowed: true, remain: 1},
+				{now: 103, requested: 0, allowed: true, remain: 5},
+			},
+		},
+		{
+			name: "refill is capped at burst", rate: 5, burst: 10,
+			takes: []take{
+				{now: 100, requested: 8, allowed: true, remain: 2},
+				{now: 103, requested: 1, allowed: true, remain: 9},
+			},
+		},
+		{
+			name: "clock going back does not refill", rate: 1, burst: 5,
+			takes: []take{
+				{now: 100, requested: 5, allowed: true, remain: 0},
+				{now: 90, requested: 1, allowed: false, remain: 0},
+			},
+		},
+		{
+			name: "expired bucket is full again", rate: 1, burst: 4,
+			takes: []take{
+				{now: 100, requested: 4, allowed: true, remain: 0},
+				{now: 108, requested: 1, allowed: true, remain: 3},
+			},
+		},
+		{
+			name: "request larger than burst is never allowed", rate: 1, burst: 3,
+			takes: []take{{now: 100, requested: 4, allowed: false, remain: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(0)
+			defer s.Close()
+
+			for i, tk := range tt.takes {
+				allowed, remain, err := s.TakeTokens(context.Background(), "key", tt.rate, tt.burst, tk.now, tk.requested)
+				if err != nil {
+					t.Fatalf("take %d: %v", i, err)
+				}
+				if allowed != tk.allowed || remain != tk.remain {
+					t.Errorf("take %d: got allowed=%v remain=%d, want allowed=%v remain=%d",
+						i, allowed, remain, tk.allowed, tk.remain)
+				}
+			}
+		})
+	}
+}
+
+func TestTakeTokensInvalidRate(t *testing.T) {
+	s := New(0)
+	defer s.Close()
+
+	if _, _, err := s.TakeTokens(context.Background(), "key", 0, 5, 100, 1); err == nil {
+		t.Fatal("expected an error for rate 0")
+	}
+}
+
+func TestTakeTokensKeysAreIsolated(t *testing.T) {
+	s := New(0)
+	defer s.Close()
+	ctx := context.Background()
+
+	if allowed, _, _ := s.TakeTokens(ctx, "a", 1, 1, 100, 1); !allowed {
+		t.Fatal("first take of a denied")
+	}
+	if allowed, _, _ := s.TakeTokens(ctx, "b", 1, 1, 100, 1); !allowed {
+		t.Fatal("take of b denied after a drained its bucket")
+	}
+}
+
+func TestRules(t *testing.T) {
+	s := New(0)
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, _, err := s.GetRule(ctx, "key"); !errors.Is(err, limiter.ErrRuleNotFound) {
+		t.Fatalf("GetRule of missing rule: got %v, want ErrRuleNotFound", err)
+	}
+
+	if err := s.SetRule(ctx, "key", 3, 7); err != nil {
+		t.Fatal(err)
+	}
+	rate, burst, err := s.GetRule(ctx, "key")
+	if err != nil || rate != 3 || burst != 7 {
+		t.Fatalf("GetRule: got %d, %d, %v, want 3, 7, nil", rate, burst, err)
+	}
+
+	rules, err := s.GetAllRules(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rules["key"]["rate"]; got != "3" {
+		t.Errorf("GetAllRules rate: got %v, want \"3\"", got)
+	}
+
+	if deleted, err := s.DeleteRule(ctx, "key"); err != nil || !deleted {
+		t.Fatalf("DeleteRule: got %v, %v, want true, nil", deleted, err)
+	}
+	if deleted, err := s.DeleteRule(ctx, "key"); err != nil || deleted {
+		t.Fatalf("second DeleteRule: got %v, %v, want false, nil", deleted, err)
+	}
+	if _, _, err := s.GetRule(ctx, "key"); !errors.Is(err, limiter.ErrRuleNotFound) {
+		t.Fatalf("GetRule after delete: got %v, want ErrRuleNotFound", err)
+	}
+}
+
+func TestResetBucket(t *testing.T) {
+	s := New(0)
+	defer s.Close()
+	ctx := context.Background()
+
+	s.TakeTokens(ctx, "key", 1, 2, 100, 2)
+	if err := s.ResetBucket(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if allowed, remain, _ := s.TakeTokens(ctx, "key", 1, 2, 100, 1); !allowed || remain != 1 {
+		t.Fatalf("take after reset: got allowed=%v remain=%d, want true 1", allowed, remain)
+	}
+}
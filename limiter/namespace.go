@@ -0,0 +1,96 @@
+package limiter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// namespaceKeyPrefix starts the keys of the named namespaces, see NamespacePrefix
+const namespaceKeyPrefix = "ns:"
+
+// ErrReservedKey is returned for the keys of the default namespace starting with ns:, which would
+// reach the rules and buckets of a named namespace
+var ErrReservedKey = errors.New("keys starting with ns: are reserved for namespaces")
+
+// namespaceName is the syntax of namespace names, safe in Redis keys and patterns
+var namespaceName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidNamespace returns an error if name is not a valid namespace name
+func ValidNamespace(name string) error {
+	if !namespaceName.MatchString(name) {
+		return fmt.Errorf("invalid namespace %q, must be lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// NamespacePrefix returns the prefix of the Redis keys of namespace: ns:<name>: for a named
+// namespace, nothing for the default namespace "", which keeps the keys of single tenant deployments
+func NamespacePrefix(namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+	return namespaceKeyPrefix + namespace + ":"
+}
+
+// validKey returns ErrReservedKey for the keys of the default namespace starting with ns:. The
+// default namespace keeps unprefixed keys, so they are the keys of a named namespace
+func (l *Limiter) validKey(key string) error {
+	if l.namespace == "" && strings.HasPrefix(key, namespaceKeyPrefix) {
+		return ErrReservedKey
+	}
+	return nil
+}
+
+// WithNamespace sets the namespace of the Limiter. It qualifies the keys seen by metrics, traces
+// and observers; the Store must isolate the namespace itself, e.g. redis.NewPrefixedStore
+func WithNamespace(namespace string) Option {
+	return func(l *Limiter) { l.namespace = namespace }
+}
+
+// Namespace returns the namespace of l, "" for the default namespace
+func (l *Limiter) Namespace() string {
+	return l.namespace
+}
+
+// Namespaces holds the Limiter of each namespace
+type Namespaces struct {
+	limiters map[string]*Limiter
+}
+
+// NewNamespaces creates Namespaces holding the default namespace only, served by l
+func NewNamespaces(l *Limiter) *Namespaces {
+	return &Namespaces{limiters: map[string]*Limiter{"": l}}
+}
+
+// Add adds the namespace of l
+func (n *Namespaces) Add(l *Limiter) error {
+	if err := ValidNamespace(l.namespace); err != nil {
+		return err
+	}
+	if _, ok := n.limiters[l.namespace]; ok {
+		return fmt.Errorf("duplicate namespace %q", l.namespace)
+	}
+	n.limiters[l.namespace] = l
+	return nil
+}
+
+// Get returns the Limiter of namespace, "" being the default namespace
+func (n *Namespaces) Get(namespace string) (*Limiter, bool) {
+	l, ok := n.limiters[namespace]
+	return l, ok
+}
+
+// Names returns the named namespaces, sorted
+func (n *Namespaces) Names() []string {
+	names := make([]string, 0, len(n.limiters)-1)
+	for name := range n.limiters {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
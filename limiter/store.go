@@ -0,0 +1,59 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrRuleNotFound is returned by Store.GetRule when key has no rule
+var ErrRuleNotFound = errors.New("rule not found")
+
+// Store holds rate limiting rules and token bucket state.
+// redis.Store keeps them in Redis, memory.Store in the current process
+type Store interface {
+	// TakeTokens takes requested tokens from the bucket of key if enough are available at now
+	// (unix seconds) and returns whether they were taken and the tokens left in the bucket
+	TakeTokens(ctx context.Context, key string, rate, burst, now, requested int64) (bool, int64, error)
+
+	// GetRule gets the rule of key, returning ErrRuleNotFound if none is set
+	GetRule(ctx context.Context, key string) (rate, burst int64, err error)
+
+	// SetRule sets the rule of key
+	SetRule(ctx context.Context, key string, rate, burst int64) error
+
+	// DeleteRule deletes the rule of key and reports whether it existed
+	DeleteRule(ctx context.Context, key string) (bool, error)
+
+	// ResetBucket deletes the token bucket of key, so it is full again
+	ResetBucket(ctx context.Context, key string) error
+
+	// GetAllRules gets all rules with their fields (rate, burst, updated_at) by key
+	GetAllRules(ctx context.Context) (map[string]map[string]interface{}, error)
+
+	// GetStats gets the rule and current tokens of key
+	GetStats(ctx context.Context, key string) (map[string]interface{}, error)
+}
+
+// TakeResult is the outcome of RuleTaker.TakeTokensWithRule
+type TakeResult struct {
+	Allowed   bool
+	Remain    int64
+	Rate      int64 // of the rule of the key, or the default rate
+	Burst     int64 // of the rule of the key, or the default burst
+	RuleFound bool
+}
+
+// RuleTaker is implemented by Stores able to read the rule of a key and take tokens from its bucket
+// in one operation, see Limiter.CheckKey
+type RuleTaker interface {
+	// TakeTokensWithRule reads the rule of key, defaultRate and defaultBurst applying when it has none,
+	// and takes requested tokens from the bucket of key at now (unix seconds) with it
+	TakeTokensWithRule(ctx context.Context, key string, defaultRate, defaultBurst, now, requested int64) (TakeResult, error)
+}
+
+// CachedRuler is implemented by Stores caching rules in the process, such as rulecache.Store
+type CachedRuler interface {
+	// CachedRule gets the rule of key from the cache only, expired or not, and reports whether one
+	// was cached. It does not reach the Store, so it serves while the Store is unavailable
+	CachedRule(key string) (rate, burst int64, ok bool)
+}
@@ -1,20 +1,44 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/your-org/rate-limiter/analytics"
+	"github.com/your-org/rate-limiter/auth"
 	"github.com/your-org/rate-limiter/config"
 	_ "github.com/your-org/rate-limiter/docs" // This is generated by swag
+	"github.com/your-org/rate-limiter/events"
+	"github.com/your-org/rate-limiter/grpcserver"
 	"github.com/your-org/rate-limiter/handler"
+	"github.com/your-org/rate-limiter/health"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/limiter/memory"
 	"github.com/your-org/rate-limiter/logger"
+	"github.com/your-org/rate-limiter/metrics"
+	"github.com/your-org/rate-limiter/proxy"
 	"github.com/your-org/rate-limiter/redis"
+	"github.com/your-org/rate-limiter/rulecache"
+	"github.com/your-org/rate-limiter/tlsconfig"
+	"github.com/your-org/rate-limiter/tracing"
+	"github.com/your-org/rate-limiter/usage"
+	"github.com/your-org/rate-limiter/webhook"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // @title           Rate Limiter Service API
@@ -35,7 +59,7 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
-// @description API key for authentication
+// @description API key or JWT, sent as "Bearer <token>" (see auth in config.yaml)
 
 func main() {
 	// Parse command line arguments
@@ -60,7 +84,9 @@ func main() {
 
 	// Log configuration information
 	logger.Info("Configuration loaded",
+		logger.String("backend", config.GlobalConfig.Backend),
 		logger.String("server_port", config.GlobalConfig.Server.Port),
+		logger.String("grpc_port", config.GlobalConfig.Server.GRPCPort),
 		logger.String("redis_addr", config.GlobalConfig.Redis.Addr),
 		logger.Int64("default_rate", config.GlobalConfig.Limiter.DefaultRate),
 		logger.Int64("default_burst", config.GlobalConfig.Limiter.DefaultBurst),
@@ -68,18 +94,229 @@ func main() {
 		logger.String("log_output", config.GlobalConfig.Log.Output),
 	)
 
-	// Initialize Redis connection
-	logger.Info("Initializing Redis connection")
-	if err := redis.Init(&config.GlobalConfig.Redis); err != nil {
-		logger.Fatal("Failed to initialize Redis", logger.ErrorField(err))
+	if err := config.Validate(config.GlobalConfig); err != nil {
+		logger.Fatal("Invalid configuration", logger.ErrorField(err))
+	}
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(&config.GlobalConfig.Tracing)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", logger.ErrorField(err))
 	}
 	defer func() {
-		logger.Info("Closing Redis connection")
-		if err := redis.Close(); err != nil {
-			logger.Error("Failed to close Redis connection", logger.ErrorField(err))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", logger.ErrorField(err))
 		}
 	}()
 
+	// Initialize storage backend
+	var store limiter.Store
+	switch config.GlobalConfig.Backend {
+	case "redis":
+		logger.Info("Initializing Redis connection")
+		if err := redis.Init(&config.GlobalConfig.Redis); err != nil {
+			logger.Fatal("Failed to initialize Redis", logger.ErrorField(err))
+		}
+		defer func() {
+			logger.Info("Closing Redis connection")
+			if err := redis.Close(); err != nil {
+				logger.Error("Failed to close Redis connection", logger.ErrorField(err))
+			}
+		}()
+		store = redis.NewStore(redis.Client)
+	case "memory":
+		logger.Warn("Using in-memory backend, rules and buckets are not shared between instances nor persisted")
+		memStore := memory.New(config.GlobalConfig.Memory.CleanupInterval)
+		defer memStore.Close()
+		store = memStore
+	default:
+		logger.Fatal("Unknown storage backend", logger.String("backend", config.GlobalConfig.Backend))
+	}
+
+	// Cache rules in process, changes are broadcast to all instances over Redis Pub/Sub
+	var ruleCache *rulecache.Cache
+	if cacheCfg := &config.GlobalConfig.RuleCache; cacheCfg.Enabled && config.GlobalConfig.Backend == "redis" {
+		invalidator, err := rulecache.NewRedisInvalidator(redis.Client, cacheCfg.Channel)
+		if err != nil {
+			logger.Fatal("Failed to initialize rule cache", logger.ErrorField(err))
+		}
+		ruleCache = rulecache.New(invalidator, cacheCfg)
+		defer ruleCache.Close()
+		store = ruleCache.Wrap(store, "")
+		logger.Info("Rule cache enabled",
+			logger.Duration("ttl", cacheCfg.TTL),
+			logger.Int("max_entries", cacheCfg.MaxEntries),
+		)
+	}
+
+	// Readiness checks of the storage backend. When the failure policies decide the checks Redis
+	// fails, an outage degrades the service rather than taking it out of rotation
+	checker := health.New(config.GlobalConfig.Health.CacheTTL, config.GlobalConfig.Health.Timeout)
+	if config.GlobalConfig.Backend == "redis" {
+		addRedisCheck := checker.Add
+		if limiter.Degrades(&config.GlobalConfig.Limiter) {
+			addRedisCheck = checker.AddOptional
+		}
+		addRedisCheck("redis", func(ctx context.Context) (interface{}, error) {
+			return redis.CheckNodes(ctx, redis.Client)
+		})
+		if breaker := redis.CircuitBreaker; breaker != nil {
+			addRedisCheck("redis_circuit", func(context.Context) (interface{}, error) {
+				if breaker.State() == redis.StateOpen {
+					return breaker.Status(), redis.ErrCircuitOpen
+				}
+				return breaker.Status(), nil
+			})
+		}
+	}
+
+	// Initialize metrics before the first check is counted
+	metrics.Init(&config.GlobalConfig.Metrics)
+
+	// Collect hot key and top denied key analytics
+	var limiterOpts []limiter.Option
+	var handlerOpts []handler.Option
+	if analyticsCfg := &config.GlobalConfig.Analytics; analyticsCfg.Enabled {
+		var analyticsStore analytics.Store
+		if config.GlobalConfig.Backend == "redis" {
+			analyticsStore = analytics.NewRedisStore(redis.Client, analyticsCfg.MaxKeysPerBucket)
+		} else {
+			analyticsStore = analytics.NewMemoryStore(analyticsCfg.MaxKeysPerBucket)
+		}
+		recorder := analytics.NewRecorder(analyticsStore, analyticsCfg)
+		defer recorder.Close()
+		limiterOpts = append(limiterOpts, limiter.WithObserver(recorder.Observe))
+		handlerOpts = append(handlerOpts, handler.WithAnalytics(recorder))
+	}
+
+	// Record per-key usage history
+	if usageCfg := &config.GlobalConfig.Usage; usageCfg.Enabled {
+		var usageStore usage.Store
+		if config.GlobalConfig.Backend == "redis" {
+			usageStore = usage.NewRedisStore(redis.Client, usageCfg.Retention)
+		} else {
+			usageStore = usage.NewMemoryStore(usageCfg.Retention)
+		}
+		recorder := usage.NewRecorder(usageStore, usageCfg)
+		defer recorder.Close()
+		limiterOpts = append(limiterOpts, limiter.WithObserver(recorder.Observe))
+		handlerOpts = append(handlerOpts, handler.WithUsage(recorder))
+	}
+
+	// Notify webhooks of limit events
+	if webhooksCfg := &config.GlobalConfig.Webhooks; webhooksCfg.Enabled {
+		var queue webhook.Queue
+		var dedup webhook.Dedup
+		if config.GlobalConfig.Backend == "redis" {
+			queue = webhook.NewRedisQueue(redis.Client)
+			dedup = webhook.NewRedisDedup(redis.Client)
+		} else {
+			queue = webhook.NewMemoryQueue()
+			dedup = webhook.NewMemoryDedup()
+		}
+		notifier, err := webhook.NewNotifier(queue, dedup, webhooksCfg)
+		if err != nil {
+			logger.Fatal("Failed to initialize webhooks", logger.ErrorField(err))
+		}
+		defer notifier.Close()
+		limiterOpts = append(limiterOpts,
+			limiter.WithObserver(notifier.Observe),
+			limiter.WithRuleObserver(notifier.ObserveRule),
+		)
+		logger.Info("Webhooks enabled", logger.Int("endpoints", len(webhooksCfg.Endpoints)))
+	}
+
+	// Stream decision events to subscribers of all instances
+	if eventsCfg := &config.GlobalConfig.Events; eventsCfg.Enabled {
+		var bus events.Bus
+		if config.GlobalConfig.Backend == "redis" {
+			redisBus, err := events.NewRedisBus(redis.Client, eventsCfg.Channel)
+			if err != nil {
+				logger.Fatal("Failed to initialize event stream", logger.ErrorField(err))
+			}
+			bus = redisBus
+		} else {
+			bus = events.NewMemoryBus()
+		}
+		stream := events.NewStream(bus, eventsCfg)
+		defer stream.Close()
+		limiterOpts = append(limiterOpts, limiter.WithObserver(stream.Observe))
+		handlerOpts = append(handlerOpts, handler.WithEvents(stream))
+	}
+
+	// Authenticate the HTTP and gRPC APIs
+	var authOpts []auth.Option
+	if len(config.GlobalConfig.Namespaces) > 0 {
+		authOpts = append(authOpts, auth.WithNamespaces())
+	}
+	authn, err := auth.New(&config.GlobalConfig.Auth, authOpts...)
+	if err != nil {
+		logger.Fatal("Failed to initialize authentication", logger.ErrorField(err))
+	}
+	if authn == nil {
+		logger.Warn("Authentication disabled, anyone reaching the API can change rules")
+	}
+
+	// Create the limiter shared by all APIs. Under the local failure policy, the checks Redis
+	// fails use in-memory buckets
+	defaultOpts := limiterOpts
+	if config.GlobalConfig.Backend == "redis" {
+		fallback := memory.New(config.GlobalConfig.Memory.CleanupInterval)
+		defer fallback.Close()
+		defaultOpts = append(append([]limiter.Option{}, limiterOpts...), limiter.WithFallback(fallback))
+	}
+	l := limiter.New(store, config.GlobalConfig.Limiter, defaultOpts...)
+	limiter.SetDefault(l)
+
+	// Create the limiter of each tenant namespace, on its own key prefix and defaults
+	namespaces := limiter.NewNamespaces(l)
+	for _, nsCfg := range config.GlobalConfig.Namespaces {
+		if err := limiter.ValidNamespace(nsCfg.Name); err != nil {
+			logger.Fatal("Invalid namespace", logger.ErrorField(err))
+		}
+		nsOpts := append(append([]limiter.Option{}, limiterOpts...), limiter.WithNamespace(nsCfg.Name))
+		var nsStore limiter.Store
+		if config.GlobalConfig.Backend == "redis" {
+			nsStore = redis.NewPrefixedStore(redis.Client, limiter.NamespacePrefix(nsCfg.Name))
+			if ruleCache != nil {
+				nsStore = ruleCache.Wrap(nsStore, limiter.NamespacePrefix(nsCfg.Name))
+			}
+			fallback := memory.New(config.GlobalConfig.Memory.CleanupInterval)
+			defer fallback.Close()
+			nsOpts = append(nsOpts, limiter.WithFallback(fallback))
+		} else {
+			memStore := memory.New(config.GlobalConfig.Memory.CleanupInterval)
+			defer memStore.Close()
+			nsStore = memStore
+		}
+		defaults := config.GlobalConfig.Limiter
+		if nsCfg.DefaultRate > 0 {
+			defaults.DefaultRate = nsCfg.DefaultRate
+		}
+		if nsCfg.DefaultBurst > 0 {
+			defaults.DefaultBurst = nsCfg.DefaultBurst
+		}
+		if err := namespaces.Add(limiter.New(nsStore, defaults, nsOpts...)); err != nil {
+			logger.Fatal("Failed to add namespace", logger.ErrorField(err))
+		}
+		logger.Info("Namespace enabled",
+			logger.String("namespace", nsCfg.Name),
+			logger.Int64("default_rate", defaults.DefaultRate),
+			logger.Int64("default_burst", defaults.DefaultBurst),
+		)
+	}
+	for _, k := range config.GlobalConfig.Auth.APIKeys {
+		if _, ok := namespaces.Get(k.Namespace); !ok && k.Namespace != auth.AnyNamespace {
+			logger.Fatal("API key scoped to an unknown namespace",
+				logger.String("name", k.Name),
+				logger.String("namespace", k.Namespace),
+			)
+		}
+	}
+	handlerOpts = append(handlerOpts, handler.WithNamespaces(namespaces))
+
 	// Set Gin mode
 	gin.SetMode(gin.ReleaseMode)
 
@@ -87,14 +324,20 @@ func main() {
 	r := gin.Default()
 
 	// Add middleware
+	if config.GlobalConfig.Tracing.Enabled {
+		r.Use(otelgin.Middleware(config.GlobalConfig.Tracing.ServiceName))
+	}
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
+	if config.GlobalConfig.Metrics.Enabled {
+		r.Use(metrics.Gin())
+	}
 
 	// 支持 CORS
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Namespace")
 		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Type")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		if c.Request.Method == "OPTIONS" {
@@ -104,58 +347,294 @@ func main() {
 		c.Next()
 	})
 
+	// Readiness, false until the servers are started and again once shutting down
+	var ready atomic.Bool
+
 	// Setup routes
-	setupRoutes(r)
+	h := handler.New(l, config.GlobalConfig, handlerOpts...)
+	setupRoutes(r, h, authn, &ready, checker)
+
+	// TLS of the HTTP, gRPC and proxy servers, with the certificate reloaded on change when enabled
+	var tlsReloader *tlsconfig.Reloader
+	if tlsCfg := &config.GlobalConfig.Server.TLS; tlsCfg.Enabled {
+		tlsReloader, err = tlsconfig.NewReloader(tlsCfg)
+		if err != nil {
+			logger.Fatal("Failed to initialize TLS", logger.ErrorField(err))
+		}
+		defer tlsReloader.Close()
+	}
 
 	// Start server
+	srv := &http.Server{
+		Addr:    config.GlobalConfig.Server.Port,
+		Handler: r,
+	}
+	srv.RegisterOnShutdown(h.Close)
+	if tlsReloader != nil {
+		srv.TLSConfig = tlsReloader.ServerConfig()
+	}
 	go func() {
-		logger.Info("Server starting", logger.String("port", config.GlobalConfig.Server.Port))
-		if err := r.Run(config.GlobalConfig.Server.Port); err != nil {
+		logger.Info("Server starting",
+			logger.String("port", config.GlobalConfig.Server.Port),
+			logger.Bool("tls", srv.TLSConfig != nil),
+		)
+		var err error
+		if srv.TLSConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Fatal("Failed to start server", logger.ErrorField(err))
 		}
 	}()
 
+	// Start gRPC server
+	var grpcOpts []grpc.ServerOption
+	if tlsReloader != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsReloader.ServerConfig())))
+	}
+	if config.GlobalConfig.Tracing.Enabled {
+		grpcOpts = append(grpcOpts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	}
+	if authn != nil {
+		grpcOpts = append(grpcOpts,
+			grpc.ChainUnaryInterceptor(authn.UnaryServerInterceptor(grpcserver.MethodRoles)),
+			grpc.ChainStreamInterceptor(authn.StreamServerInterceptor(grpcserver.MethodRoles)),
+		)
+	}
+	grpcServer := grpcserver.NewWithNamespaces(namespaces, &config.GlobalConfig.Envoy, grpcOpts...)
+	if config.GlobalConfig.Server.GRPCPort != "" {
+		lis, err := net.Listen("tcp", config.GlobalConfig.Server.GRPCPort)
+		if err != nil {
+			logger.Fatal("Failed to listen for gRPC", logger.ErrorField(err))
+		}
+		go func() {
+			logger.Info("gRPC server starting",
+				logger.String("port", config.GlobalConfig.Server.GRPCPort),
+				logger.Bool("tls", tlsReloader != nil),
+			)
+			if err := grpcServer.Serve(lis); err != nil {
+				logger.Fatal("Failed to start gRPC server", logger.ErrorField(err))
+			}
+		}()
+	}
+
+	// Start rate limiting reverse proxy
+	var proxySrv *http.Server
+	if config.GlobalConfig.Proxy.Enabled {
+		p, err := proxy.New(&config.GlobalConfig.Proxy, l)
+		if err != nil {
+			logger.Fatal("Failed to create reverse proxy", logger.ErrorField(err))
+		}
+		proxySrv = &http.Server{
+			Addr:    config.GlobalConfig.Proxy.Port,
+			Handler: p,
+		}
+		if tlsReloader != nil {
+			proxySrv.TLSConfig = tlsReloader.ServerConfig()
+		}
+		go func() {
+			logger.Info("Reverse proxy starting",
+				logger.String("port", config.GlobalConfig.Proxy.Port),
+				logger.Bool("tls", proxySrv.TLSConfig != nil),
+			)
+			var err error
+			if proxySrv.TLSConfig != nil {
+				err = proxySrv.ListenAndServeTLS("", "")
+			} else {
+				err = proxySrv.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				logger.Fatal("Failed to start reverse proxy", logger.ErrorField(err))
+			}
+		}()
+	}
+
+	ready.Store(true)
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	sig := <-quit
+
+	logger.Info("Shutting down server...", logger.String("signal", sig.String()))
+	shutdown(&config.GlobalConfig.Server, &ready, quit, grpcServer, srv, proxySrv)
 
-	logger.Info("Shutting down server...")
+	// The deferred functions then stop the background components, which flush their pending
+	// writes, close Redis once nothing uses it anymore and sync the logger last
 }
 
-func setupRoutes(r *gin.Engine) {
+// shutdown drains the servers. Readiness fails first and the servers keep serving for the
+// shutdown delay, so load balancers stop sending new requests; then the servers stop accepting
+// connections and wait up to the shutdown timeout for in-flight requests. A second signal
+// skips the delay and closes the remaining connections
+func shutdown(cfg *config.ServerConfig, ready *atomic.Bool, quit <-chan os.Signal, grpcServer *grpc.Server, servers ...*http.Server) {
+	ready.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownDelay+cfg.ShutdownTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-quit:
+			logger.Warn("Second signal received, closing the remaining connections")
+			cancel()
+		}
+	}()
+
+	logger.Info("Not ready anymore, waiting for load balancers to stop sending requests",
+		logger.Duration("delay", cfg.ShutdownDelay),
+	)
+	select {
+	case <-time.After(cfg.ShutdownDelay):
+	case <-ctx.Done():
+	}
+
+	logger.Info("Draining connections", logger.Duration("timeout", cfg.ShutdownTimeout))
+	drainCtx, drainCancel := context.WithTimeout(ctx, cfg.ShutdownTimeout)
+	defer drainCancel()
+
+	var wg sync.WaitGroup
+	for _, srv := range servers {
+		if srv == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(drainCtx); err != nil {
+				logger.Warn("Connections not drained in time, closing them",
+					logger.String("addr", srv.Addr),
+					logger.ErrorField(err),
+				)
+				srv.Close()
+			}
+		}(srv)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-drainCtx.Done():
+			logger.Warn("gRPC calls not drained in time, closing them")
+			grpcServer.Stop()
+			<-stopped
+		}
+	}()
+
+	wg.Wait()
+	logger.Info("Servers stopped")
+}
+
+func setupRoutes(r *gin.Engine, h *handler.Handler, authn *auth.Authenticator, ready *atomic.Bool, checker *health.Checker) {
 	logger.Info("Setting up routes")
 
+	// Roles required by the API routes, no-ops when authentication is disabled
+	requireCheck := authn.Require(auth.RoleCheck)
+	requireViewer := authn.Require(auth.RoleViewer)
+	requireAdmin := authn.Require(auth.RoleAdmin)
+
 	// API v1 route group
 	v1 := r.Group("/v1")
 	{
 		// Check rate limit
-		v1.POST("/check_rate_limit", handler.CheckRateLimit)
+		v1.POST("/check_rate_limit", requireCheck, h.CheckRateLimit)
 		logger.Debug("Registered route", logger.String("method", "POST"), logger.String("path", "/v1/check_rate_limit"))
 
 		// Update rate limiting rule
-		v1.POST("/update_rule", handler.UpdateRule)
+		v1.POST("/update_rule", requireAdmin, h.UpdateRule)
 		logger.Debug("Registered route", logger.String("method", "POST"), logger.String("path", "/v1/update_rule"))
 
+		// Delete rate limiting rule
+		v1.POST("/delete_rule", requireAdmin, h.DeleteRule)
+		logger.Debug("Registered route", logger.String("method", "POST"), logger.String("path", "/v1/delete_rule"))
+
+		// Reset token bucket
+		v1.POST("/reset_bucket", requireAdmin, h.ResetBucket)
+		logger.Debug("Registered route", logger.String("method", "POST"), logger.String("path", "/v1/reset_bucket"))
+
 		// Get monitoring statistics
-		v1.GET("/stats", handler.GetStats)
+		v1.GET("/stats", requireViewer, h.GetStats)
 		logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", "/v1/stats"))
 
 		// Get specific rule statistics
-		v1.GET("/rule_stats", handler.GetRuleStats)
+		v1.GET("/rule_stats", requireViewer, h.GetRuleStats)
 		logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", "/v1/rule_stats"))
+
+		// Get top keys by checks and denials
+		v1.GET("/top_keys", requireViewer, h.TopKeys)
+		logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", "/v1/top_keys"))
+
+		// Get usage history of a key
+		v1.GET("/usage", requireViewer, h.GetUsage)
+		logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", "/v1/usage"))
+
+		// Stream decision events
+		v1.GET("/events", requireViewer, h.StreamEvents)
+		logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", "/v1/events"))
+
+		// Forward-auth check for nginx auth_request and Traefik/Caddy
+		v1.GET("/forward_auth", requireCheck, h.ForwardAuth)
+		logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", "/v1/forward_auth"))
 	}
 
-	// Health check
-	r.GET("/health", func(c *gin.Context) {
+	// Liveness check, the process serves requests. It does not check the dependencies, so an
+	// unreachable Redis does not get the service restarted
+	live := func(c *gin.Context) {
 		logger.Debug("Health check request", logger.String("client_ip", c.ClientIP()))
 		c.JSON(200, gin.H{
 			"status":  "ok",
 			"service": "rate-limiter",
 		})
-	})
+	}
+	r.GET("/live", live)
+	logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", "/live"))
+	r.GET("/health", live)
 	logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", "/health"))
 
+	// Readiness check of the dependencies, fails while starting and shutting down
+	r.GET("/ready", func(c *gin.Context) {
+		if !ready.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "not_ready",
+				"service": "rate-limiter",
+				"error":   "starting or shutting down",
+			})
+			return
+		}
+
+		report := checker.Check(c.Request.Context())
+		status, code := "ready", http.StatusOK
+		if report.Status == health.StatusDegraded {
+			status = "degraded"
+		}
+		if !report.Up() {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{
+			"status":     status,
+			"service":    "rate-limiter",
+			"components": report.Components,
+			"checked_at": report.CheckedAt,
+		})
+	})
+	logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", "/ready"))
+
+	// Prometheus metrics
+	metricsCfg := config.GlobalConfig.Metrics
+	if metricsCfg.Enabled {
+		r.GET(metricsCfg.Path, gin.WrapH(metrics.Handler()))
+		logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", metricsCfg.Path))
+	}
+
 	// Swagger documentation
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", "/swagger/*any"))
@@ -163,17 +642,29 @@ func setupRoutes(r *gin.Engine) {
 	// API documentation
 	r.GET("/", func(c *gin.Context) {
 		logger.Debug("API documentation request", logger.String("client_ip", c.ClientIP()))
+		endpoints := gin.H{
+			"POST /v1/check_rate_limit": "Check if rate limit is exceeded (returns allowed status and remaining tokens)",
+			"POST /v1/update_rule":      "Update rate limiting rule",
+			"POST /v1/delete_rule":      "Delete rate limiting rule",
+			"POST /v1/reset_bucket":     "Refill the token bucket of a key",
+			"GET /v1/stats":             "Get all monitoring statistics",
+			"GET /v1/rule_stats":        "Get specific rule statistics",
+			"GET /v1/top_keys":          "Get the keys with the most checks and denials over 5m, 1h or 24h",
+			"GET /v1/usage":             "Get allowed and denied checks per minute of a key",
+			"GET /v1/events":            "Stream denied (and sampled allowed) decisions as server-sent events",
+			"GET /v1/forward_auth":      "Forward-auth rate limit check keyed by request headers (204 or 429)",
+			"GET /live":                 "Liveness check, the process serves requests",
+			"GET /health":               "Liveness check, same as /live",
+			"GET /ready":                "Readiness check of the configuration and Redis, 503 when not ready or shutting down",
+			"GET /swagger/index.html":   "Swagger API documentation",
+		}
+		if metricsCfg.Enabled {
+			endpoints["GET "+metricsCfg.Path] = "Prometheus metrics"
+		}
 		c.JSON(200, gin.H{
-			"service": "Rate Limiter Service",
-			"version": "1.0.0",
-			"endpoints": gin.H{
-				"POST /v1/check_rate_limit": "Check if rate limit is exceeded (returns allowed status and remaining tokens)",
-				"POST /v1/update_rule":      "Update rate limiting rule",
-				"GET /v1/stats":             "Get all monitoring statistics",
-				"GET /v1/rule_stats":        "Get specific rule statistics",
-				"GET /health":               "Health check",
-				"GET /swagger/index.html":   "Swagger API documentation",
-			},
+			"service":   "Rate Limiter Service",
+			"version":   "1.0.0",
+			"endpoints": endpoints,
 		})
 	})
 	logger.Debug("Registered route", logger.String("method", "GET"), logger.String("path", "/"))
@@ -0,0 +1,158 @@
+// Package metrics exposes Prometheus metrics of the rate limiter: check decisions, Redis commands
+// and connection pool, and HTTP request latency
+package metrics
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+const namespace = "ratelimiter"
+
+// otherPattern is the pattern label of keys matching none of the configured patterns
+const otherPattern = "other"
+
+// Check results
+const (
+	ResultAllowed = "allowed"
+	ResultDenied  = "denied"
+	ResultError   = "error"
+)
+
+// Registry holds all metrics of the service, including Go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	checks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "checks_total",
+		Help:      "Rate limit checks by key pattern and result (allowed, denied, error).",
+	}, []string{"pattern", "result"})
+
+	degradedChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "degraded_checks_total",
+		Help:      "Rate limit checks decided by the failure policy (open, closed, local) because the store failed, by key pattern, policy and result.",
+	}, []string{"pattern", "policy", "result"})
+
+	ruleCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rule_cache_lookups_total",
+		Help:      "Rule lookups of the in-process rule cache by result (hit, miss, stale).",
+	}, []string{"result"})
+
+	redisDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Redis command latency by command, including the token bucket script (eval/evalsha).",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command"})
+
+	redisErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_errors_total",
+		Help:      "Failed Redis commands by command. Missing keys are not errors.",
+	}, []string{"command"})
+
+	redisCircuitState = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "redis_circuit_state",
+		Help:      "State of the Redis circuit breaker: 0 closed, 1 half-open, 2 open.",
+	})
+
+	redisCircuitTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_circuit_transitions_total",
+		Help:      "Transitions of the Redis circuit breaker by new state.",
+	}, []string{"state"})
+
+	redisCircuitRejected = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_circuit_rejected_total",
+		Help:      "Redis commands and pipelines failed fast by the open circuit breaker.",
+	})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+)
+
+// keyPatterns are matched in order against keys to label checks
+var keyPatterns []string
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		checks,
+		degradedChecks,
+		ruleCacheLookups,
+		redisDuration,
+		redisErrors,
+		redisCircuitState,
+		redisCircuitTransitions,
+		redisCircuitRejected,
+		httpDuration,
+	)
+}
+
+// Init sets the key patterns used to label check decisions. Call it before serving requests
+func Init(cfg *config.MetricsConfig) {
+	keyPatterns = keyPatterns[:0]
+	for _, p := range cfg.KeyPatterns {
+		if _, err := path.Match(p, ""); err != nil {
+			logger.Warn("Ignoring invalid metrics key pattern",
+				logger.String("pattern", p),
+				logger.ErrorField(err),
+			)
+			continue
+		}
+		keyPatterns = append(keyPatterns, p)
+	}
+
+	logger.Info("Metrics initialized",
+		logger.String("path", cfg.Path),
+		logger.Int("key_patterns", len(keyPatterns)),
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveCheck counts a check decision of key. Raw keys are never used as labels:
+// the key is reported under the first configured pattern it matches, or "other"
+func ObserveCheck(key, result string) {
+	checks.WithLabelValues(Pattern(key), result).Inc()
+}
+
+// ObserveDegraded counts a check decision of key made by the failure policy, in addition to ObserveCheck
+func ObserveDegraded(key, policy, result string) {
+	degradedChecks.WithLabelValues(Pattern(key), policy, result).Inc()
+}
+
+// ObserveRuleCache counts a lookup of the rule cache: hit, miss, or stale when an expired rule is
+// served because the store failed
+func ObserveRuleCache(result string) {
+	ruleCacheLookups.WithLabelValues(result).Inc()
+}
+
+// Pattern returns the first configured key pattern matching key, or "other"
+func Pattern(key string) string {
+	for _, p := range keyPatterns {
+		if ok, _ := path.Match(p, key); ok {
+			return p
+		}
+	}
+	return otherPattern
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisHook returns a go-redis hook recording the latency and errors of every command
+func RedisHook() redis.Hook {
+	return redisHook{}
+}
+
+type redisHook struct{}
+
+func (redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			redisErrors.WithLabelValues("dial").Inc()
+		}
+		return conn, err
+	}
+}
+
+func (redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		observeRedis(cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		observeRedis("pipeline", start, err)
+		return err
+	}
+}
+
+func observeRedis(command string, start time.Time, err error) {
+	redisDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, redis.Nil) {
+		redisErrors.WithLabelValues(command).Inc()
+	}
+}
+
+// ObserveRedisCircuit records a transition of the Redis circuit breaker to state, whose gauge value is value
+func ObserveRedisCircuit(state string, value int) {
+	redisCircuitState.Set(float64(value))
+	redisCircuitTransitions.WithLabelValues(state).Inc()
+}
+
+// ObserveRedisCircuitRejected counts a command failed fast by the open Redis circuit breaker
+func ObserveRedisCircuitRejected() {
+	redisCircuitRejected.Inc()
+}
+
+// PoolStatser is implemented by *redis.Client and *redis.ClusterClient
+type PoolStatser interface {
+	PoolStats() *redis.PoolStats
+}
+
+// RegisterRedisPool exposes the connection pool stats of client
+func RegisterRedisPool(client PoolStatser) {
+	if err := Registry.Register(newPoolCollector(client)); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			panic(err)
+		}
+	}
+}
+
+// poolCollector reads the pool stats of a go-redis client on every scrape
+type poolCollector struct {
+	client PoolStatser
+
+	hits     *prometheus.Desc
+	misses   *prometheus.Desc
+	timeouts *prometheus.Desc
+	conns    *prometheus.Desc
+}
+
+func newPoolCollector(client PoolStatser) *poolCollector {
+	return &poolCollector{
+		client: client,
+		hits: prometheus.NewDesc(namespace+"_redis_pool_hits_total",
+			"Times a free connection was found in the pool.", nil, nil),
+		misses: prometheus.NewDesc(namespace+"_redis_pool_misses_total",
+			"Times a free connection was not found in the pool.", nil, nil),
+		timeouts: prometheus.NewDesc(namespace+"_redis_pool_timeouts_total",
+			"Times a wait for a connection timed out.", nil, nil),
+		conns: prometheus.NewDesc(namespace+"_redis_pool_connections",
+			"Connections in the pool by state (total, idle, stale).", []string{"state"}, nil),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.conns
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.conns, prometheus.GaugeValue, float64(stats.TotalConns), "total")
+	ch <- prometheus.MustNewConstMetric(c.conns, prometheus.GaugeValue, float64(stats.IdleConns), "idle")
+	ch <- prometheus.MustNewConstMetric(c.conns, prometheus.GaugeValue, float64(stats.StaleConns), "stale")
+}
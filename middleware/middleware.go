@@ -0,0 +1,120 @@
+// Package middleware enforces rate limits in-process for net/http and Gin servers.
+//
+// The middleware takes an explicitly constructed limiter and a key extractor, so Go services
+// can share the rules and buckets of the rate limiter service through the same Redis:
+//
+//	client := goredis.NewClient(&goredis.Options{Addr: "localhost:6379"})
+//	l := limiter.New(redis.NewStore(client), config.LimiterConfig{DefaultRate: 10, DefaultBurst: 50})
+//	key := extractor.Join(":", extractor.Header("X-Api-Key"), extractor.PathSegment(1))
+//
+//	http.Handle("/", middleware.HTTP(l, key)(handler))
+//	router.Use(middleware.Gin(l, key))
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/your-org/rate-limiter/extractor"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// Option customizes the middleware
+type Option func(*options)
+
+type options struct {
+	onDenied     http.HandlerFunc
+	onMissingKey http.HandlerFunc
+	onError      func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// WithDeniedHandler sets the handler for rate limited requests. The RateLimit-* and
+// Retry-After headers are already set when it is called. Defaults to a plain 429 response
+func WithDeniedHandler(h http.HandlerFunc) Option {
+	return func(o *options) { o.onDenied = h }
+}
+
+// WithMissingKeyHandler sets the handler for requests the key cannot be extracted from, or whose
+// key is reserved (limiter.ErrReservedKey). Defaults to a plain 400 response
+func WithMissingKeyHandler(h http.HandlerFunc) Option {
+	return func(o *options) { o.onMissingKey = h }
+}
+
+// WithErrorHandler sets the handler for limiter errors, e.g. Redis being unavailable.
+// Defaults to a plain 500 response
+func WithErrorHandler(h func(w http.ResponseWriter, r *http.Request, err error)) Option {
+	return func(o *options) { o.onError = h }
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		onDenied: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		},
+		onMissingKey: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "rate limit key not found in request", http.StatusBadRequest)
+		},
+		onError: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, "rate limit check failed", http.StatusInternalServerError)
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// HTTP returns net/http middleware that rate limits requests by the key extracted with key
+func HTTP(l *limiter.Limiter, key extractor.Func, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if check(l, key, o, w, r) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// Gin returns Gin middleware that rate limits requests by the key extracted with key
+func Gin(l *limiter.Limiter, key extractor.Func, opts ...Option) gin.HandlerFunc {
+	o := newOptions(opts)
+	return func(c *gin.Context) {
+		if !check(l, key, o, c.Writer, c.Request) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// check checks the rate limit of the request and writes the response if it must not proceed.
+// It reports whether the request is allowed
+func check(l *limiter.Limiter, key extractor.Func, o *options, w http.ResponseWriter, r *http.Request) bool {
+	k, ok := key(r)
+	if !ok {
+		logger.Debug("Rate limit key not found in request", logger.String("path", r.URL.Path))
+		o.onMissingKey(w, r)
+		return false
+	}
+
+	d, err := l.CheckKey(r.Context(), k, 1)
+	if errors.Is(err, limiter.ErrReservedKey) {
+		logger.Debug("Reserved rate limit key in request", logger.String("path", r.URL.Path))
+		o.onMissingKey(w, r)
+		return false
+	}
+	if err != nil {
+		o.onError(w, r, err)
+		return false
+	}
+
+	limiter.SetDecisionHeaders(w.Header(), d)
+	if !d.Allowed {
+		o.onDenied(w, r)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/extractor"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/limiter/memory"
+	"github.com/your-org/rate-limiter/middleware"
+)
+
+// errStore fails every check
+type errStore struct{ limiter.Store }
+
+func (errStore) TakeTokensWithRule(context.Context, string, int64, int64, int64, int64) (limiter.TakeResult, error) {
+	return limiter.TakeResult{}, context.DeadlineExceeded
+}
+
+func newLimiter(t *testing.T) *limiter.Limiter {
+	t.Helper()
+	store := memory.New(0)
+	t.Cleanup(func() { store.Close() })
+	return limiter.New(store, config.LimiterConfig{DefaultRate: 1, DefaultBurst: 1})
+}
+
+// servers returns the HTTP and Gin middleware around a handler answering 200
+func servers(l *limiter.Limiter, opts ...middleware.Option) map[string]http.Handler {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	key := extractor.Header("X-Api-Key")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Gin(l, key, opts...))
+	router.GET("/", gin.WrapH(ok))
+
+	return map[string]http.Handler{
+		"http": middleware.HTTP(l, key, opts...)(ok),
+		"gin":  router,
+	}
+}
+
+func send(h http.Handler, key string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if key != "" {
+		r.Header.Set("X-Api-Key", key)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	for name, h := range servers(newLimiter(t)) {
+		t.Run(name, func(t *testing.T) {
+			key := "key-" + name
+			if w := send(h, key); w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "0" {
+				t.Fatalf("first request: got %d %v, want 200 with the rate limit headers", w.Code, w.Header())
+			}
+
+			w := send(h, key)
+			if w.Code != http.StatusTooManyRequests {
+				t.Fatalf("second request: got %d, want 429", w.Code)
+			}
+			for header, want := range map[string]string{"RateLimit-Limit": "1", "RateLimit-Remaining": "0", "Retry-After": "1"} {
+				if got := w.Header().Get(header); got != want {
+					t.Errorf("%s: got %q, want %q", header, got, want)
+				}
+			}
+
+			if w := send(h, ""); w.Code != http.StatusBadRequest {
+				t.Errorf("missing key: got %d, want 400", w.Code)
+			}
+			if w := send(h, "ns:team:key"); w.Code != http.StatusBadRequest {
+				t.Errorf("reserved key: got %d, want 400", w.Code)
+			}
+		})
+	}
+}
+
+func TestMiddlewareHandlers(t *testing.T) {
+	var denied, missing, failed int
+	opts := []middleware.Option{
+		middleware.WithDeniedHandler(func(w http.ResponseWriter, r *http.Request) {
+			denied++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}),
+		middleware.WithMissingKeyHandler(func(w http.ResponseWriter, r *http.Request) {
+			missing++
+			w.WriteHeader(http.StatusUnauthorized)
+		}),
+		middleware.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			failed++
+			w.WriteHeader(http.StatusBadGateway)
+		}),
+	}
+
+	for name, h := range servers(newLimiter(t), opts...) {
+		t.Run(name, func(t *testing.T) {
+			denied, missing = 0, 0
+			send(h, "key-"+name)
+			if w := send(h, "key-"+name); w.Code != http.StatusServiceUnavailable || denied != 1 {
+				t.Errorf("denied: got %d and %d calls, want 503 from the handler", w.Code, denied)
+			}
+			if w := send(h, ""); w.Code != http.StatusUnauthorized || missing != 1 {
+				t.Errorf("missing key: got %d and %d calls, want 401 from the handler", w.Code, missing)
+			}
+		})
+	}
+
+	failing := limiter.New(errStore{}, config.LimiterConfig{DefaultRate: 1, DefaultBurst: 1, FailurePolicy: "error"})
+	for name, h := range servers(failing, opts...) {
+		t.Run(name+", error", func(t *testing.T) {
+			failed = 0
+			if w := send(h, "key"); w.Code != http.StatusBadGateway || failed != 1 {
+				t.Errorf("got %d and %d calls, want 502 from the handler", w.Code, failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,935 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: ratelimiter/v1/ratelimiter.proto
+
+package ratelimiterv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CheckRateLimitRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Rate limiting key (user-defined format), e.g. "your_api_key:gpt-4"
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Number of tokens to take at once, optional (defaults to 1)
+	Tokens        int64 `protobuf:"varint,2,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckRateLimitRequest) Reset() {
+	*x = CheckRateLimitRequest{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckRateLimitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckRateLimitRequest) ProtoMessage() {}
+
+func (x *CheckRateLimitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckRateLimitRequest.ProtoReflect.Descriptor instead.
+func (*CheckRateLimitRequest) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CheckRateLimitRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CheckRateLimitRequest) GetTokens() int64 {
+	if x != nil {
+		return x.Tokens
+	}
+	return 0
+}
+
+type CheckRateLimitResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Whether the request is allowed
+	Allowed bool `protobuf:"varint,1,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	// Error message (if any)
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// Number of remaining tokens
+	Remain int64 `protobuf:"varint,3,opt,name=remain,proto3" json:"remain,omitempty"`
+	// Failure policy (open, closed, local) that decided the check while the store was unavailable
+	Degraded      string `protobuf:"bytes,4,opt,name=degraded,proto3" json:"degraded,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckRateLimitResponse) Reset() {
+	*x = CheckRateLimitResponse{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckRateLimitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckRateLimitResponse) ProtoMessage() {}
+
+func (x *CheckRateLimitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckRateLimitResponse.ProtoReflect.Descriptor instead.
+func (*CheckRateLimitResponse) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckRateLimitResponse) GetAllowed() bool {
+	if x != nil {
+		return x.Allowed
+	}
+	return false
+}
+
+func (x *CheckRateLimitResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CheckRateLimitResponse) GetRemain() int64 {
+	if x != nil {
+		return x.Remain
+	}
+	return 0
+}
+
+func (x *CheckRateLimitResponse) GetDegraded() string {
+	if x != nil {
+		return x.Degraded
+	}
+	return ""
+}
+
+type UpdateRuleRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Rate limiting key (user-defined format)
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Tokens per second
+	RateLimit int64 `protobuf:"varint,2,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+	// Bucket capacity, optional (defaults to 50)
+	Burst         int64 `protobuf:"varint,3,opt,name=burst,proto3" json:"burst,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRuleRequest) Reset() {
+	*x = UpdateRuleRequest{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRuleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRuleRequest) ProtoMessage() {}
+
+func (x *UpdateRuleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRuleRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRuleRequest) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UpdateRuleRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *UpdateRuleRequest) GetRateLimit() int64 {
+	if x != nil {
+		return x.RateLimit
+	}
+	return 0
+}
+
+func (x *UpdateRuleRequest) GetBurst() int64 {
+	if x != nil {
+		return x.Burst
+	}
+	return 0
+}
+
+type UpdateRuleResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Status of the operation
+	Status string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	// Response message
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRuleResponse) Reset() {
+	*x = UpdateRuleResponse{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRuleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRuleResponse) ProtoMessage() {}
+
+func (x *UpdateRuleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRuleResponse.ProtoReflect.Descriptor instead.
+func (*UpdateRuleResponse) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UpdateRuleResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UpdateRuleResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type DeleteRuleRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Rate limiting key
+	Key           string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRuleRequest) Reset() {
+	*x = DeleteRuleRequest{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRuleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRuleRequest) ProtoMessage() {}
+
+func (x *DeleteRuleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRuleRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRuleRequest) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeleteRuleRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type DeleteRuleResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Status of the operation
+	Status string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	// Response message
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRuleResponse) Reset() {
+	*x = DeleteRuleResponse{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRuleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRuleResponse) ProtoMessage() {}
+
+func (x *DeleteRuleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRuleResponse.ProtoReflect.Descriptor instead.
+func (*DeleteRuleResponse) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteRuleResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *DeleteRuleResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ResetBucketRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Rate limiting key
+	Key           string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetBucketRequest) Reset() {
+	*x = ResetBucketRequest{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetBucketRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetBucketRequest) ProtoMessage() {}
+
+func (x *ResetBucketRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetBucketRequest.ProtoReflect.Descriptor instead.
+func (*ResetBucketRequest) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ResetBucketRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type ResetBucketResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Status of the operation
+	Status string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	// Response message
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetBucketResponse) Reset() {
+	*x = ResetBucketResponse{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetBucketResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetBucketResponse) ProtoMessage() {}
+
+func (x *ResetBucketResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetBucketResponse.ProtoReflect.Descriptor instead.
+func (*ResetBucketResponse) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ResetBucketResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ResetBucketResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type Rule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Tokens per second
+	Rate int64 `protobuf:"varint,1,opt,name=rate,proto3" json:"rate,omitempty"`
+	// Bucket capacity
+	Burst int64 `protobuf:"varint,2,opt,name=burst,proto3" json:"burst,omitempty"`
+	// Unix timestamp of the last update
+	UpdatedAt     int64 `protobuf:"varint,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rule) Reset() {
+	*x = Rule{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rule) ProtoMessage() {}
+
+func (x *Rule) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rule.ProtoReflect.Descriptor instead.
+func (*Rule) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Rule) GetRate() int64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *Rule) GetBurst() int64 {
+	if x != nil {
+		return x.Burst
+	}
+	return 0
+}
+
+func (x *Rule) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type RuleStats struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Whether a rule exists for the key; the other fields are unset if not
+	Found bool `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	// Tokens per second
+	Rate int64 `protobuf:"varint,2,opt,name=rate,proto3" json:"rate,omitempty"`
+	// Bucket capacity
+	Burst int64 `protobuf:"varint,3,opt,name=burst,proto3" json:"burst,omitempty"`
+	// Tokens currently left in the bucket
+	CurrentTokens int64 `protobuf:"varint,4,opt,name=current_tokens,json=currentTokens,proto3" json:"current_tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RuleStats) Reset() {
+	*x = RuleStats{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RuleStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RuleStats) ProtoMessage() {}
+
+func (x *RuleStats) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RuleStats.ProtoReflect.Descriptor instead.
+func (*RuleStats) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RuleStats) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *RuleStats) GetRate() int64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *RuleStats) GetBurst() int64 {
+	if x != nil {
+		return x.Burst
+	}
+	return 0
+}
+
+func (x *RuleStats) GetCurrentTokens() int64 {
+	if x != nil {
+		return x.CurrentTokens
+	}
+	return 0
+}
+
+type GetStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{10}
+}
+
+type GetStatsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Rate limiting rules
+	Rules map[string]*Rule `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Statistics for each rule
+	Stats         map[string]*RuleStats `protobuf:"bytes,2,rep,name=stats,proto3" json:"stats,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetStatsResponse) GetRules() map[string]*Rule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetStats() map[string]*RuleStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+type GetRuleStatsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Rate limiting key
+	Key           string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRuleStatsRequest) Reset() {
+	*x = GetRuleStatsRequest{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRuleStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRuleStatsRequest) ProtoMessage() {}
+
+func (x *GetRuleStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRuleStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetRuleStatsRequest) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetRuleStatsRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type GetRuleStatsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Rate limiting key
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Statistics for the key
+	Stats         *RuleStats `protobuf:"bytes,2,opt,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRuleStatsResponse) Reset() {
+	*x = GetRuleStatsResponse{}
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRuleStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRuleStatsResponse) ProtoMessage() {}
+
+func (x *GetRuleStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimiter_v1_ratelimiter_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRuleStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetRuleStatsResponse) Descriptor() ([]byte, []int) {
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetRuleStatsResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *GetRuleStatsResponse) GetStats() *RuleStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+var File_ratelimiter_v1_ratelimiter_proto protoreflect.FileDescriptor
+
+const file_ratelimiter_v1_ratelimiter_proto_rawDesc = "" +
+	"\n" +
+	" ratelimiter/v1/ratelimiter.proto\x12\x0eratelimiter.v1\"A\n" +
+	"\x15CheckRateLimitRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06tokens\x18\x02 \x01(\x03R\x06tokens\"\x80\x01\n" +
+	"\x16CheckRateLimitResponse\x12\x18\n" +
+	"\aallowed\x18\x01 \x01(\bR\aallowed\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x16\n" +
+	"\x06remain\x18\x03 \x01(\x03R\x06remain\x12\x1a\n" +
+	"\bdegraded\x18\x04 \x01(\tR\bdegraded\"Z\n" +
+	"\x11UpdateRuleRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1d\n" +
+	"\n" +
+	"rate_limit\x18\x02 \x01(\x03R\trateLimit\x12\x14\n" +
+	"\x05burst\x18\x03 \x01(\x03R\x05burst\"F\n" +
+	"\x12UpdateRuleResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"%\n" +
+	"\x11DeleteRuleRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"F\n" +
+	"\x12DeleteRuleResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"&\n" +
+	"\x12ResetBucketRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"G\n" +
+	"\x13ResetBucketResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"O\n" +
+	"\x04Rule\x12\x12\n" +
+	"\x04rate\x18\x01 \x01(\x03R\x04rate\x12\x14\n" +
+	"\x05burst\x18\x02 \x01(\x03R\x05burst\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\x03R\tupdatedAt\"r\n" +
+	"\tRuleStats\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\bR\x05found\x12\x12\n" +
+	"\x04rate\x18\x02 \x01(\x03R\x04rate\x12\x14\n" +
+	"\x05burst\x18\x03 \x01(\x03R\x05burst\x12%\n" +
+	"\x0ecurrent_tokens\x18\x04 \x01(\x03R\rcurrentTokens\"\x11\n" +
+	"\x0fGetStatsRequest\"\xbd\x02\n" +
+	"\x10GetStatsResponse\x12A\n" +
+	"\x05rules\x18\x01 \x03(\v2+.ratelimiter.v1.GetStatsResponse.RulesEntryR\x05rules\x12A\n" +
+	"\x05stats\x18\x02 \x03(\v2+.ratelimiter.v1.GetStatsResponse.StatsEntryR\x05stats\x1aN\n" +
+	"\n" +
+	"RulesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12*\n" +
+	"\x05value\x18\x02 \x01(\v2\x14.ratelimiter.v1.RuleR\x05value:\x028\x01\x1aS\n" +
+	"\n" +
+	"StatsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12/\n" +
+	"\x05value\x18\x02 \x01(\v2\x19.ratelimiter.v1.RuleStatsR\x05value:\x028\x01\"'\n" +
+	"\x13GetRuleStatsRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"Y\n" +
+	"\x14GetRuleStatsResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12/\n" +
+	"\x05stats\x18\x02 \x01(\v2\x19.ratelimiter.v1.RuleStatsR\x05stats2\x9a\x04\n" +
+	"\vRateLimiter\x12_\n" +
+	"\x0eCheckRateLimit\x12%.ratelimiter.v1.CheckRateLimitRequest\x1a&.ratelimiter.v1.CheckRateLimitResponse\x12S\n" +
+	"\n" +
+	"UpdateRule\x12!.ratelimiter.v1.UpdateRuleRequest\x1a\".ratelimiter.v1.UpdateRuleResponse\x12S\n" +
+	"\n" +
+	"DeleteRule\x12!.ratelimiter.v1.DeleteRuleRequest\x1a\".ratelimiter.v1.DeleteRuleResponse\x12V\n" +
+	"\vResetBucket\x12\".ratelimiter.v1.ResetBucketRequest\x1a#.ratelimiter.v1.ResetBucketResponse\x12M\n" +
+	"\bGetStats\x12\x1f.ratelimiter.v1.GetStatsRequest\x1a .ratelimiter.v1.GetStatsResponse\x12Y\n" +
+	"\fGetRuleStats\x12#.ratelimiter.v1.GetRuleStatsRequest\x1a$.ratelimiter.v1.GetRuleStatsResponseBEZCgithub.com/your-org/rate-limiter/proto/ratelimiter/v1;ratelimiterv1b\x06proto3"
+
+var (
+	file_ratelimiter_v1_ratelimiter_proto_rawDescOnce sync.Once
+	file_ratelimiter_v1_ratelimiter_proto_rawDescData []byte
+)
+
+func file_ratelimiter_v1_ratelimiter_proto_rawDescGZIP() []byte {
+	file_ratelimiter_v1_ratelimiter_proto_rawDescOnce.Do(func() {
+		file_ratelimiter_v1_ratelimiter_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ratelimiter_v1_ratelimiter_proto_rawDesc), len(file_ratelimiter_v1_ratelimiter_proto_rawDesc)))
+	})
+	return file_ratelimiter_v1_ratelimiter_proto_rawDescData
+}
+
+var file_ratelimiter_v1_ratelimiter_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_ratelimiter_v1_ratelimiter_proto_goTypes = []any{
+	(*CheckRateLimitRequest)(nil),  // 0: ratelimiter.v1.CheckRateLimitRequest
+	(*CheckRateLimitResponse)(nil), // 1: ratelimiter.v1.CheckRateLimitResponse
+	(*UpdateRuleRequest)(nil),      // 2: ratelimiter.v1.UpdateRuleRequest
+	(*UpdateRuleResponse)(nil),     // 3: ratelimiter.v1.UpdateRuleResponse
+	(*DeleteRuleRequest)(nil),      // 4: ratelimiter.v1.DeleteRuleRequest
+	(*DeleteRuleResponse)(nil),     // 5: ratelimiter.v1.DeleteRuleResponse
+	(*ResetBucketRequest)(nil),     // 6: ratelimiter.v1.ResetBucketRequest
+	(*ResetBucketResponse)(nil),    // 7: ratelimiter.v1.ResetBucketResponse
+	(*Rule)(nil),                   // 8: ratelimiter.v1.Rule
+	(*RuleStats)(nil),              // 9: ratelimiter.v1.RuleStats
+	(*GetStatsRequest)(nil),        // 10: ratelimiter.v1.GetStatsRequest
+	(*GetStatsResponse)(nil),       // 11: ratelimiter.v1.GetStatsResponse
+	(*GetRuleStatsRequest)(nil),    // 12: ratelimiter.v1.GetRuleStatsRequest
+	(*GetRuleStatsResponse)(nil),   // 13: ratelimiter.v1.GetRuleStatsResponse
+	nil,                            // 14: ratelimiter.v1.GetStatsResponse.RulesEntry
+	nil,                            // 15: ratelimiter.v1.GetStatsResponse.StatsEntry
+}
+var file_ratelimiter_v1_ratelimiter_proto_depIdxs = []int32{
+	14, // 0: ratelimiter.v1.GetStatsResponse.rules:type_name -> ratelimiter.v1.GetStatsResponse.RulesEntry
+	15, // 1: ratelimiter.v1.GetStatsResponse.stats:type_name -> ratelimiter.v1.GetStatsResponse.StatsEntry
+	9,  // 2: ratelimiter.v1.GetRuleStatsResponse.stats:type_name -> ratelimiter.v1.RuleStats
+	8,  // 3: ratelimiter.v1.GetStatsResponse.RulesEntry.value:type_name -> ratelimiter.v1.Rule
+	9,  // 4: ratelimiter.v1.GetStatsResponse.StatsEntry.value:type_name -> ratelimiter.v1.RuleStats
+	0,  // 5: ratelimiter.v1.RateLimiter.CheckRateLimit:input_type -> ratelimiter.v1.CheckRateLimitRequest
+	2,  // 6: ratelimiter.v1.RateLimiter.UpdateRule:input_type -> ratelimiter.v1.UpdateRuleRequest
+	4,  // 7: ratelimiter.v1.RateLimiter.DeleteRule:input_type -> ratelimiter.v1.DeleteRuleRequest
+	6,  // 8: ratelimiter.v1.RateLimiter.ResetBucket:input_type -> ratelimiter.v1.ResetBucketRequest
+	10, // 9: ratelimiter.v1.RateLimiter.GetStats:input_type -> ratelimiter.v1.GetStatsRequest
+	12, // 10: ratelimiter.v1.RateLimiter.GetRuleStats:input_type -> ratelimiter.v1.GetRuleStatsRequest
+	1,  // 11: ratelimiter.v1.RateLimiter.CheckRateLimit:output_type -> ratelimiter.v1.CheckRateLimitResponse
+	3,  // 12: ratelimiter.v1.RateLimiter.UpdateRule:output_type -> ratelimiter.v1.UpdateRuleResponse
+	5,  // 13: ratelimiter.v1.RateLimiter.DeleteRule:output_type -> ratelimiter.v1.DeleteRuleResponse
+	7,  // 14: ratelimiter.v1.RateLimiter.ResetBucket:output_type -> ratelimiter.v1.ResetBucketResponse
+	11, // 15: ratelimiter.v1.RateLimiter.GetStats:output_type -> ratelimiter.v1.GetStatsResponse
+	13, // 16: ratelimiter.v1.RateLimiter.GetRuleStats:output_type -> ratelimiter.v1.GetRuleStatsResponse
+	11, // [11:17] is the sub-list for method output_type
+	5,  // [5:11] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_ratelimiter_v1_ratelimiter_proto_init() }
+func file_ratelimiter_v1_ratelimiter_proto_init() {
+	if File_ratelimiter_v1_ratelimiter_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ratelimiter_v1_ratelimiter_proto_rawDesc), len(file_ratelimiter_v1_ratelimiter_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ratelimiter_v1_ratelimiter_proto_goTypes,
+		DependencyIndexes: file_ratelimiter_v1_ratelimiter_proto_depIdxs,
+		MessageInfos:      file_ratelimiter_v1_ratelimiter_proto_msgTypes,
+	}.Build()
+	File_ratelimiter_v1_ratelimiter_proto = out.File
+	file_ratelimiter_v1_ratelimiter_proto_goTypes = nil
+	file_ratelimiter_v1_ratelimiter_proto_depIdxs = nil
+}
@@ -0,0 +1,171 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/extractor"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+	"github.com/your-org/rate-limiter/middleware"
+)
+
+// Proxy is a rate limiting reverse proxy. Requests are matched to a route by path prefix,
+// keyed by the route's extractors, checked with the limiter and forwarded to the route's
+// upstream or rejected with 429
+type Proxy struct {
+	routes []*route
+}
+
+type route struct {
+	name       string
+	pathPrefix string
+	strip      bool
+	handler    http.Handler
+}
+
+// New creates a reverse proxy from its configuration, checking requests with l
+func New(cfg *config.ProxyConfig, l *limiter.Limiter) (*Proxy, error) {
+	if len(cfg.Routes) == 0 {
+		return nil, fmt.Errorf("proxy requires at least one route")
+	}
+
+	p := &Proxy{}
+	for i, rc := range cfg.Routes {
+		r, err := newRoute(rc, l)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy route %d (%s): %w", i, rc.Name, err)
+		}
+		p.routes = append(p.routes, r)
+
+		logger.Info("Registered proxy route",
+			logger.String("name", rc.Name),
+			logger.String("path_prefix", rc.PathPrefix),
+			logger.String("upstream", rc.Upstream),
+		)
+	}
+
+	// Longest prefix wins
+	sort.SliceStable(p.routes, func(i, j int) bool {
+		return len(p.routes[i].pathPrefix) > len(p.routes[j].pathPrefix)
+	})
+
+	return p, nil
+}
+
+func newRoute(rc config.ProxyRouteConfig, l *limiter.Limiter) (*route, error) {
+	if rc.PathPrefix == "" || !strings.HasPrefix(rc.PathPrefix, "/") {
+		return nil, fmt.Errorf("path_prefix must start with /")
+	}
+	target, err := url.Parse(rc.Upstream)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid upstream %q", rc.Upstream)
+	}
+	if len(rc.Key) == 0 {
+		return nil, fmt.Errorf("at least one key extractor is required")
+	}
+
+	funcs := make([]extractor.Func, 0, len(rc.Key)+1)
+	if rc.KeyPrefix != "" {
+		funcs = append(funcs, extractor.Static(rc.KeyPrefix))
+	}
+	for _, kc := range rc.Key {
+		f, err := extractor.FromConfig(kc)
+		if err != nil {
+			return nil, err
+		}
+		funcs = append(funcs, f)
+	}
+	separator := rc.Separator
+	if separator == "" {
+		separator = ":"
+	}
+
+	r := &route{
+		name:       rc.Name,
+		pathPrefix: rc.PathPrefix,
+		strip:      rc.StripPrefix,
+	}
+	upstream := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if r.strip {
+				pr.Out.URL.Path = "/" + strings.TrimLeft(strings.TrimPrefix(pr.In.URL.Path, r.pathPrefix), "/")
+				pr.Out.URL.RawPath = ""
+			}
+			pr.SetURL(target)
+			pr.SetXForwarded()
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			logger.Error("Proxy upstream request failed",
+				logger.String("route", r.name),
+				logger.String("upstream", target.String()),
+				logger.ErrorField(err),
+			)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	r.handler = middleware.HTTP(l, extractor.Join(separator, funcs...),
+		middleware.WithDeniedHandler(func(w http.ResponseWriter, req *http.Request) {
+			logger.Warn("Rate limit exceeded",
+				logger.String("route", r.name),
+				logger.String("path", req.URL.Path),
+			)
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		}),
+		middleware.WithMissingKeyHandler(func(w http.ResponseWriter, req *http.Request) {
+			logger.Warn("Failed to extract rate limit key",
+				logger.String("route", r.name),
+				logger.String("path", req.URL.Path),
+			)
+			http.Error(w, "rate limit key not found in request", http.StatusBadRequest)
+		}),
+		middleware.WithErrorHandler(func(w http.ResponseWriter, req *http.Request, err error) {
+			logger.Error("Rate limit check failed",
+				logger.String("route", r.name),
+				logger.ErrorField(err),
+			)
+			http.Error(w, "rate limit check failed", http.StatusInternalServerError)
+		}),
+	)(upstream)
+	return r, nil
+}
+
+// ServeHTTP implements http.Handler
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	startTime := time.Now()
+
+	r := p.match(req.URL.Path)
+	if r == nil {
+		logger.Debug("No proxy route matched", logger.String("path", req.URL.Path))
+		http.NotFound(w, req)
+		return
+	}
+
+	r.handler.ServeHTTP(w, req)
+
+	logger.Info("Proxy request completed",
+		logger.String("route", r.name),
+		logger.String("path", req.URL.Path),
+		logger.Duration("duration", time.Since(startTime)),
+	)
+}
+
+// match returns the route with the longest matching path prefix
+func (p *Proxy) match(path string) *route {
+	for _, r := range p.routes {
+		if !strings.HasPrefix(path, r.pathPrefix) {
+			continue
+		}
+		// "/api" matches "/api" and "/api/x" but not "/apix"
+		if len(path) == len(r.pathPrefix) || strings.HasSuffix(r.pathPrefix, "/") || path[len(r.pathPrefix)] == '/' {
+			return r
+		}
+	}
+	return nil
+}
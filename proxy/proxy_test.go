@@ -0,0 +1,129 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/limiter/memory"
+)
+
+// newUpstream returns an upstream answering with its name and the path and query it received
+func newUpstream(t *testing.T, name string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", name)
+		w.Header().Set("X-Forwarded-Host-Seen", r.Header.Get("X-Forwarded-Host"))
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, r.URL.RequestURI())
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// newProxy returns a proxy of /api to v1 and of /api/v2, stripped, to v2, keyed by X-Api-Key.
+// Each key gets a single request
+func newProxy(t *testing.T) *Proxy {
+	t.Helper()
+	store := memory.New(0)
+	t.Cleanup(func() { store.Close() })
+	l := limiter.New(store, config.LimiterConfig{DefaultRate: 1, DefaultBurst: 1})
+
+	key := []config.KeyExtractorConfig{{Type: "header", Name: "X-Api-Key"}}
+	p, err := New(&config.ProxyConfig{Routes: []config.ProxyRouteConfig{
+		{Name: "v1", PathPrefix: "/api", Upstream: newUpstream(t, "v1"), Key: key},
+		{Name: "v2", PathPrefix: "/api/v2", Upstream: newUpstream(t, "v2"), StripPrefix: true, KeyPrefix: "v2", Key: key},
+	}}, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProxyRoutes(t *testing.T) {
+	p := newProxy(t)
+
+	tests := []struct {
+		path     string
+		status   int
+		upstream string
+		wantPath string // received by the upstream
+	}{
+		{path: "/api", status: http.StatusCreated, upstream: "v1", wantPath: "/api"},
+		{path: "/api/users?page=2", status: http.StatusCreated, upstream: "v1", wantPath: "/api/users?page=2"},
+		{path: "/api/v2", status: http.StatusCreated, upstream: "v2", wantPath: "/"},
+		{path: "/api/v2/users?page=2", status: http.StatusCreated, upstream: "v2", wantPath: "/users?page=2"},
+		{path: "/api/v2x", status: http.StatusCreated, upstream: "v1", wantPath: "/api/v2x"},
+		{path: "/apix", status: http.StatusNotFound},
+		{path: "/other", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-Api-Key", tt.path) // a bucket per request
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.upstream == "" {
+				return
+			}
+			if got := w.Header().Get("X-Upstream"); got != tt.upstream {
+				t.Errorf("got upstream %q, want %q", got, tt.upstream)
+			}
+			if got := w.Body.String(); got != tt.wantPath {
+				t.Errorf("upstream got %q, want %q", got, tt.wantPath)
+			}
+			if got := w.Header().Get("X-Forwarded-Host-Seen"); got != req.Host {
+				t.Errorf("X-Forwarded-Host: got %q, want %q", got, req.Host)
+			}
+			if w.Header().Get("RateLimit-Limit") != "1" || w.Header().Get("RateLimit-Remaining") != "0" {
+				t.Errorf("rate limit headers: got %v", w.Header())
+			}
+		})
+	}
+}
+
+func TestProxyRateLimits(t *testing.T) {
+	p := newProxy(t)
+	send := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			req.Header.Set("X-Api-Key", key)
+		}
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("/api/a", "alice"); w.Code != http.StatusCreated {
+		t.Fatalf("first request: got %d, want 201", w.Code)
+	}
+	w := send("/api/b", "alice")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: got %d, want 429", w.Code)
+	}
+	if w.Header().Get("X-Upstream") != "" {
+		t.Error("the denied request reached the upstream")
+	}
+	for name, want := range map[string]string{"RateLimit-Limit": "1", "RateLimit-Remaining": "0", "Retry-After": "1"} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+
+	// The key prefix of v2 gives alice another bucket
+	if w := send("/api/v2/a", "alice"); w.Code != http.StatusCreated {
+		t.Errorf("other route: got %d, want 201", w.Code)
+	}
+
+	w = send("/api/a", "")
+	if w.Code != http.StatusBadRequest || w.Header().Get("X-Upstream") != "" {
+		t.Errorf("missing key: got %d, want 400 without reaching the upstream", w.Code)
+	}
+}
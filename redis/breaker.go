@@ -0,0 +1,264 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/logger"
+	"github.com/your-org/rate-limiter/metrics"
+)
+
+// ErrCircuitOpen is returned without calling Redis while the circuit breaker is open
+var ErrCircuitOpen = errors.New("redis: circuit breaker open")
+
+// CircuitBreaker guards the global Client, nil when the breaker is disabled
+var CircuitBreaker *Breaker
+
+// BreakerState is the state of a Breaker
+type BreakerState int
+
+// Breaker states, their values are the values of the redis_circuit_state metric
+const (
+	StateClosed   BreakerState = iota // commands are sent to Redis
+	StateHalfOpen                     // a few probe commands are sent to Redis
+	StateOpen                         // commands fail with ErrCircuitOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerStatus is a snapshot of a Breaker, reported by the readiness check
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+}
+
+// Breaker is a circuit breaker and a go-redis hook. It opens after FailureThreshold consecutive
+// failed or slow commands, then fails every command with ErrCircuitOpen for OpenTimeout instead of
+// waiting for the timeouts and retries of the client. It then lets HalfOpenMaxCalls probe commands
+// through: the circuit closes when they all succeed and opens again on the first failure
+type Breaker struct {
+	cfg config.RedisBreakerConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	state     BreakerState
+	gen       uint64 // incremented on every transition, outcomes of older commands are ignored
+	failures  int    // consecutive failures while closed
+	probes    int    // probe commands let through while half-open
+	successes int    // successful probe commands while half-open
+	openedAt  time.Time
+	lastErr   error
+}
+
+// NewBreaker creates a closed Breaker
+func NewBreaker(cfg *config.RedisBreakerConfig) *Breaker {
+	b := &Breaker{cfg: *cfg, now: time.Now}
+	if b.cfg.FailureThreshold <= 0 {
+		b.cfg.FailureThreshold = 5
+	}
+	if b.cfg.OpenTimeout <= 0 {
+		b.cfg.OpenTimeout = 5 * time.Second
+	}
+	if b.cfg.HalfOpenMaxCalls <= 0 {
+		b.cfg.HalfOpenMaxCalls = 1
+	}
+	return b
+}
+
+// State returns the current state, an open breaker past its OpenTimeout is reported half-open
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && !b.now().Before(b.openedAt.Add(b.cfg.OpenTimeout)) {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// Status returns a snapshot of the breaker
+func (b *Breaker) Status() BreakerStatus {
+	state := b.State()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		State:               state.String(),
+		ConsecutiveFailures: b.failures,
+	}
+	if state != StateClosed {
+		openedAt := b.openedAt.UTC()
+		status.OpenedAt = &openedAt
+	}
+	if b.lastErr != nil {
+		status.LastError = b.lastErr.Error()
+	}
+	return status
+}
+
+// allow reports whether a command may be sent and returns the generation to record its outcome with
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if b.now().Before(b.openedAt.Add(b.cfg.OpenTimeout)) {
+			return 0, ErrCircuitOpen
+		}
+		b.transition(StateHalfOpen)
+	}
+	if b.state == StateHalfOpen {
+		if b.probes >= b.cfg.HalfOpenMaxCalls {
+			return 0, ErrCircuitOpen
+		}
+		b.probes++
+	}
+	return b.gen, nil
+}
+
+// record records the outcome of a command allowed at generation gen
+func (b *Breaker) record(gen uint64, err error, elapsed time.Duration) {
+	failed := isUnavailable(err)
+	if !failed && b.cfg.SlowCallThreshold > 0 && elapsed > b.cfg.SlowCallThreshold {
+		failed = true
+		err = fmt.Errorf("slow call took %s", elapsed)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if gen != b.gen {
+		return
+	}
+	// A canceled caller says nothing about Redis, its probe is given to another command
+	if errors.Is(err, context.Canceled) {
+		if b.state == StateHalfOpen {
+			b.probes--
+		}
+		return
+	}
+	if failed {
+		b.lastErr = err
+	}
+
+	switch b.state {
+	case StateClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.transition(StateOpen)
+		}
+	case StateHalfOpen:
+		if failed {
+			b.transition(StateOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenMaxCalls {
+			b.transition(StateClosed)
+		}
+	}
+}
+
+// transition moves the breaker to state. b.mu must be held
+func (b *Breaker) transition(state BreakerState) {
+	from := b.state
+	b.state = state
+	b.gen++
+	b.probes = 0
+	b.successes = 0
+
+	switch state {
+	case StateOpen:
+		b.openedAt = b.now()
+		logger.Warn("Redis circuit breaker opened, failing commands fast",
+			logger.String("from", from.String()),
+			logger.Int("consecutive_failures", b.failures),
+			logger.Duration("open_timeout", b.cfg.OpenTimeout),
+			logger.ErrorField(b.lastErr),
+		)
+	case StateHalfOpen:
+		logger.Info("Redis circuit breaker half-open, probing Redis")
+	case StateClosed:
+		b.failures = 0
+		b.lastErr = nil
+		logger.Info("Redis circuit breaker closed, Redis recovered")
+	}
+	metrics.ObserveRedisCircuit(state.String(), int(state))
+}
+
+// isUnavailable reports whether err means Redis could not serve the command. Replies such as
+// NOSCRIPT or WRONGTYPE come from a working server
+func isUnavailable(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var replyErr redis.Error
+	if errors.As(err, &replyErr) {
+		msg := replyErr.Error()
+		return strings.HasPrefix(msg, "LOADING") ||
+			strings.HasPrefix(msg, "CLUSTERDOWN") ||
+			strings.HasPrefix(msg, "MASTERDOWN")
+	}
+	return true
+}
+
+func (b *Breaker) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (b *Breaker) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		gen, err := b.allow()
+		if err != nil {
+			metrics.ObserveRedisCircuitRejected()
+			cmd.SetErr(err)
+			return err
+		}
+		start := time.Now()
+		err = next(ctx, cmd)
+		b.record(gen, err, time.Since(start))
+		return err
+	}
+}
+
+func (b *Breaker) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		gen, err := b.allow()
+		if err != nil {
+			metrics.ObserveRedisCircuitRejected()
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		start := time.Now()
+		err = next(ctx, cmds)
+		b.record(gen, err, time.Since(start))
+		return err
+	}
+}
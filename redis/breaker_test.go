@@ -0,0 +1,178 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/your-org/rate-limiter/config"
+)
+
+var errDown = errors.New("dial tcp: connection refused")
+
+// newTestBreaker returns a Breaker on a stopped clock, advanced through the returned pointer
+func newTestBreaker(cfg config.RedisBreakerConfig) (*Breaker, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	b := NewBreaker(&cfg)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+// call runs a command through b that fails with err, and returns the error of allow
+func call(b *Breaker, err error) error {
+	gen, allowErr := b.allow()
+	if allowErr != nil {
+		return allowErr
+	}
+	b.record(gen, err, time.Millisecond)
+	return nil
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(config.RedisBreakerConfig{FailureThreshold: 3, OpenTimeout: time.Second})
+
+	call(b, errDown)
+	call(b, errDown)
+	call(b, nil) // a success resets the count
+	call(b, errDown)
+	call(b, errDown)
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("after 2 consecutive failures: got %s, want closed", got)
+	}
+
+	call(b, errDown)
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("after 3 consecutive failures: got %s, want open", got)
+	}
+	if err := call(b, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("command while open: got %v, want ErrCircuitOpen", err)
+	}
+	if status := b.Status(); status.OpenedAt == nil || status.LastError != errDown.Error() {
+		t.Errorf("status: got %+v", status)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes []error // of the probes
+		want     BreakerState
+	}{
+		{name: "probes succeed", outcomes: []error{nil, nil}, want: StateClosed},
+		{name: "first probe fails", outcomes: []error{errDown}, want: StateOpen},
+		{name: "second probe fails", outcomes: []error{nil, errDown}, want: StateOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, now := newTestBreaker(config.RedisBreakerConfig{
+				FailureThreshold: 1,
+				OpenTimeout:      time.Second,
+				HalfOpenMaxCalls: 2,
+			})
+			call(b, errDown)
+
+			*now = now.Add(time.Second - time.Nanosecond)
+			if got := b.State(); got != StateOpen {
+				t.Fatalf("before the open timeout: got %s, want open", got)
+			}
+			*now = now.Add(time.Nanosecond)
+			if got := b.State(); got != StateHalfOpen {
+				t.Fatalf("after the open timeout: got %s, want half_open", got)
+			}
+
+			for i, err := range tt.outcomes {
+				if allowErr := call(b, err); allowErr != nil {
+					t.Fatalf("probe %d: %v", i, allowErr)
+				}
+			}
+			if got := b.State(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerLimitsProbes(t *testing.T) {
+	b, now := newTestBreaker(config.RedisBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second, HalfOpenMaxCalls: 1})
+	call(b, errDown)
+	*now = now.Add(time.Second)
+
+	gen, err := b.allow()
+	if err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("command beyond the probes: got %v, want ErrCircuitOpen", err)
+	}
+
+	// A canceled probe gives its place to another command
+	b.record(gen, context.Canceled, time.Millisecond)
+	gen, err = b.allow()
+	if err != nil {
+		t.Fatalf("probe after a canceled one: %v", err)
+	}
+	b.record(gen, nil, time.Millisecond)
+	if got := b.State(); got != StateClosed {
+		t.Errorf("got %s, want closed", got)
+	}
+}
+
+func TestBreakerIgnoresStaleOutcomes(t *testing.T) {
+	b, _ := newTestBreaker(config.RedisBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second})
+
+	// A command sent while closed completes after the breaker opened
+	gen, _ := b.allow()
+	call(b, errDown)
+	b.record(gen, nil, time.Millisecond)
+	if got := b.State(); got != StateOpen {
+		t.Errorf("got %s, want open, the outcome of the older command must be ignored", got)
+	}
+}
+
+func TestBreakerSlowCalls(t *testing.T) {
+	b, _ := newTestBreaker(config.RedisBreakerConfig{FailureThreshold: 2, SlowCallThreshold: 100 * time.Millisecond})
+
+	for i := 0; i < 2; i++ {
+		gen, err := b.allow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.record(gen, nil, 200*time.Millisecond)
+	}
+	if got := b.State(); got != StateOpen {
+		t.Errorf("after 2 slow calls: got %s, want open", got)
+	}
+}
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "nil reply", err: redis.Nil, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "network", err: errDown, want: true},
+		{name: "deadline", err: context.DeadlineExceeded, want: true},
+		{name: "noscript", err: redisError("NOSCRIPT No matching script"), want: false},
+		{name: "wrongtype", err: redisError("WRONGTYPE Operation against a key"), want: false},
+		{name: "loading", err: redisError("LOADING Redis is loading the dataset"), want: true},
+		{name: "clusterdown", err: redisError("CLUSTERDOWN The cluster is down"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUnavailable(tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// redisError is a reply error of the server
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+func (redisError) RedisError()     {}
@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/your-org/rate-limiter/limiter"
+)
+
+// tokenBucketScript is the officially recommended token bucket Redis Lua script.
+// The bucket of KEYS[1] holds its token count in KEYS[1] and the last refill time in KEYS[1]:last_refreshed.
+// When the rule hash KEYS[2] is given, its rate and burst replace the ARGV defaults, so the rule is read
+// and applied atomically with the bucket update
+const tokenBucketScript = `
+local key     = KEYS[1]
+local rate    = tonumber(ARGV[1])
+local burst   = tonumber(ARGV[2])
+local now     = tonumber(ARGV[3])
+local requested = tonumber(ARGV[4])
+
+local found = 0
+if KEYS[2] then
+    local rule = redis.call("hmget", KEYS[2], "rate", "burst")
+    local rule_rate = tonumber(rule[1])
+    local rule_burst = tonumber(rule[2])
+    if rule_rate and rule_burst and rule_rate > 0 and rule_burst > 0 then
+        rate = rule_rate
+        burst = rule_burst
+        found = 1
+    end
+end
+
+local fill_time = burst/rate
+local ttl = math.floor(fill_time*2)
+
+local last_tokens = tonumber(redis.call("get", key) or burst)
+local last_refreshed = tonumber(redis.call("get", key .. ":last_refreshed") or now)
+
+local delta = math.max(0, now-last_refreshed)
+local filled_tokens = math.min(burst, last_tokens + (delta*rate))
+local allowed = filled_tokens >= requested
+local new_tokens = filled_tokens
+if allowed then
+    new_tokens = filled_tokens - requested
+end
+
+redis.call("setex", key, ttl, new_tokens)
+redis.call("setex", key .. ":last_refreshed", ttl, now)
+return {allowed and 1 or 0, new_tokens, rate, burst, found}
+`
+
+// tokenBucket runs tokenBucketScript by SHA, sending the script only when a node does not have it
+var tokenBucket = redis.NewScript(tokenBucketScript)
+
+// TakeTokens takes requested tokens from the bucket of key if enough are available at now (unix seconds)
+// and returns whether they were taken and the tokens left in the bucket
+func (s *Store) TakeTokens(ctx context.Context, key string, rate, burst, now, requested int64) (bool, int64, error) {
+	res, err := s.runTokenBucket(ctx, []string{s.prefix + key}, rate, burst, now, requested)
+	if err != nil {
+		return false, 0, err
+	}
+	return res.Allowed, res.Remain, nil
+}
+
+// TakeTokensWithRule reads the rule of key, defaultRate and defaultBurst applying when it has none, and
+// takes requested tokens from the bucket of key at now (unix seconds) with it in one script run: one
+// round trip, and no rule change can land between the read and the update. On a cluster the rule and
+// the bucket are in different slots, so the rule is read first
+func (s *Store) TakeTokensWithRule(ctx context.Context, key string, defaultRate, defaultBurst, now, requested int64) (limiter.TakeResult, error) {
+	if s.AtomicRule() {
+		return s.runTokenBucket(ctx, []string{s.prefix + key, s.ruleKey(key)}, defaultRate, defaultBurst, now, requested)
+	}
+
+	rate, burst, err := s.GetRule(ctx, key)
+	found := err == nil
+	switch {
+	case errors.Is(err, limiter.ErrRuleNotFound):
+		rate, burst = defaultRate, defaultBurst
+	case err != nil:
+		return limiter.TakeResult{}, err
+	}
+	allowed, remain, err := s.TakeTokens(ctx, key, rate, burst, now, requested)
+	if err != nil {
+		return limiter.TakeResult{}, err
+	}
+	return limiter.TakeResult{Allowed: allowed, Remain: remain, Rate: rate, Burst: burst, RuleFound: found}, nil
+}
+
+// AtomicRule reports whether TakeTokensWithRule reads the rule in the script updating the bucket,
+// false on a cluster
+func (s *Store) AtomicRule() bool {
+	_, cluster := s.client.(*redis.ClusterClient)
+	return !cluster
+}
+
+func (s *Store) runTokenBucket(ctx context.Context, keys []string, rate, burst, now, requested int64) (limiter.TakeResult, error) {
+	args := []interface{}{rate, burst, now, requested}
+
+	result, err := tokenBucket.Run(ctx, s.client, keys, args...).Result()
+	if err != nil {
+		return limiter.TakeResult{}, err
+	}
+
+	// Parse result array
+	resultArray, ok := result.([]interface{})
+	if !ok || len(resultArray) != 5 {
+		return limiter.TakeResult{}, fmt.Errorf("invalid result format from Lua script: %v", result)
+	}
+	values := make([]int64, len(resultArray))
+	for i, v := range resultArray {
+		n, ok := v.(int64)
+		if !ok {
+			return limiter.TakeResult{}, fmt.Errorf("invalid result format from Lua script: %v", result)
+		}
+		values[i] = n
+	}
+
+	return limiter.TakeResult{
+		Allowed:   values[0] == 1,
+		Remain:    values[1],
+		Rate:      values[2],
+		Burst:     values[3],
+		RuleFound: values[4] == 1,
+	}, nil
+}
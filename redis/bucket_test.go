@@ -0,0 +1,173 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/your-org/rate-limiter/limiter"
+)
+
+const testNow = 1700000000
+
+func newMiniredis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestTakeTokensWithRule(t *testing.T) {
+	mr, client := newMiniredis(t)
+	s := NewStore(client)
+	ctx := context.Background()
+
+	if err := s.SetRule(ctx, "limited", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	mr.HSet("rule:invalid", "rate", "0", "burst", "10")
+
+	tests := []struct {
+		name      string
+		key       string
+		allowed   bool
+		remain    int64
+		rate      int64
+		burst     int64
+		ruleFound bool
+	}{
+		{name: "rule", key: "limited", allowed: true, remain: 1, rate: 1, burst: 2, ruleFound: true},
+		{name: "rule, last token", key: "limited", allowed: true, remain: 0, rate: 1, burst: 2, ruleFound: true},
+		{name: "rule, empty bucket", key: "limited", allowed: false, remain: 0, rate: 1, burst: 2, ruleFound: true},
+		{name: "no rule", key: "other", allowed: true, remain: 4, rate: 10, burst: 5},
+		{name: "invalid rule", key: "invalid", allowed: true, remain: 4, rate: 10, burst: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.TakeTokensWithRule(ctx, tt.key, 10, 5, testNow, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := limiter.TakeResult{Allowed: tt.allowed, Remain: tt.remain, Rate: tt.rate, Burst: tt.burst, RuleFound: tt.ruleFound}
+			if res != want {
+				t.Errorf("got %+v, want %+v", res, want)
+			}
+		})
+	}
+}
+
+func TestTakeTokensWithRuleAppliesRuleChanges(t *testing.T) {
+	_, client := newMiniredis(t)
+	s := NewStore(client)
+	ctx := context.Background()
+
+	if err := s.SetRule(ctx, "key", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := s.TakeTokensWithRule(ctx, "key", 10, 5, testNow, 1); res.Burst != 2 {
+		t.Fatalf("got burst %d, want 2", res.Burst)
+	}
+
+	// The next check reads the new rule, there is no cache in the Store
+	if err := s.SetRule(ctx, "key", 1, 100); err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.TakeTokensWithRule(ctx, "key", 10, 5, testNow+10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1 token left, refilled with 10 seconds at rate 1, less the token taken
+	if !res.RuleFound || res.Burst != 100 || res.Remain != 10 {
+		t.Errorf("after SetRule: got %+v, want burst 100 and 10 left", res)
+	}
+
+	if _, err := s.DeleteRule(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	res, err = s.TakeTokensWithRule(ctx, "key", 10, 5, testNow+10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RuleFound || res.Rate != 10 || res.Burst != 5 {
+		t.Errorf("after DeleteRule: got %+v, want the defaults", res)
+	}
+}
+
+func TestTakeTokensWithRuleAfterScriptFlush(t *testing.T) {
+	_, client := newMiniredis(t)
+	s := NewStore(client)
+	ctx := context.Background()
+
+	if _, err := s.TakeTokensWithRule(ctx, "key", 10, 5, testNow, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ScriptFlush(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.TakeTokensWithRule(ctx, "key", 10, 5, testNow, 1)
+	if err != nil {
+		t.Fatalf("after SCRIPT FLUSH: %v", err)
+	}
+	if res.Remain != 3 {
+		t.Errorf("got %d left, want 3", res.Remain)
+	}
+}
+
+func TestPrefixedStoresAreIsolated(t *testing.T) {
+	mr, client := newMiniredis(t)
+	root, teamA := NewStore(client), NewPrefixedStore(client, "ns:team-a:")
+	ctx := context.Background()
+
+	if err := teamA.SetRule(ctx, "key", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if !mr.Exists("ns:team-a:rule:key") || mr.Exists("rule:key") {
+		t.Fatal("the rule of the prefixed Store is not under its prefix")
+	}
+	if _, _, err := root.GetRule(ctx, "key"); !errors.Is(err, limiter.ErrRuleNotFound) {
+		t.Fatalf("rule of another prefix: got %v, want ErrRuleNotFound", err)
+	}
+
+	res, err := teamA.TakeTokensWithRule(ctx, "key", 10, 5, testNow, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.RuleFound || res.Burst != 2 {
+		t.Errorf("prefixed Store: got %+v, want its rule", res)
+	}
+	res, err = root.TakeTokensWithRule(ctx, "key", 10, 5, testNow, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RuleFound || res.Remain != 4 {
+		t.Errorf("unprefixed Store: got %+v, want the defaults and its own bucket", res)
+	}
+	if !mr.Exists("ns:team-a:key") || !mr.Exists("key") {
+		t.Error("the buckets are not under the prefix of their Store")
+	}
+}
+
+func TestGetRule(t *testing.T) {
+	_, client := newMiniredis(t)
+	s := NewStore(client)
+	ctx := context.Background()
+
+	if _, _, err := s.GetRule(ctx, "key"); !errors.Is(err, limiter.ErrRuleNotFound) {
+		t.Fatalf("no rule: got %v, want ErrRuleNotFound", err)
+	}
+	if err := s.SetRule(ctx, "key", 3, 7); err != nil {
+		t.Fatal(err)
+	}
+	if rate, burst, err := s.GetRule(ctx, "key"); err != nil || rate != 3 || burst != 7 {
+		t.Fatalf("got %d/%d, %v, want 3/7", rate, burst, err)
+	}
+	if deleted, err := s.DeleteRule(ctx, "key"); err != nil || !deleted {
+		t.Fatalf("DeleteRule: got %v, %v", deleted, err)
+	}
+	if deleted, err := s.DeleteRule(ctx, "key"); err != nil || deleted {
+		t.Fatalf("DeleteRule of a deleted rule: got %v, %v", deleted, err)
+	}
+}
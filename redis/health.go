@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NodeStatus is the outcome of checking a Redis node
+type NodeStatus struct {
+	Addr         string  `json:"addr"`
+	Role         string  `json:"role"` // master or replica
+	LatencyMS    float64 `json:"latency_ms"`
+	ScriptLoaded bool    `json:"script_loaded"` // Only checked on masters, which run the scripts
+	Error        string  `json:"error,omitempty"`
+}
+
+// CheckNodes pings every node of client, each master and replica of a cluster, and makes sure the
+// masters have the token bucket script, loading it where it is missing (after a restart or a
+// SCRIPT FLUSH). It returns an error if a node is unreachable or cannot load the script
+func CheckNodes(ctx context.Context, client redis.Cmdable) ([]NodeStatus, error) {
+	var mu sync.Mutex
+	var nodes []NodeStatus
+	check := func(ctx context.Context, addr, role string, node redis.Cmdable) {
+		status := checkNode(ctx, node, role == "master")
+		status.Addr = addr
+		status.Role = role
+		mu.Lock()
+		nodes = append(nodes, status)
+		mu.Unlock()
+	}
+
+	switch c := client.(type) {
+	case *redis.ClusterClient:
+		// The callbacks always succeed, node errors are reported in their status
+		err := c.ForEachMaster(ctx, func(ctx context.Context, shard *redis.Client) error {
+			check(ctx, shard.Options().Addr, "master", shard)
+			return nil
+		})
+		if err == nil {
+			err = c.ForEachSlave(ctx, func(ctx context.Context, shard *redis.Client) error {
+				check(ctx, shard.Options().Addr, "replica", shard)
+				return nil
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cluster nodes: %w", err)
+		}
+	case *redis.Client:
+		check(ctx, c.Options().Addr, "master", c)
+	default:
+		check(ctx, "", "master", client)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Role != nodes[j].Role {
+			return nodes[i].Role == "master"
+		}
+		return nodes[i].Addr < nodes[j].Addr
+	})
+
+	var errs []error
+	for _, n := range nodes {
+		if n.Error != "" {
+			errs = append(errs, fmt.Errorf("%s %s: %s", n.Role, n.Addr, n.Error))
+		}
+	}
+	if len(nodes) == 0 {
+		errs = append(errs, errors.New("no node"))
+	}
+	return nodes, errors.Join(errs...)
+}
+
+func checkNode(ctx context.Context, node redis.Cmdable, master bool) NodeStatus {
+	var status NodeStatus
+
+	start := time.Now()
+	err := node.Ping(ctx).Err()
+	status.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if !master {
+		return status
+	}
+
+	exists, err := tokenBucket.Exists(ctx, node).Result()
+	if err != nil {
+		status.Error = "failed to check the token bucket script: " + err.Error()
+		return status
+	}
+	if len(exists) == 0 || !exists[0] {
+		if err := tokenBucket.Load(ctx, node).Err(); err != nil {
+			status.Error = "failed to load the token bucket script: " + err.Error()
+			return status
+		}
+	}
+	status.ScriptLoaded = true
+	return status
+}
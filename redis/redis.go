@@ -2,36 +2,126 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
 	"github.com/your-org/rate-limiter/logger"
+	"github.com/your-org/rate-limiter/metrics"
+	"github.com/your-org/rate-limiter/tlsconfig"
+	"github.com/your-org/rate-limiter/tracing"
 )
 
 var Client redis.Cmdable
 
-// Init initializes Redis connection (single node or cluster)
+// Store reads and writes rate limiting rules and token buckets through a Redis client.
+// The package level functions use a Store on the global Client
+type Store struct {
+	client redis.Cmdable
+	prefix string // prepended to the rule and bucket keys
+}
+
+var (
+	_ limiter.Store     = (*Store)(nil)
+	_ limiter.RuleTaker = (*Store)(nil)
+)
+
+// NewStore creates a Store on an explicitly constructed client (single node or cluster)
+func NewStore(client redis.Cmdable) *Store {
+	return &Store{client: client}
+}
+
+// NewPrefixedStore creates a Store keeping its rules in <prefix>rule:<key> and its buckets in
+// <prefix><key>, isolated from the Stores with other prefixes
+func NewPrefixedStore(client redis.Cmdable, prefix string) *Store {
+	return &Store{client: client, prefix: prefix}
+}
+
+func (s *Store) ruleKey(key string) string {
+	return s.prefix + "rule:" + key
+}
+
+// Init initializes Redis connection (single node, cluster or Sentinel)
 func Init(cfg *config.RedisConfig) error {
-	// Check if cluster configuration is provided
-	if cfg.Cluster != nil && len(cfg.Cluster.Nodes) > 0 {
-		return initCluster(cfg)
+	cluster := cfg.Cluster != nil && len(cfg.Cluster.Nodes) > 0
+	sentinel := cfg.Sentinel != nil && (cfg.Sentinel.MasterName != "" || len(cfg.Sentinel.Addrs) > 0)
+	if cluster && sentinel {
+		return errors.New("redis: cluster and sentinel are exclusive")
+	}
+
+	tlsCfg, err := tlsConfig(&cfg.TLS)
+	if err != nil {
+		return err
+	}
+
+	// The breaker is the first hook, commands it fails fast are not measured as Redis commands
+	CircuitBreaker = nil
+	if cfg.Breaker.Enabled {
+		CircuitBreaker = NewBreaker(&cfg.Breaker)
+	}
+
+	// Check if cluster or Sentinel configuration is provided
+	if cluster {
+		return initCluster(cfg, tlsCfg)
+	}
+	if sentinel {
+		return initSentinel(cfg, tlsCfg)
 	}
-	return initSingleNode(cfg)
+	return initSingleNode(cfg, tlsCfg)
+}
+
+// tlsConfig returns the TLS configuration of the Redis connections, nil if TLS is disabled
+func tlsConfig(cfg *config.RedisTLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	minVersion, err := tlsconfig.ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, fmt.Errorf("redis tls: %w", err)
+	}
+	tlsCfg := &tls.Config{
+		MinVersion:         minVersion,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile != "" {
+		pool, err := tlsconfig.LoadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis tls: %w", err)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis tls: failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	if cfg.InsecureSkipVerify {
+		logger.Warn("Redis TLS server certificate verification disabled")
+	}
+	return tlsCfg, nil
 }
 
 // initSingleNode initializes single node Redis connection
-func initSingleNode(cfg *config.RedisConfig) error {
+func initSingleNode(cfg *config.RedisConfig, tlsCfg *tls.Config) error {
 	logger.Info("Initializing single node Redis connection",
 		logger.String("addr", cfg.Addr),
 		logger.Int("db", cfg.DB),
 		logger.Int("pool_size", cfg.PoolSize),
+		logger.Bool("tls", tlsCfg != nil),
 	)
 
 	client := redis.NewClient(&redis.Options{
 		Addr:         cfg.Addr,
+		Username:     cfg.Username,
 		Password:     cfg.Password,
 		DB:           cfg.DB,
 		PoolSize:     cfg.PoolSize,
@@ -39,12 +129,19 @@ func initSingleNode(cfg *config.RedisConfig) error {
 		DialTimeout:  cfg.DialTimeout,
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
-		// Connection pool configuration
-		MaxRetries:      3,
-		MinRetryBackoff: 8 * time.Millisecond,
-		MaxRetryBackoff: 512 * time.Millisecond,
+		TLSConfig:    tlsCfg,
+		// Retries of failed commands
+		MaxRetries:      cfg.MaxRetries,
+		MinRetryBackoff: cfg.MinRetryBackoff,
+		MaxRetryBackoff: cfg.MaxRetryBackoff,
 	})
 
+	if CircuitBreaker != nil {
+		client.AddHook(CircuitBreaker)
+	}
+	client.AddHook(metrics.RedisHook())
+	client.AddHook(tracing.RedisHook())
+	metrics.RegisterRedisPool(client)
 	Client = client
 
 	// Test connection
@@ -61,26 +158,35 @@ func initSingleNode(cfg *config.RedisConfig) error {
 }
 
 // initCluster initializes Redis cluster connection
-func initCluster(cfg *config.RedisConfig) error {
+func initCluster(cfg *config.RedisConfig, tlsCfg *tls.Config) error {
 	logger.Info("Initializing Redis cluster connection",
 		logger.Any("nodes", cfg.Cluster.Nodes),
 		logger.Int("pool_size", cfg.PoolSize),
+		logger.Bool("tls", tlsCfg != nil),
 	)
 
 	client := redis.NewClusterClient(&redis.ClusterOptions{
 		Addrs:        cfg.Cluster.Nodes,
+		Username:     cfg.Username,
 		Password:     cfg.Password,
 		PoolSize:     cfg.PoolSize,
 		MinIdleConns: cfg.MinIdleConns,
 		DialTimeout:  cfg.DialTimeout,
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
-		// Connection pool configuration
-		MaxRetries:      3,
-		MinRetryBackoff: 8 * time.Millisecond,
-		MaxRetryBackoff: 512 * time.Millisecond,
+		TLSConfig:    tlsCfg,
+		// Retries of failed commands
+		MaxRetries:      cfg.MaxRetries,
+		MinRetryBackoff: cfg.MinRetryBackoff,
+		MaxRetryBackoff: cfg.MaxRetryBackoff,
 	})
 
+	if CircuitBreaker != nil {
+		client.AddHook(CircuitBreaker)
+	}
+	client.AddHook(metrics.RedisHook())
+	client.AddHook(tracing.RedisHook())
+	metrics.RegisterRedisPool(client)
 	Client = client
 
 	// Test connection
@@ -96,6 +202,62 @@ func initCluster(cfg *config.RedisConfig) error {
 	return nil
 }
 
+// initSentinel initializes the connection to the master of a Sentinel monitored group.
+// The client asks the Sentinels for the master and reconnects to the new master after a failover
+func initSentinel(cfg *config.RedisConfig, tlsCfg *tls.Config) error {
+	if cfg.Sentinel.MasterName == "" || len(cfg.Sentinel.Addrs) == 0 {
+		return errors.New("redis sentinel: master_name and addrs are required")
+	}
+
+	logger.Info("Initializing Redis Sentinel connection",
+		logger.String("master_name", cfg.Sentinel.MasterName),
+		logger.Any("sentinels", cfg.Sentinel.Addrs),
+		logger.Int("db", cfg.DB),
+		logger.Int("pool_size", cfg.PoolSize),
+		logger.Bool("tls", tlsCfg != nil),
+	)
+
+	client := redis.NewFailoverClient(&redis.FailoverOptions{
+		MasterName:       cfg.Sentinel.MasterName,
+		SentinelAddrs:    cfg.Sentinel.Addrs,
+		SentinelUsername: cfg.Sentinel.Username,
+		SentinelPassword: cfg.Sentinel.Password,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		DB:               cfg.DB,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+		DialTimeout:      cfg.DialTimeout,
+		ReadTimeout:      cfg.ReadTimeout,
+		WriteTimeout:     cfg.WriteTimeout,
+		TLSConfig:        tlsCfg,
+		// Retries of failed commands
+		MaxRetries:      cfg.MaxRetries,
+		MinRetryBackoff: cfg.MinRetryBackoff,
+		MaxRetryBackoff: cfg.MaxRetryBackoff,
+	})
+
+	if CircuitBreaker != nil {
+		client.AddHook(CircuitBreaker)
+	}
+	client.AddHook(metrics.RedisHook())
+	client.AddHook(tracing.RedisHook())
+	metrics.RegisterRedisPool(client)
+	Client = client
+
+	// Test connection
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.Error("Failed to connect to Redis through Sentinel", logger.ErrorField(err))
+		return fmt.Errorf("failed to connect to Redis through Sentinel: %w", err)
+	}
+
+	logger.Info("Redis Sentinel connection established successfully")
+	return nil
+}
+
 // Close closes Redis connection
 func Close() error {
 	if Client != nil {
@@ -112,7 +274,27 @@ func Close() error {
 
 // SetRule sets rate limiting rule
 func SetRule(ctx context.Context, key string, rate, burst int64) error {
-	ruleKey := fmt.Sprintf("rule:%s", key)
+	return NewStore(Client).SetRule(ctx, key, rate, burst)
+}
+
+// GetRule gets rate limiting rule
+func GetRule(ctx context.Context, key string) (rate, burst int64, err error) {
+	return NewStore(Client).GetRule(ctx, key)
+}
+
+// GetAllRules gets all rate limiting rules
+func GetAllRules(ctx context.Context) (map[string]map[string]interface{}, error) {
+	return NewStore(Client).GetAllRules(ctx)
+}
+
+// GetStats gets rate limiting statistics
+func GetStats(ctx context.Context, key string) (map[string]interface{}, error) {
+	return NewStore(Client).GetStats(ctx, key)
+}
+
+// SetRule sets rate limiting rule
+func (s *Store) SetRule(ctx context.Context, key string, rate, burst int64) error {
+	ruleKey := s.ruleKey(key)
 
 	logger.Info("Setting rate limit rule",
 		logger.String("key", key),
@@ -120,7 +302,7 @@ func SetRule(ctx context.Context, key string, rate, burst int64) error {
 		logger.Int64("burst", burst),
 	)
 
-	err := Client.HMSet(ctx, ruleKey, map[string]interface{}{
+	err := s.client.HMSet(ctx, ruleKey, map[string]interface{}{
 		"rate":       rate,
 		"burst":      burst,
 		"updated_at": time.Now().Unix(),
@@ -139,12 +321,12 @@ func SetRule(ctx context.Context, key string, rate, burst int64) error {
 }
 
 // GetRule gets rate limiting rule
-func GetRule(ctx context.Context, key string) (rate, burst int64, err error) {
-	ruleKey := fmt.Sprintf("rule:%s", key)
+func (s *Store) GetRule(ctx context.Context, key string) (rate, burst int64, err error) {
+	ruleKey := s.ruleKey(key)
 
 	logger.Debug("Getting rate limit rule", logger.String("key", key))
 
-	result, err := Client.HMGet(ctx, ruleKey, "rate", "burst").Result()
+	result, err := s.client.HMGet(ctx, ruleKey, "rate", "burst").Result()
 	if err != nil {
 		logger.Error("Failed to get rate limit rule",
 			logger.String("key", key),
@@ -155,7 +337,7 @@ func GetRule(ctx context.Context, key string) (rate, burst int64, err error) {
 
 	if result[0] == nil || result[1] == nil {
 		logger.Debug("Rate limit rule not found, using defaults", logger.String("key", key))
-		return 0, 0, fmt.Errorf("rule not found")
+		return 0, 0, limiter.ErrRuleNotFound
 	}
 
 	rate, err = strconv.ParseInt(result[0].(string), 10, 64)
@@ -185,13 +367,50 @@ func GetRule(ctx context.Context, key string) (rate, burst int64, err error) {
 	return rate, burst, nil
 }
 
+// DeleteRule deletes the rate limiting rule of key and reports whether it existed
+func (s *Store) DeleteRule(ctx context.Context, key string) (bool, error) {
+	ruleKey := s.ruleKey(key)
+
+	n, err := s.client.Del(ctx, ruleKey).Result()
+	if err != nil {
+		logger.Error("Failed to delete rate limit rule",
+			logger.String("key", key),
+			logger.ErrorField(err),
+		)
+		return false, err
+	}
+
+	logger.Info("Rate limit rule deleted",
+		logger.String("key", key),
+		logger.Bool("existed", n > 0),
+	)
+	return n > 0, nil
+}
+
+// ResetBucket deletes the token bucket of key, so the next check starts with a full bucket
+func (s *Store) ResetBucket(ctx context.Context, key string) error {
+	// Deleted one by one, the two keys may live in different cluster slots
+	for _, k := range []string{s.prefix + key, s.prefix + key + ":last_refreshed"} {
+		if err := s.client.Del(ctx, k).Err(); err != nil {
+			logger.Error("Failed to reset token bucket",
+				logger.String("key", key),
+				logger.ErrorField(err),
+			)
+			return err
+		}
+	}
+
+	logger.Info("Token bucket reset", logger.String("key", key))
+	return nil
+}
+
 // GetAllRules gets all rate limiting rules
-func GetAllRules(ctx context.Context) (map[string]map[string]interface{}, error) {
-	pattern := "rule:*"
+func (s *Store) GetAllRules(ctx context.Context) (map[string]map[string]interface{}, error) {
+	pattern := s.ruleKey("*")
 
 	logger.Debug("Getting all rate limit rules")
 
-	keys, err := Client.Keys(ctx, pattern).Result()
+	keys, err := s.client.Keys(ctx, pattern).Result()
 	if err != nil {
 		logger.Error("Failed to get rule keys", logger.ErrorField(err))
 		return nil, err
@@ -201,8 +420,8 @@ func GetAllRules(ctx context.Context) (map[string]map[string]interface{}, error)
 
 	rules := make(map[string]map[string]interface{})
 	for _, key := range keys {
-		ruleKey := key[5:] // Remove "rule:" prefix
-		result, err := Client.HGetAll(ctx, key).Result()
+		ruleKey := key[len(s.ruleKey("")):] // Remove "<prefix>rule:" prefix
+		result, err := s.client.HGetAll(ctx, key).Result()
 		if err != nil {
 			logger.Warn("Failed to get rule data",
 				logger.String("key", key),
@@ -222,13 +441,13 @@ func GetAllRules(ctx context.Context) (map[string]map[string]interface{}, error)
 }
 
 // GetStats gets rate limiting statistics
-func GetStats(ctx context.Context, key string) (map[string]interface{}, error) {
+func (s *Store) GetStats(ctx context.Context, key string) (map[string]interface{}, error) {
 	logger.Debug("Getting stats for key", logger.String("key", key))
 
 	stats := make(map[string]interface{})
 
 	// Get rule information
-	rate, burst, err := GetRule(ctx, key)
+	rate, burst, err := s.GetRule(ctx, key)
 	if err != nil {
 		stats["rate"] = "unknown"
 		stats["burst"] = "unknown"
@@ -238,7 +457,7 @@ func GetStats(ctx context.Context, key string) (map[string]interface{}, error) {
 	}
 
 	// Get current token count - Fix: use key directly, no need for tokens: prefix
-	tokens, err := Client.Get(ctx, key).Result()
+	tokens, err := s.client.Get(ctx, s.prefix+key).Result()
 	if err != nil && err != redis.Nil {
 		logger.Error("Failed to get current tokens",
 			logger.String("key", key),
@@ -0,0 +1,63 @@
+package rulecache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PubSubClient is a Redis client able to subscribe, *redis.Client and *redis.ClusterClient
+type PubSubClient interface {
+	redis.Cmdable
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// RedisInvalidator broadcasts rule changes over a Redis Pub/Sub channel, the message being the key
+type RedisInvalidator struct {
+	client  PubSubClient
+	channel string
+}
+
+// NewRedisInvalidator creates an invalidator on channel. client must be a *redis.Client or
+// *redis.ClusterClient
+func NewRedisInvalidator(client redis.Cmdable, channel string) (*RedisInvalidator, error) {
+	psc, ok := client.(PubSubClient)
+	if !ok {
+		return nil, fmt.Errorf("redis client %T does not support Pub/Sub", client)
+	}
+	return &RedisInvalidator{client: psc, channel: channel}, nil
+}
+
+// Publish publishes key
+func (i *RedisInvalidator) Publish(ctx context.Context, key string) error {
+	if err := i.client.Publish(ctx, i.channel, key).Err(); err != nil {
+		return fmt.Errorf("failed to publish rule change: %w", err)
+	}
+	return nil
+}
+
+// Subscribe subscribes to the channel until ctx is done or a receive fails. Messages published
+// while no subscription is active are lost, so every subscription invalidates all keys
+func (i *RedisInvalidator) Subscribe(ctx context.Context, invalidate func(key string)) error {
+	ps := i.client.Subscribe(ctx, i.channel)
+	defer ps.Close()
+
+	for {
+		msg, err := ps.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to receive rule changes: %w", err)
+		}
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if msg.Kind == "subscribe" {
+				invalidate("")
+			}
+		case *redis.Message:
+			invalidate(msg.Payload)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+// Package rulecache caches the rules of a limiter.Store in the current process, so checks do not
+// read the rule of their key from Redis every time, and rules stay known while Redis fails. Stores
+// reading the rule in the operation taking the tokens (redis.Store outside a cluster) keep doing so,
+// their checks refresh the cache.
+//
+// Rules set or deleted through a cached Store are invalidated at once on this instance and
+// broadcast to the other instances by an Invalidator. Changes an instance misses, e.g. made while
+// it was disconnected or directly in Redis, are picked up when the entry expires after the TTL
+package rulecache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+	"github.com/your-org/rate-limiter/metrics"
+)
+
+// Lookup results
+const (
+	ResultHit   = "hit"
+	ResultMiss  = "miss"
+	ResultStale = "stale"
+)
+
+// Invalidator broadcasts rule changes to the caches of all instances
+type Invalidator interface {
+	// Publish notifies all instances that the rule of key changed
+	Publish(ctx context.Context, key string) error
+
+	// Subscribe calls invalidate with the key of every rule change, and with "" when changes may
+	// have been missed, e.g. on (re)subscription, until ctx is done
+	Subscribe(ctx context.Context, invalidate func(key string)) error
+}
+
+type entry struct {
+	rate      int64
+	burst     int64
+	found     bool // false for keys without a rule
+	expiresAt time.Time
+}
+
+// Cache holds the rules of the Stores it wraps, by key qualified with their prefix. Expired entries
+// are kept until evicted: they are served while the store fails
+type Cache struct {
+	invalidator Invalidator
+	ttl         time.Duration
+	maxEntries  int
+	now         func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]entry
+	gen     uint64 // incremented on every invalidation, lookups started before do not fill the cache
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// New creates a Cache and subscribes to the rule changes of invalidator, which may be nil when
+// this is the only instance. Call Close to unsubscribe
+func New(invalidator Invalidator, cfg *config.RuleCacheConfig) *Cache {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Cache{
+		invalidator: invalidator,
+		ttl:         cfg.TTL,
+		maxEntries:  cfg.MaxEntries,
+		now:         time.Now,
+		entries:     make(map[string]entry),
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+	if c.ttl <= 0 {
+		c.ttl = 10 * time.Second
+	}
+	if c.maxEntries <= 0 {
+		c.maxEntries = 100000
+	}
+
+	if invalidator == nil {
+		close(c.done)
+		return c
+	}
+	go func() {
+		defer close(c.done)
+		c.subscribe(ctx)
+	}()
+	return c
+}
+
+// Close stops receiving rule changes
+func (c *Cache) Close() {
+	c.once.Do(func() {
+		c.cancel()
+		<-c.done
+	})
+}
+
+// Wrap returns a Store caching the rules of store, whose keys are qualified with prefix in the
+// cache and the invalidations, see limiter.NamespacePrefix
+func (c *Cache) Wrap(store limiter.Store, prefix string) *Store {
+	return &Store{Store: store, cache: c, prefix: prefix}
+}
+
+// subscribe applies the invalidations of the other instances, resubscribing on errors
+func (c *Cache) subscribe(ctx context.Context) {
+	for {
+		err := c.invalidator.Subscribe(ctx, c.invalidate)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Error("Rule invalidation subscription failed, resubscribing", logger.ErrorField(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// invalidate drops the entry of key, or all entries when key is ""
+func (c *Cache) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if key == "" {
+		c.entries = make(map[string]entry)
+		return
+	}
+	delete(c.entries, key)
+}
+
+// get returns the entry of key and the generation to fill it with
+func (c *Cache) get(key string) (entry, bool, uint64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[key]
+	return e, ok, c.gen
+}
+
+// put fills the entry of key, unless it was invalidated since generation gen
+func (c *Cache) put(key string, e entry, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		// Map iteration order is random, an arbitrary entry makes room
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	e.expiresAt = c.now().Add(c.ttl)
+	c.entries[key] = e
+}
+
+// Store is a limiter.Store serving the rules of the wrapped Store from a Cache
+type Store struct {
+	limiter.Store
+	cache  *Cache
+	prefix string
+}
+
+var (
+	_ limiter.Store       = (*Store)(nil)
+	_ limiter.RuleTaker   = (*Store)(nil)
+	_ limiter.CachedRuler = (*Store)(nil)
+)
+
+// GetRule gets the rule of key from the cache, or from the wrapped Store when it is not cached or
+// expired. When the wrapped Store fails, an expired rule is served
+func (s *Store) GetRule(ctx context.Context, key string) (rate, burst int64, err error) {
+	qualifiedKey := s.prefix + key
+	e, cached, gen := s.cache.get(qualifiedKey)
+	if cached && s.cache.now().Before(e.expiresAt) {
+		metrics.ObserveRuleCache(ResultHit)
+		return e.result()
+	}
+
+	rate, burst, err = s.Store.GetRule(ctx, key)
+	switch {
+	case err == nil:
+		s.cache.put(qualifiedKey, entry{rate: rate, burst: burst, found: true}, gen)
+	case errors.Is(err, limiter.ErrRuleNotFound):
+		s.cache.put(qualifiedKey, entry{}, gen)
+	case cached:
+		metrics.ObserveRuleCache(ResultStale)
+		logger.Debug("Serving expired rule, the store failed",
+			logger.String("key", key),
+			logger.ErrorField(err),
+		)
+		return e.result()
+	}
+	metrics.ObserveRuleCache(ResultMiss)
+	return rate, burst, err
+}
+
+// CachedRule gets the rule of key from the cache only, expired or not, see limiter.CachedRuler
+func (s *Store) CachedRule(key string) (rate, burst int64, ok bool) {
+	e, cached, _ := s.cache.get(s.prefix + key)
+	if !cached || !e.found {
+		return 0, 0, false
+	}
+	return e.rate, e.burst, true
+}
+
+// atomicRuleTaker is a limiter.RuleTaker reporting whether it reads the rule in the operation taking
+// the tokens, such as redis.Store
+type atomicRuleTaker interface {
+	limiter.RuleTaker
+	AtomicRule() bool
+}
+
+// TakeTokensWithRule reads the rule of key and takes requested tokens from its bucket. When the
+// wrapped Store does both in one operation, the rule it read refreshes the cache; otherwise the
+// rule is served by GetRule
+func (s *Store) TakeTokensWithRule(ctx context.Context, key string, defaultRate, defaultBurst, now, requested int64) (limiter.TakeResult, error) {
+	rt, ok := s.Store.(atomicRuleTaker)
+	if !ok || !rt.AtomicRule() {
+		rate, burst, err := s.GetRule(ctx, key)
+		found := err == nil
+		switch {
+		case errors.Is(err, limiter.ErrRuleNotFound):
+			rate, burst = defaultRate, defaultBurst
+		case err != nil:
+			return limiter.TakeResult{}, err
+		}
+		allowed, remain, err := s.Store.TakeTokens(ctx, key, rate, burst, now, requested)
+		if err != nil {
+			return limiter.TakeResult{}, err
+		}
+		return limiter.TakeResult{Allowed: allowed, Remain: remain, Rate: rate, Burst: burst, RuleFound: found}, nil
+	}
+
+	qualifiedKey := s.prefix + key
+	_, _, gen := s.cache.get(qualifiedKey)
+	res, err := rt.TakeTokensWithRule(ctx, key, defaultRate, defaultBurst, now, requested)
+	if err != nil {
+		return res, err
+	}
+	e := entry{found: res.RuleFound}
+	if res.RuleFound {
+		e.rate, e.burst = res.Rate, res.Burst
+	}
+	s.cache.put(qualifiedKey, e, gen)
+	return res, nil
+}
+
+func (e entry) result() (rate, burst int64, err error) {
+	if !e.found {
+		return 0, 0, limiter.ErrRuleNotFound
+	}
+	return e.rate, e.burst, nil
+}
+
+// SetRule sets the rule of key and invalidates it on all instances
+func (s *Store) SetRule(ctx context.Context, key string, rate, burst int64) error {
+	if err := s.Store.SetRule(ctx, key, rate, burst); err != nil {
+		return err
+	}
+	s.changed(ctx, key)
+	return nil
+}
+
+// DeleteRule deletes the rule of key and invalidates it on all instances
+func (s *Store) DeleteRule(ctx context.Context, key string) (bool, error) {
+	deleted, err := s.Store.DeleteRule(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if deleted {
+		s.changed(ctx, key)
+	}
+	return deleted, nil
+}
+
+// changed invalidates the rule of key on this instance and broadcasts the change. The change is
+// made, a failed broadcast reaches the other instances when their entry expires
+func (s *Store) changed(ctx context.Context, key string) {
+	qualifiedKey := s.prefix + key
+	s.cache.invalidate(qualifiedKey)
+	if s.cache.invalidator == nil {
+		return
+	}
+	if err := s.cache.invalidator.Publish(ctx, qualifiedKey); err != nil {
+		logger.Error("Failed to broadcast rule change, other instances apply it when their cache expires",
+			logger.String("key", qualifiedKey),
+			logger.ErrorField(err),
+		)
+	}
+}
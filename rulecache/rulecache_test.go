@@ -0,0 +1,401 @@
+package rulecache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/limiter/memory"
+	"github.com/your-org/rate-limiter/redis"
+)
+
+var errDown = errors.New("store unavailable")
+
+// countingStore counts the rule reads reaching a memory.Store, which fail while err is set.
+// afterRead runs once a rule is read, before it is returned
+type countingStore struct {
+	*memory.Store
+	mu        sync.Mutex
+	reads     int
+	err       error
+	afterRead func()
+}
+
+func newCountingStore(t *testing.T) *countingStore {
+	t.Helper()
+	s := &countingStore{Store: memory.New(0)}
+	t.Cleanup(func() { s.Store.Close() })
+	return s
+}
+
+func (s *countingStore) GetRule(ctx context.Context, key string) (int64, int64, error) {
+	s.mu.Lock()
+	s.reads++
+	err, afterRead := s.err, s.afterRead
+	s.mu.Unlock()
+	if err != nil {
+		return 0, 0, err
+	}
+	rate, burst, err := s.Store.GetRule(ctx, key)
+	if afterRead != nil {
+		afterRead()
+	}
+	return rate, burst, err
+}
+
+func (s *countingStore) Reads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reads
+}
+
+// bus is an Invalidator delivering the published keys to the subscriptions of every Cache.
+// Subscriptions end with an error when drop is called
+type bus struct {
+	mu            sync.Mutex
+	subs          map[int]func(key string)
+	next          int
+	drops         []chan struct{}
+	subscriptions int
+}
+
+func newBus() *bus {
+	return &bus{subs: make(map[int]func(key string))}
+}
+
+func (b *bus) Publish(_ context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, invalidate := range b.subs {
+		invalidate(key)
+	}
+	return nil
+}
+
+func (b *bus) Subscribe(ctx context.Context, invalidate func(key string)) error {
+	// Registered after the flush, so waitSubscriptions returns once it is done
+	invalidate("")
+	drop := make(chan struct{})
+	b.mu.Lock()
+	id := b.next
+	b.next++
+	b.subs[id] = invalidate
+	b.drops = append(b.drops, drop)
+	b.subscriptions++
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.subs, id)
+		b.mu.Unlock()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-drop:
+		return errors.New("connection lost")
+	}
+}
+
+// drop ends the current subscriptions
+func (b *bus) drop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, drop := range b.drops {
+		close(drop)
+	}
+	b.drops = nil
+}
+
+// waitSubscriptions waits for n subscriptions to have been made, and to be active
+func (b *bus) waitSubscriptions(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		b.mu.Lock()
+		done := b.subscriptions >= n && len(b.subs) > 0
+		b.mu.Unlock()
+		if done {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d subscriptions not made", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// newCache returns a Cache on a stopped clock, advanced through the returned pointer
+func newCache(t *testing.T, invalidator Invalidator, cfg config.RuleCacheConfig) (*Cache, *time.Time) {
+	t.Helper()
+	now := time.Unix(1700000000, 0)
+	c := New(invalidator, &cfg)
+	c.now = func() time.Time { return now }
+	t.Cleanup(c.Close)
+	return c, &now
+}
+
+func getRule(t *testing.T, s *Store, key string) (int64, int64) {
+	t.Helper()
+	rate, burst, err := s.GetRule(context.Background(), key)
+	if err != nil {
+		t.Fatalf("GetRule %s: %v", key, err)
+	}
+	return rate, burst
+}
+
+func TestGetRuleCachesUntilExpired(t *testing.T) {
+	backend := newCountingStore(t)
+	c, now := newCache(t, nil, config.RuleCacheConfig{TTL: 10 * time.Second})
+	s := c.Wrap(backend, "")
+	ctx := context.Background()
+
+	if err := backend.SetRule(ctx, "key", 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	getRule(t, s, "key")
+	getRule(t, s, "key")
+	if _, _, err := s.GetRule(ctx, "missing"); !errors.Is(err, limiter.ErrRuleNotFound) {
+		t.Fatalf("missing rule: got %v, want ErrRuleNotFound", err)
+	}
+	if _, _, err := s.GetRule(ctx, "missing"); !errors.Is(err, limiter.ErrRuleNotFound) {
+		t.Fatalf("cached missing rule: got %v, want ErrRuleNotFound", err)
+	}
+	if got := backend.Reads(); got != 2 {
+		t.Fatalf("reads: got %d, want 2, a rule and a missing rule", got)
+	}
+
+	*now = now.Add(10 * time.Second)
+	getRule(t, s, "key")
+	if got := backend.Reads(); got != 3 {
+		t.Errorf("reads after the TTL: got %d, want 3", got)
+	}
+}
+
+func TestChangesAreNotServedStale(t *testing.T) {
+	backend := newCountingStore(t)
+	b := newBus()
+	cache1, _ := newCache(t, b, config.RuleCacheConfig{TTL: time.Hour})
+	cache2, _ := newCache(t, b, config.RuleCacheConfig{TTL: time.Hour})
+	b.waitSubscriptions(t, 2)
+	instance1, instance2 := cache1.Wrap(backend, ""), cache2.Wrap(backend, "")
+	ctx := context.Background()
+
+	if err := instance1.SetRule(ctx, "key", 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	getRule(t, instance1, "key")
+	getRule(t, instance2, "key")
+
+	// The instance making the change and the others read the new rule
+	if err := instance1.SetRule(ctx, "key", 2, 10); err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range []*Store{instance1, instance2} {
+		if rate, burst := getRule(t, s, "key"); rate != 2 || burst != 10 {
+			t.Errorf("instance %d after SetRule: got %d/%d, want 2/10", i+1, rate, burst)
+		}
+	}
+
+	if _, err := instance2.DeleteRule(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range []*Store{instance1, instance2} {
+		if _, _, err := s.GetRule(ctx, "key"); !errors.Is(err, limiter.ErrRuleNotFound) {
+			t.Errorf("instance %d after DeleteRule: got %v, want ErrRuleNotFound", i+1, err)
+		}
+	}
+}
+
+func TestInvalidationDuringReadIsNotCached(t *testing.T) {
+	backend := newCountingStore(t)
+	c, _ := newCache(t, nil, config.RuleCacheConfig{TTL: time.Hour})
+	s := c.Wrap(backend, "")
+	ctx := context.Background()
+
+	if err := backend.SetRule(ctx, "key", 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	// The rule changes after the cache read the old one, before it is cached
+	backend.afterRead = func() {
+		backend.afterRead = nil
+		if err := s.SetRule(ctx, "key", 2, 10); err != nil {
+			t.Error(err)
+		}
+	}
+	if rate, _ := getRule(t, s, "key"); rate != 1 {
+		t.Fatalf("read in flight: got rate %d, want 1", rate)
+	}
+	if rate, _ := getRule(t, s, "key"); rate != 2 {
+		t.Errorf("next read: got rate %d, want 2, the read started before the change must not be cached", rate)
+	}
+}
+
+func TestResubscriptionFlushes(t *testing.T) {
+	backend := newCountingStore(t)
+	b := newBus()
+	c, _ := newCache(t, b, config.RuleCacheConfig{TTL: time.Hour})
+	b.waitSubscriptions(t, 1)
+	s := c.Wrap(backend, "")
+	ctx := context.Background()
+
+	if err := backend.SetRule(ctx, "key", 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	getRule(t, s, "key")
+
+	// A change published while the subscription is down is lost
+	b.drop()
+	if err := backend.SetRule(ctx, "key", 2, 10); err != nil {
+		t.Fatal(err)
+	}
+	b.waitSubscriptions(t, 2)
+
+	if rate, _ := getRule(t, s, "key"); rate != 2 {
+		t.Errorf("after resubscribing: got rate %d, want 2, the cache must be flushed", rate)
+	}
+}
+
+func TestServesExpiredRuleWhileStoreFails(t *testing.T) {
+	backend := newCountingStore(t)
+	c, now := newCache(t, nil, config.RuleCacheConfig{TTL: time.Second})
+	s := c.Wrap(backend, "ns:a:")
+	ctx := context.Background()
+
+	if err := backend.SetRule(ctx, "key", 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	getRule(t, s, "key")
+	*now = now.Add(time.Minute)
+	backend.err = errDown
+
+	if rate, burst := getRule(t, s, "key"); rate != 1 || burst != 5 {
+		t.Errorf("expired rule: got %d/%d, want 1/5", rate, burst)
+	}
+	if _, _, err := s.GetRule(ctx, "other"); !errors.Is(err, errDown) {
+		t.Errorf("uncached rule: got %v, want the store error", err)
+	}
+
+	// The cache-only lookup of the failure policy never reaches the store
+	reads := backend.Reads()
+	if rate, burst, ok := s.CachedRule("key"); !ok || rate != 1 || burst != 5 {
+		t.Errorf("CachedRule: got %d/%d %v, want 1/5", rate, burst, ok)
+	}
+	if _, _, ok := s.CachedRule("other"); ok {
+		t.Error("CachedRule of an uncached key: got a rule")
+	}
+	if got := backend.Reads(); got != reads {
+		t.Errorf("CachedRule read the store %d times", got-reads)
+	}
+}
+
+func TestPrefixesAreSeparate(t *testing.T) {
+	backendA, backendB := newCountingStore(t), newCountingStore(t)
+	c, _ := newCache(t, nil, config.RuleCacheConfig{TTL: time.Hour})
+	a, b := c.Wrap(backendA, "ns:a:"), c.Wrap(backendB, "ns:b:")
+	ctx := context.Background()
+
+	if err := a.SetRule(ctx, "key", 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetRule(ctx, "key", 2, 10); err != nil {
+		t.Fatal(err)
+	}
+	if rate, _ := getRule(t, a, "key"); rate != 1 {
+		t.Errorf("namespace a: got rate %d, want 1", rate)
+	}
+	if rate, _ := getRule(t, b, "key"); rate != 2 {
+		t.Errorf("namespace b: got rate %d, want 2", rate)
+	}
+}
+
+func TestMaxEntries(t *testing.T) {
+	backend := newCountingStore(t)
+	c, _ := newCache(t, nil, config.RuleCacheConfig{TTL: time.Hour, MaxEntries: 2})
+	s := c.Wrap(backend, "")
+
+	for _, key := range []string{"a", "b", "c"} {
+		s.GetRule(context.Background(), key)
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if got := len(c.entries); got != 2 {
+		t.Errorf("entries: got %d, want 2", got)
+	}
+}
+
+func TestTakeTokensWithRule(t *testing.T) {
+	backend := newCountingStore(t)
+	c, _ := newCache(t, nil, config.RuleCacheConfig{TTL: time.Hour})
+	s := c.Wrap(backend, "")
+	ctx := context.Background()
+
+	if err := s.SetRule(ctx, "limited", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key       string
+		burst     int64
+		remain    int64
+		ruleFound bool
+	}{
+		{key: "limited", burst: 2, remain: 1, ruleFound: true},
+		{key: "limited", burst: 2, remain: 0, ruleFound: true},
+		{key: "default", burst: 5, remain: 4, ruleFound: false},
+	}
+	for i, tt := range tests {
+		res, err := s.TakeTokensWithRule(ctx, tt.key, 1, 5, 1700000000, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Burst != tt.burst || res.Remain != tt.remain || res.RuleFound != tt.ruleFound {
+			t.Errorf("check %d: got %+v", i, res)
+		}
+	}
+	if got := backend.Reads(); got != 2 {
+		t.Errorf("reads: got %d, want 2, one per key", got)
+	}
+}
+
+func TestAtomicTakeRefreshesCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	c, _ := newCache(t, nil, config.RuleCacheConfig{TTL: time.Hour})
+	s := c.Wrap(redis.NewStore(client), "")
+	ctx := context.Background()
+
+	if err := s.SetRule(ctx, "key", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	// The rule read by the token bucket script fills the cache
+	res, err := s.TakeTokensWithRule(ctx, "key", 10, 5, 1700000000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.RuleFound || res.Burst != 2 {
+		t.Fatalf("got %+v, want the rule", res)
+	}
+	if rate, burst, ok := s.CachedRule("key"); !ok || rate != 1 || burst != 2 {
+		t.Fatalf("CachedRule: got %d/%d %v, want 1/2", rate, burst, ok)
+	}
+
+	// A rule changed behind the cache is read by the next check and refreshes it
+	mr.HSet("rule:key", "rate", "1", "burst", "9")
+	res, err = s.TakeTokensWithRule(ctx, "key", 10, 5, 1700000000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Burst != 9 {
+		t.Errorf("got burst %d, want 9", res.Burst)
+	}
+	if _, burst, _ := s.CachedRule("key"); burst != 9 {
+		t.Errorf("cached burst: got %d, want 9", burst)
+	}
+}
@@ -0,0 +1,202 @@
+// Package tlsconfig builds the TLS configuration of the servers from PEM files, reloading the
+// certificate and the client CA when their files change so rotated certificates are served
+// without a restart
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// ParseVersion parses a TLS version, "1.2" or "1.3"
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q, must be 1.2 or 1.3", version)
+	}
+}
+
+// Reloader serves a certificate and a client CA pool loaded from files, checking every reload
+// interval whether the files changed. On a failed reload the previous certificate and pool are kept
+type Reloader struct {
+	cfg        config.TLSConfig
+	minVersion uint16
+
+	current atomic.Pointer[tls.Config]
+	modTime map[string]time.Time // by file, of the files current was loaded from
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewReloader loads the files of cfg and starts watching them
+func NewReloader(cfg *config.TLSConfig) (*Reloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tls: cert_file and key_file are required")
+	}
+	minVersion, err := ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reloader{
+		cfg:        *cfg,
+		minVersion: minVersion,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if r.cfg.ReloadInterval <= 0 {
+		r.cfg.ReloadInterval = time.Minute
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	go r.run()
+	return r, nil
+}
+
+// ServerConfig returns the TLS configuration of a server, resolved per connection so new
+// connections use the latest certificate and client CA pool
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: r.minVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+		// Unused as GetConfigForClient always returns a configuration, http.Server requires one of
+		// Certificates and GetCertificate
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.current.Load().Certificates[0], nil
+		},
+	}
+}
+
+// Close stops watching the files
+func (r *Reloader) Close() {
+	r.once.Do(func() {
+		close(r.stop)
+		<-r.done
+	})
+}
+
+func (r *Reloader) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.load(); err != nil {
+				logger.Error("Failed to reload TLS certificate, keeping the previous one",
+					logger.String("cert_file", r.cfg.CertFile),
+					logger.ErrorField(err),
+				)
+			}
+		}
+	}
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+// changed reports whether a file was modified since it was loaded
+func (r *Reloader) changed() bool {
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			logger.Error("Failed to check TLS file", logger.String("file", file), logger.ErrorField(err))
+			continue
+		}
+		if !info.ModTime().Equal(r.modTime[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads the files and replaces the current configuration
+func (r *Reloader) load() error {
+	// Modification times are read first, a file changing while it is loaded is loaded again
+	modTime := make(map[string]time.Time, 3)
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("failed to read TLS file: %w", err)
+		}
+		modTime[file] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse TLS certificate: %w", err)
+	}
+	cert.Leaf = leaf
+
+	tlsCfg := &tls.Config{
+		MinVersion:   r.minVersion,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.cfg.ClientCAFile != "" {
+		pool, err := LoadCertPool(r.cfg.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	r.current.Store(tlsCfg)
+	r.modTime = modTime
+	logger.Info("TLS certificate loaded",
+		logger.String("cert_file", r.cfg.CertFile),
+		logger.String("subject", leaf.Subject.String()),
+		logger.Any("not_after", leaf.NotAfter),
+		logger.Bool("mtls", r.cfg.ClientCAFile != ""),
+	)
+	return nil
+}
+
+// LoadCertPool reads the PEM certificates of file into a pool
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA file %s has no PEM certificate", file)
+	}
+	return pool, nil
+}
@@ -0,0 +1,154 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+)
+
+// writePair writes a self-signed certificate of serial and its key, returning the PEM of the key
+func writePair(t *testing.T, certFile, keyFile string, serial int64) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, keyFile, keyPEM)
+	return keyPEM
+}
+
+// writeFile writes data and moves the modification time at least a second past the previous one,
+// so the change is seen even on file systems with a coarse time resolution
+func writeFile(t *testing.T, file string, data []byte) {
+	t.Helper()
+	modTime := time.Now()
+	if info, err := os.Stat(file); err == nil && !modTime.After(info.ModTime()) {
+		modTime = info.ModTime().Add(time.Second)
+	}
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// servedSerial returns the serial of the certificate served by a TLS handshake with cfg
+func servedSerial(t *testing.T, cfg *tls.Config) int64 {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		conn.(*tls.Conn).Handshake()
+		conn.Close()
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestReloaderRotatesCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writePair(t, certFile, keyFile, 1)
+
+	r, err := NewReloader(&config.TLSConfig{CertFile: certFile, KeyFile: keyFile, ReloadInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	cfg := r.ServerConfig()
+	if got := servedSerial(t, cfg); got != 1 {
+		t.Fatalf("got serial %d, want 1", got)
+	}
+
+	writePair(t, certFile, keyFile, 2)
+	deadline := time.Now().Add(5 * time.Second)
+	for r.current.Load().Certificates[0].Leaf.SerialNumber.Int64() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("the rotated certificate was not loaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := servedSerial(t, cfg); got != 2 {
+		t.Errorf("handshake: got serial %d, want 2", got)
+	}
+	cert, err := cfg.GetCertificate(nil)
+	if err != nil || cert.Leaf.SerialNumber.Int64() != 2 {
+		t.Errorf("GetCertificate: got %v, want serial 2", err)
+	}
+}
+
+func TestReloaderKeepsCertificateOnBadPair(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	oldKey := writePair(t, certFile, keyFile, 1)
+
+	r, err := NewReloader(&config.TLSConfig{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close() // the files are checked by this test
+
+	tests := []struct {
+		name  string
+		write func()
+	}{
+		{name: "key of another certificate", write: func() {
+			writePair(t, certFile, keyFile, 2)
+			writeFile(t, keyFile, oldKey)
+		}},
+		{name: "invalid key", write: func() { writeFile(t, keyFile, []byte("not a key")) }},
+		{name: "missing certificate", write: func() { os.Remove(certFile) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.write()
+			if err := r.load(); err == nil {
+				t.Fatal("load: expected an error")
+			}
+			if got := servedSerial(t, r.ServerConfig()); got != 1 {
+				t.Errorf("got serial %d, want the previous certificate", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Package tracing sets up OpenTelemetry tracing: the exporter, sampler and W3C trace context propagation
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the spans created by this service
+const ScopeName = "github.com/your-org/rate-limiter"
+
+// Tracer returns the tracer of the service
+func Tracer() trace.Tracer {
+	return otel.Tracer(ScopeName)
+}
+
+// Init installs the W3C trace context propagator and, if tracing is enabled, a tracer provider
+// exporting to the configured exporter. The returned function flushes and stops the exporter
+func Init(cfg *config.TracingConfig) (func(context.Context) error, error) {
+	// Trace context is propagated even when tracing is disabled, so callers' traces are not broken
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.Enabled {
+		logger.Info("Tracing disabled")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion("1.0.0"),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Sampled callers are always followed, the ratio applies to new traces
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	logger.Info("Tracing initialized",
+		logger.String("exporter", cfg.Exporter),
+		logger.String("endpoint", cfg.Endpoint),
+		logger.Any("sample_ratio", cfg.SampleRatio),
+	)
+	return tp.Shutdown, nil
+}
+
+func newExporter(cfg *config.TracingConfig) (sdktrace.SpanExporter, error) {
+	ctx := context.Background()
+
+	switch cfg.Exporter {
+	case "stdout":
+		return stdouttrace.New()
+	case "otlp":
+		switch cfg.Protocol {
+		case "grpc":
+			opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+			if cfg.Insecure {
+				opts = append(opts, otlptracegrpc.WithInsecure())
+			}
+			return otlptracegrpc.New(ctx, opts...)
+		case "http":
+			opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+			if cfg.Insecure {
+				opts = append(opts, otlptracehttp.WithInsecure())
+			}
+			return otlptracehttp.New(ctx, opts...)
+		default:
+			return nil, fmt.Errorf("unknown OTLP protocol %q, must be grpc or http", cfg.Protocol)
+		}
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, must be otlp or stdout", cfg.Exporter)
+	}
+}
@@ -0,0 +1,76 @@
+package usage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the history in the current process, for the memory backend
+type MemoryStore struct {
+	retention time.Duration
+	now       func() time.Time
+
+	mu   sync.Mutex
+	keys map[string]map[int64]*Point // key -> unix minute -> point
+}
+
+// NewMemoryStore creates a store keeping retention of history
+func NewMemoryStore(retention time.Duration) *MemoryStore {
+	return &MemoryStore{
+		retention: retention,
+		now:       time.Now,
+		keys:      make(map[string]map[int64]*Point),
+	}
+}
+
+// Add adds points and drops the ones older than the retention
+func (s *MemoryStore) Add(_ context.Context, points map[string][]Point) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, ps := range points {
+		minutes, ok := s.keys[key]
+		if !ok {
+			minutes = make(map[int64]*Point)
+			s.keys[key] = minutes
+		}
+		for _, p := range ps {
+			existing, ok := minutes[p.Time.Unix()]
+			if !ok {
+				existing = &Point{Time: p.Time}
+				minutes[p.Time.Unix()] = existing
+			}
+			existing.Allowed += p.Allowed
+			existing.Denied += p.Denied
+			existing.Tokens += p.Tokens
+		}
+	}
+
+	before := s.now().Add(-s.retention).Unix()
+	for key, minutes := range s.keys {
+		for minute := range minutes {
+			if minute < before {
+				delete(minutes, minute)
+			}
+		}
+		if len(minutes) == 0 {
+			delete(s.keys, key)
+		}
+	}
+	return nil
+}
+
+// Range returns the points of key with from <= Time < to
+func (s *MemoryStore) Range(_ context.Context, key string, from, to time.Time) ([]Point, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var points []Point
+	for minute, p := range s.keys[key] {
+		if minute >= from.Unix() && minute < to.Unix() {
+			points = append(points, *p)
+		}
+	}
+	return points, nil
+}
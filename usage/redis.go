@@ -0,0 +1,115 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps the history of a key in one hash per hour, usage:{<key>}:<unix hour>, with the
+// fields <unix minute>:allowed, <unix minute>:denied and <unix minute>:tokens. Hashes expire an hour
+// after the retention, and the hash tag keeps the hours of a key on the same cluster node
+type RedisStore struct {
+	client    redis.Cmdable
+	retention time.Duration
+}
+
+// NewRedisStore creates a store keeping retention of history
+func NewRedisStore(client redis.Cmdable, retention time.Duration) *RedisStore {
+	return &RedisStore{client: client, retention: retention}
+}
+
+func hourKey(key string, hour int64) string {
+	return fmt.Sprintf("usage:{%s}:%d", key, hour)
+}
+
+// Add increments the counters of points in one pipeline
+func (s *RedisStore) Add(ctx context.Context, points map[string][]Point) error {
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, ps := range points {
+			hours := make(map[int64]bool)
+			for _, p := range ps {
+				hour := p.Time.Truncate(time.Hour).Unix()
+				hours[hour] = true
+
+				hk := hourKey(key, hour)
+				field := strconv.FormatInt(p.Time.Unix(), 10)
+				if p.Allowed > 0 {
+					pipe.HIncrBy(ctx, hk, field+":allowed", p.Allowed)
+					pipe.HIncrBy(ctx, hk, field+":tokens", p.Tokens)
+				}
+				if p.Denied > 0 {
+					pipe.HIncrBy(ctx, hk, field+":denied", p.Denied)
+				}
+			}
+			for hour := range hours {
+				pipe.Expire(ctx, hourKey(key, hour), s.retention+time.Hour)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add usage history: %w", err)
+	}
+	return nil
+}
+
+// Range reads the hours overlapping [from, to) in one pipeline
+func (s *RedisStore) Range(ctx context.Context, key string, from, to time.Time) ([]Point, error) {
+	var cmds []*redis.MapStringStringCmd
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for hour := from.Truncate(time.Hour); hour.Before(to); hour = hour.Add(time.Hour) {
+			cmds = append(cmds, pipe.HGetAll(ctx, hourKey(key, hour.Unix())))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage history: %w", err)
+	}
+
+	byMinute := make(map[int64]*Point)
+	for _, cmd := range cmds {
+		for field, value := range cmd.Val() {
+			minuteStr, counter, ok := strings.Cut(field, ":")
+			if !ok {
+				continue
+			}
+			minute, err := strconv.ParseInt(minuteStr, 10, 64)
+			if err != nil {
+				continue
+			}
+			t := time.Unix(minute, 0)
+			if t.Before(from) || !t.Before(to) {
+				continue
+			}
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			p, ok := byMinute[minute]
+			if !ok {
+				p = &Point{Time: t}
+				byMinute[minute] = p
+			}
+			switch counter {
+			case "allowed":
+				p.Allowed = n
+			case "denied":
+				p.Denied = n
+			case "tokens":
+				p.Tokens = n
+			}
+		}
+	}
+
+	points := make([]Point, 0, len(byMinute))
+	for _, p := range byMinute {
+		points = append(points, *p)
+	}
+	return points, nil
+}
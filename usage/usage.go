@@ -0,0 +1,202 @@
+// Package usage records per-key usage history: allowed and denied checks and the tokens taken,
+// per minute, retained for a configurable window.
+//
+// A Recorder observes the limiter decisions, aggregates them in memory and flushes them to a Store
+// periodically, so the latest minute may lag by up to the flush interval
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// maxPendingPoints bounds the key minutes aggregated between two flushes, further decisions are dropped
+const maxPendingPoints = 100000
+
+// Point is the usage of a key during one minute
+type Point struct {
+	Time    time.Time `json:"time" example:"2024-01-01T12:00:00Z"` // Start of the minute
+	Allowed int64     `json:"allowed" example:"120"`               // Allowed checks
+	Denied  int64     `json:"denied" example:"3"`                  // Denied checks
+	Tokens  int64     `json:"tokens" example:"120"`                // Tokens taken by allowed checks
+}
+
+// pointKey identifies the minute of a key
+type pointKey struct {
+	key    string
+	minute int64 // unix seconds
+}
+
+// Store persists per-minute usage
+type Store interface {
+	// Add adds points, grouped by key, to the history of their keys
+	Add(ctx context.Context, points map[string][]Point) error
+
+	// Range returns the points of key with from <= Time < to, one per minute with data
+	Range(ctx context.Context, key string, from, to time.Time) ([]Point, error)
+}
+
+// Recorder aggregates decisions in memory and flushes them to its Store
+type Recorder struct {
+	store     Store
+	retention time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	pending map[pointKey]*Point
+	dropped int64
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewRecorder creates a Recorder flushing to store every cfg.FlushInterval. Call Close to flush
+// the remaining counts
+func NewRecorder(store Store, cfg *config.UsageConfig) *Recorder {
+	interval := cfg.FlushInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	r := &Recorder{
+		store:     store,
+		retention: cfg.Retention,
+		now:       time.Now,
+		pending:   make(map[pointKey]*Point),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go r.run(interval)
+	return r
+}
+
+// Retention returns how long the history is kept
+func (r *Recorder) Retention() time.Duration {
+	return r.retention
+}
+
+// Observe counts a decision, it is a limiter.Observer
+func (r *Recorder) Observe(_ context.Context, d limiter.Decision) {
+	minute := d.Time.Truncate(time.Minute)
+	pk := pointKey{key: d.Key, minute: minute.Unix()}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.pending[pk]
+	if !ok {
+		if len(r.pending) >= maxPendingPoints {
+			r.dropped++
+			return
+		}
+		p = &Point{Time: minute}
+		r.pending[pk] = p
+	}
+	if d.Allowed {
+		p.Allowed++
+		p.Tokens += d.Requested
+	} else {
+		p.Denied++
+	}
+}
+
+// History returns the usage of key over the last window, one point per minute including
+// minutes without checks. window is capped to the retention
+func (r *Recorder) History(ctx context.Context, key string, window time.Duration) ([]Point, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be positive")
+	}
+	if window > r.retention {
+		window = r.retention
+	}
+
+	to := r.now().Truncate(time.Minute).Add(time.Minute)
+	from := to.Add(-window)
+	points, err := r.store.Range(ctx, key, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill the minutes without checks, so graphs do not interpolate over them
+	byMinute := make(map[int64]Point, len(points))
+	for _, p := range points {
+		byMinute[p.Time.Unix()] = p
+	}
+	filled := make([]Point, 0, int(window/time.Minute))
+	for t := from; t.Before(to); t = t.Add(time.Minute) {
+		p, ok := byMinute[t.Unix()]
+		if !ok {
+			p = Point{Time: t}
+		}
+		p.Time = p.Time.UTC()
+		filled = append(filled, p)
+	}
+	return filled, nil
+}
+
+// Close stops the flusher and flushes the remaining counts
+func (r *Recorder) Close() {
+	r.once.Do(func() {
+		close(r.stop)
+		<-r.done
+	})
+}
+
+func (r *Recorder) run(interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			r.flush()
+			return
+		case <-ticker.C:
+			r.flush()
+		}
+	}
+}
+
+// flush writes the pending points to the store. Points are lost if the write fails
+func (r *Recorder) flush() {
+	r.mu.Lock()
+	pending, dropped := r.pending, r.dropped
+	r.pending = make(map[pointKey]*Point, len(pending))
+	r.dropped = 0
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		logger.Warn("Usage history dropped decisions, too many keys between flushes",
+			logger.Int64("dropped", dropped),
+		)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	points := make(map[string][]Point)
+	for pk, p := range pending {
+		points[pk.key] = append(points[pk.key], *p)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := r.store.Add(ctx, points); err != nil {
+		logger.Error("Failed to flush usage history",
+			logger.Int("points", len(pending)),
+			logger.ErrorField(err),
+		)
+		return
+	}
+	logger.Debug("Usage history flushed", logger.Int("points", len(pending)))
+}
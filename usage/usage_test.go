@@ -0,0 +1,135 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+)
+
+// stores returns a memory and a Redis store keeping an hour of history, the memory one on the clock
+// of now
+func stores(t *testing.T, now *time.Time) (map[string]Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	memory := NewMemoryStore(time.Hour)
+	memory.now = func() time.Time { return *now }
+	return map[string]Store{"memory": memory, "redis": NewRedisStore(client, time.Hour)}, mr
+}
+
+func TestHistory(t *testing.T) {
+	now := time.Unix(1700000000, 0) // 22:13:20 UTC
+	minute := now.Truncate(time.Minute)
+	backends, _ := stores(t, &now)
+
+	for name, store := range backends {
+		t.Run(name, func(t *testing.T) {
+			r := NewRecorder(store, &config.UsageConfig{FlushInterval: time.Hour, Retention: time.Hour})
+			r.now = func() time.Time { return now }
+
+			for _, d := range []limiter.Decision{
+				{Key: "key", Allowed: true, Requested: 2, Time: minute.Add(-2*time.Minute + 5*time.Second)},
+				{Key: "key", Allowed: true, Requested: 3, Time: minute.Add(-2*time.Minute + 55*time.Second)},
+				{Key: "key", Allowed: false, Requested: 1, Time: minute.Add(-2 * time.Minute)},
+				{Key: "key", Allowed: true, Requested: 1, Time: now},
+				{Key: "key", Allowed: true, Requested: 1, Time: minute.Add(-50 * time.Minute)}, // the previous hour
+				{Key: "key", Allowed: true, Requested: 1, Time: minute.Add(-2 * time.Hour)},    // beyond the retention
+				{Key: "other", Allowed: true, Requested: 1, Time: now},
+			} {
+				r.Observe(context.Background(), d)
+			}
+			r.Close() // flushes
+
+			points, err := r.History(context.Background(), "key", 5*time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []Point{
+				{Time: minute.Add(-4 * time.Minute)},
+				{Time: minute.Add(-3 * time.Minute)},
+				{Time: minute.Add(-2 * time.Minute), Allowed: 2, Denied: 1, Tokens: 5},
+				{Time: minute.Add(-1 * time.Minute)},
+				{Time: minute, Allowed: 1, Tokens: 1},
+			}
+			if len(points) != len(want) {
+				t.Fatalf("got %d points, want %d", len(points), len(want))
+			}
+			for i, p := range points {
+				if !p.Time.Equal(want[i].Time) || p.Time.Location() != time.UTC || p.Allowed != want[i].Allowed ||
+					p.Denied != want[i].Denied || p.Tokens != want[i].Tokens {
+					t.Errorf("point %d: got %+v, want %+v", i, p, want[i])
+				}
+			}
+
+			// The window is capped to the retention, and spans the previous hour
+			points, err = r.History(context.Background(), "key", 24*time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(points) != 60 || !points[0].Time.Equal(minute.Add(-59*time.Minute)) {
+				t.Fatalf("capped window: got %d points from %s, want 60 from %s", len(points), points[0].Time, minute.Add(-59*time.Minute))
+			}
+			var allowed int64
+			for _, p := range points {
+				allowed += p.Allowed
+			}
+			if p := points[9]; !p.Time.Equal(minute.Add(-50*time.Minute)) || p.Allowed != 1 || allowed != 4 {
+				t.Errorf("capped window: got %+v and %d allowed, want 1 allowed 50 minutes ago and 4 in total", p, allowed)
+			}
+
+			if _, err := r.History(context.Background(), "key", 0); err == nil {
+				t.Error("window 0: expected an error")
+			}
+		})
+	}
+}
+
+func TestMemoryStoreRetention(t *testing.T) {
+	now := time.Unix(1700000000, 0).Truncate(time.Minute)
+	s := NewMemoryStore(time.Hour)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	old := now.Add(-30 * time.Minute)
+	s.Add(ctx, map[string][]Point{"key": {{Time: old, Allowed: 1}}, "gone": {{Time: old, Allowed: 1}}})
+
+	// The next flush drops the minutes older than the retention
+	now = now.Add(31 * time.Minute)
+	s.Add(ctx, map[string][]Point{"key": {{Time: now, Allowed: 2}}})
+
+	points, _ := s.Range(ctx, "key", old.Add(-time.Hour), now.Add(time.Minute))
+	if len(points) != 1 || !points[0].Time.Equal(now) {
+		t.Errorf("got %+v, want the latest minute only", points)
+	}
+	if _, ok := s.keys["gone"]; ok {
+		t.Error("a key without recent minutes is still kept")
+	}
+}
+
+func TestRedisStoreRetention(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	backends, mr := stores(t, &now)
+	s := backends["redis"]
+	ctx := context.Background()
+
+	if err := s.Add(ctx, map[string][]Point{"key": {{Time: now.Truncate(time.Minute), Allowed: 1, Tokens: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	hk := hourKey("key", now.Truncate(time.Hour).Unix())
+	if ttl := mr.TTL(hk); ttl != 2*time.Hour {
+		t.Fatalf("TTL of %s: got %s, want the retention plus an hour", hk, ttl)
+	}
+
+	mr.FastForward(2 * time.Hour)
+	points, err := s.Range(ctx, "key", now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil || len(points) != 0 {
+		t.Errorf("after the retention: got %+v, %v, want no point", points, err)
+	}
+}
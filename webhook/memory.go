@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryQueue keeps the deliveries in the current process, for the memory backend. Pending
+// deliveries are lost on restart
+type MemoryQueue struct {
+	mu         sync.Mutex
+	deliveries map[string]*memoryDelivery
+	dead       []Delivery // newest first
+}
+
+type memoryDelivery struct {
+	Delivery
+	due time.Time
+}
+
+// NewMemoryQueue creates an empty queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{deliveries: make(map[string]*memoryDelivery)}
+}
+
+// Enqueue adds d, due at
+func (q *MemoryQueue) Enqueue(_ context.Context, d Delivery, at time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.deliveries[d.ID] = &memoryDelivery{Delivery: d, due: at}
+	return nil
+}
+
+// Claim returns the delivery due first, if it is due at now
+func (q *MemoryQueue) Claim(_ context.Context, now, leaseUntil time.Time) (*Delivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var first *memoryDelivery
+	for _, md := range q.deliveries {
+		if !md.due.After(now) && (first == nil || md.due.Before(first.due)) {
+			first = md
+		}
+	}
+	if first == nil {
+		return nil, nil
+	}
+	first.due = leaseUntil
+	d := first.Delivery
+	return &d, nil
+}
+
+// Ack removes the delivery
+func (q *MemoryQueue) Ack(_ context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.deliveries, id)
+	return nil
+}
+
+// Retry increments the attempts of d and reschedules it
+func (q *MemoryQueue) Retry(_ context.Context, d Delivery, at time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	d.Attempts++
+	q.deliveries[d.ID] = &memoryDelivery{Delivery: d, due: at}
+	return nil
+}
+
+// Dead removes d and keeps it in the dead letters
+func (q *MemoryQueue) Dead(_ context.Context, d Delivery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.deliveries, d.ID)
+	d.Attempts++
+	q.dead = append([]Delivery{d}, q.dead...)
+	if len(q.dead) > maxDead {
+		q.dead = q.dead[:maxDead]
+	}
+	return nil
+}
+
+// MemoryDedup deduplicates events in the current process
+type MemoryDedup struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// NewMemoryDedup creates an empty Dedup
+func NewMemoryDedup() *MemoryDedup {
+	return &MemoryDedup{expires: make(map[string]time.Time)}
+}
+
+// Acquire reports whether name is not acquired or expired, and acquires it for ttl
+func (d *MemoryDedup) Acquire(_ context.Context, name string, ttl time.Duration) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if expiry, ok := d.expires[name]; ok && now.Before(expiry) {
+		return false, nil
+	}
+	if len(d.expires) >= maxSeen {
+		for k, expiry := range d.expires {
+			if !now.Before(expiry) {
+				delete(d.expires, k)
+			}
+		}
+	}
+	d.expires[name] = now.Add(ttl)
+	return true, nil
+}
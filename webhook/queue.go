@@ -0,0 +1,180 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxDead bounds the deliveries kept after their last attempt failed
+const maxDead = 1000
+
+// Delivery is an event to post to one endpoint
+type Delivery struct {
+	ID       string          `json:"id"`
+	Event    string          `json:"event"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+	Attempts int             `json:"attempts"` // Failed attempts so far
+}
+
+// Queue holds the deliveries until they succeed or run out of attempts. A claimed delivery is
+// hidden for a lease, so a delivery claimed by an instance that dies is claimed again afterwards
+type Queue interface {
+	// Enqueue adds d, due at
+	Enqueue(ctx context.Context, d Delivery, at time.Time) error
+
+	// Claim returns a delivery due at now and hides it until leaseUntil, or nil if none is due
+	Claim(ctx context.Context, now, leaseUntil time.Time) (*Delivery, error)
+
+	// Ack removes a delivered delivery
+	Ack(ctx context.Context, id string) error
+
+	// Retry records a failed attempt of d and makes it due at
+	Retry(ctx context.Context, d Delivery, at time.Time) error
+
+	// Dead removes d after its last attempt and keeps it in the dead letters
+	Dead(ctx context.Context, d Delivery) error
+}
+
+// Redis keys of the queue. They share the {webhook} hash tag so the claim script runs on a cluster
+const (
+	queueKey       = "{webhook}:queue" // sorted set of delivery IDs scored by due time in milliseconds
+	deadKey        = "{webhook}:dead"  // list of the JSON encoded dead deliveries, newest first
+	deliveryKey    = "{webhook}:delivery:"
+	dedupKeyPrefix = "{webhook}:dedup:"
+)
+
+// claimScript pops the first due delivery ID and pushes its due time to the end of the lease
+var claimScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #ids == 0 then
+	return false
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ids[1])
+return ids[1]
+`)
+
+// RedisQueue is a Queue persisted in Redis and shared by all instances. Deliveries are hashes,
+// {webhook}:delivery:<id>, scheduled in the {webhook}:queue sorted set
+type RedisQueue struct {
+	client redis.Cmdable
+}
+
+// NewRedisQueue creates a queue on client
+func NewRedisQueue(client redis.Cmdable) *RedisQueue {
+	return &RedisQueue{client: client}
+}
+
+// Enqueue stores d and schedules it in one transaction
+func (q *RedisQueue) Enqueue(ctx context.Context, d Delivery, at time.Time) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, deliveryKey+d.ID, "event", d.Event, "url", d.URL, "body", string(d.Body), "attempts", d.Attempts)
+		pipe.ZAdd(ctx, queueKey, redis.Z{Score: float64(at.UnixMilli()), Member: d.ID})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// Claim claims the first due delivery
+func (q *RedisQueue) Claim(ctx context.Context, now, leaseUntil time.Time) (*Delivery, error) {
+	for {
+		id, err := claimScript.Run(ctx, q.client, []string{queueKey}, now.UnixMilli(), leaseUntil.UnixMilli()).Text()
+		if err == redis.Nil {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim webhook delivery: %w", err)
+		}
+
+		fields, err := q.client.HGetAll(ctx, deliveryKey+id).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+		}
+		if len(fields) == 0 {
+			// Acked by another instance after its lease expired
+			q.client.ZRem(ctx, queueKey, id)
+			continue
+		}
+		attempts, _ := strconv.Atoi(fields["attempts"])
+		return &Delivery{
+			ID:       id,
+			Event:    fields["event"],
+			URL:      fields["url"],
+			Body:     json.RawMessage(fields["body"]),
+			Attempts: attempts,
+		}, nil
+	}
+}
+
+// Ack removes the delivery
+func (q *RedisQueue) Ack(ctx context.Context, id string) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, queueKey, id)
+		pipe.Del(ctx, deliveryKey+id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ack webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// Retry increments the attempts of d and reschedules it
+func (q *RedisQueue) Retry(ctx context.Context, d Delivery, at time.Time) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, deliveryKey+d.ID, "attempts", d.Attempts+1)
+		pipe.ZAdd(ctx, queueKey, redis.Z{Score: float64(at.UnixMilli()), Member: d.ID})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reschedule webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// Dead moves d to the {webhook}:dead list, which keeps the latest deliveries
+func (q *RedisQueue) Dead(ctx context.Context, d Delivery) error {
+	d.Attempts++
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook delivery: %w", err)
+	}
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, queueKey, d.ID)
+		pipe.Del(ctx, deliveryKey+d.ID)
+		pipe.LPush(ctx, deadKey, data)
+		pipe.LTrim(ctx, deadKey, 0, maxDead-1)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move webhook delivery to dead letters: %w", err)
+	}
+	return nil
+}
+
+// RedisDedup deduplicates events across instances with SET NX keys expiring after the window
+type RedisDedup struct {
+	client redis.Cmdable
+}
+
+// NewRedisDedup creates a Dedup on client
+func NewRedisDedup(client redis.Cmdable) *RedisDedup {
+	return &RedisDedup{client: client}
+}
+
+// Acquire sets {webhook}:dedup:<name> if it does not exist
+func (d *RedisDedup) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	ok, err := d.client.SetNX(ctx, dedupKeyPrefix+name, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to deduplicate webhook event: %w", err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedisQueue(t *testing.T) (*miniredis.Miniredis, *RedisQueue, *RedisDedup) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, NewRedisQueue(client), NewRedisDedup(client)
+}
+
+func TestRedisQueueLease(t *testing.T) {
+	_, q, _ := newRedisQueue(t)
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	lease := now.Add(time.Minute)
+
+	d := Delivery{ID: "a", Event: EventThrottled, URL: "http://example.com", Body: json.RawMessage(`{"id":"e"}`)}
+	if err := q.Enqueue(ctx, d, now); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := q.Claim(ctx, now.Add(-time.Millisecond), lease); err != nil || got != nil {
+		t.Fatalf("claim before due: got %+v, %v, want nil", got, err)
+	}
+
+	got, err := q.Claim(ctx, now, lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.ID != d.ID || got.URL != d.URL || string(got.Body) != string(d.Body) {
+		t.Fatalf("claim: got %+v, want %+v", got, d)
+	}
+	if got, err := q.Claim(ctx, lease.Add(-time.Millisecond), lease.Add(time.Minute)); err != nil || got != nil {
+		t.Fatalf("claim during the lease: got %+v, %v, want nil", got, err)
+	}
+
+	// The instance holding the lease died, the delivery is claimed again once it expires
+	got, err = q.Claim(ctx, lease, lease.Add(time.Minute))
+	if err != nil || got == nil || got.ID != d.ID {
+		t.Fatalf("claim after the lease: got %+v, %v, want the delivery again", got, err)
+	}
+}
+
+func TestRedisQueueRetryAckDead(t *testing.T) {
+	mr, q, _ := newRedisQueue(t)
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	for _, id := range []string{"a", "b"} {
+		if err := q.Enqueue(ctx, Delivery{ID: id, URL: "http://example.com", Body: json.RawMessage(`{}`)}, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, _ := q.Claim(ctx, now, now.Add(time.Minute))
+	if err := q.Retry(ctx, *a, now.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := q.Claim(ctx, now, now.Add(time.Minute))
+	if b == nil || b.ID != "b" {
+		t.Fatalf("got %+v, want b, a is not due before its retry", b)
+	}
+	a, _ = q.Claim(ctx, now.Add(time.Second), now.Add(time.Minute))
+	if a == nil || a.ID != "a" || a.Attempts != 1 {
+		t.Fatalf("retried delivery: got %+v, want a after 1 attempt", a)
+	}
+
+	if err := q.Ack(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Dead(ctx, *a); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists(queueKey) || mr.Exists(deliveryKey+"a") || mr.Exists(deliveryKey+"b") {
+		t.Error("acked and dead deliveries are still queued")
+	}
+	dead, err := mr.List(deadKey)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("dead letters: got %v, %v, want 1", dead, err)
+	}
+	var d Delivery
+	if err := json.Unmarshal([]byte(dead[0]), &d); err != nil || d.ID != "a" || d.Attempts != 2 {
+		t.Errorf("dead letter: got %+v, %v, want a after 2 attempts", d, err)
+	}
+}
+
+func TestRedisQueueSkipsAckedDeliveries(t *testing.T) {
+	mr, q, _ := newRedisQueue(t)
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	q.Enqueue(ctx, Delivery{ID: "acked"}, now)
+	q.Enqueue(ctx, Delivery{ID: "pending"}, now.Add(time.Millisecond))
+	// Acked by another instance while its ID was requeued by an expired lease
+	mr.Del(deliveryKey + "acked")
+
+	got, err := q.Claim(ctx, now.Add(time.Second), now.Add(time.Minute))
+	if err != nil || got == nil || got.ID != "pending" {
+		t.Fatalf("got %+v, %v, want pending", got, err)
+	}
+	if members, _ := mr.ZMembers(queueKey); len(members) != 1 {
+		t.Errorf("queue: got %v, want the acked ID removed", members)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	mr, _, redisDedup := newRedisQueue(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		dedup  Dedup
+		ttl    time.Duration
+		expire func() // ends the window
+	}{
+		{name: "redis", dedup: redisDedup, ttl: time.Minute, expire: func() { mr.FastForward(time.Minute) }},
+		{name: "memory", dedup: NewMemoryDedup(), ttl: 10 * time.Millisecond, expire: func() { time.Sleep(20 * time.Millisecond) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range []bool{true, false} {
+				if first, err := tt.dedup.Acquire(ctx, "throttled:key", tt.ttl); err != nil || first != want {
+					t.Fatalf("acquire %d: got %v, %v, want %v", i, first, err, want)
+				}
+			}
+			if first, _ := tt.dedup.Acquire(ctx, "throttled:other", tt.ttl); !first {
+				t.Error("another name: got false, want true")
+			}
+			tt.expire()
+			if first, _ := tt.dedup.Acquire(ctx, "throttled:key", tt.ttl); !first {
+				t.Error("after the window: got false, want true")
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// pollInterval is how long a worker waits when no delivery is due
+const pollInterval = time.Second
+
+// Headers of a delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderID        = "X-Webhook-Id"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign returns the signature of body sent at timestamp (unix seconds): "sha256=" followed by the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with secret. Receivers should compare it in constant time
+// and reject old timestamps
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sender posts the due deliveries of a queue
+type sender struct {
+	queue     Queue
+	endpoints map[string]config.WebhookEndpoint
+	cfg       *config.WebhooksConfig
+	client    *http.Client
+}
+
+func newSender(queue Queue, endpoints map[string]config.WebhookEndpoint, cfg *config.WebhooksConfig) *sender {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &sender{
+		queue:     queue,
+		endpoints: endpoints,
+		cfg:       cfg,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// run delivers until stop is closed
+func (s *sender) run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		if s.next() {
+			continue
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// next claims and sends one delivery. It reports whether a delivery was due
+func (s *sender) next() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The lease outlasts the request, so the delivery is not sent twice concurrently
+	now := time.Now()
+	d, err := s.queue.Claim(ctx, now, now.Add(s.client.Timeout+time.Minute))
+	if err != nil {
+		logger.Error("Failed to claim webhook delivery", logger.ErrorField(err))
+		return false
+	}
+	if d == nil {
+		return false
+	}
+
+	ep, ok := s.endpoints[d.URL]
+	if !ok {
+		logger.Warn("Dropping webhook delivery to an endpoint no longer configured",
+			logger.String("url", d.URL),
+		)
+		if err := s.queue.Ack(ctx, d.ID); err != nil {
+			logger.Error("Failed to drop webhook delivery", logger.ErrorField(err))
+		}
+		return true
+	}
+
+	err = s.send(ep, d)
+	if err == nil {
+		if err := s.queue.Ack(ctx, d.ID); err != nil {
+			logger.Error("Failed to ack webhook delivery", logger.ErrorField(err))
+		}
+		return true
+	}
+
+	attempts := d.Attempts + 1
+	if attempts >= s.cfg.MaxAttempts {
+		logger.Error("Webhook delivery failed, giving up",
+			logger.String("url", d.URL),
+			logger.Int("attempts", attempts),
+			logger.ErrorField(err),
+		)
+		if err := s.queue.Dead(ctx, *d); err != nil {
+			logger.Error("Failed to move webhook delivery to dead letters", logger.ErrorField(err))
+		}
+		return true
+	}
+
+	backoff := s.backoff(attempts)
+	logger.Warn("Webhook delivery failed, retrying",
+		logger.String("url", d.URL),
+		logger.Int("attempts", attempts),
+		logger.Duration("retry_in", backoff),
+		logger.ErrorField(err),
+	)
+	if err := s.queue.Retry(ctx, *d, time.Now().Add(backoff)); err != nil {
+		logger.Error("Failed to reschedule webhook delivery", logger.ErrorField(err))
+	}
+	return true
+}
+
+// send posts d to ep. Any 2xx response is a success
+func (s *sender) send(ep config.WebhookEndpoint, d *Delivery) error {
+	req, err := http.NewRequest(http.MethodPost, ep.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "rate-limiter-webhook/1.0")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderID, d.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(ep.Secret, timestamp, d.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff returns the wait before the attempt following attempts failures: MinBackoff doubled
+// on each failure, capped to MaxBackoff, the upper half jittered so retries do not align
+func (s *sender) backoff(attempts int) time.Duration {
+	minBackoff, maxBackoff := s.cfg.MinBackoff, s.cfg.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = time.Second
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+
+	d := minBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,363 @@
+// Package webhook notifies HTTP endpoints of limit events: a key throttled for the first time in
+// a window, the quota of a key crossing a threshold, and rule changes.
+//
+// A Notifier observes the limiter, deduplicates the key events per key and window, and enqueues one
+// delivery per subscribed endpoint in a Queue. Workers post the deliveries signed with HMAC-SHA256
+// and retry failures with exponential backoff. With the Redis queue, pending deliveries survive
+// restarts and are shared by all instances
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+	"github.com/your-org/rate-limiter/logger"
+)
+
+// Event types
+const (
+	EventThrottled      = "key.throttled"       // A key is denied for the first time in the dedup window
+	EventQuotaThreshold = "key.quota_threshold" // The used share of a key's burst reaches a threshold
+	EventRuleChanged    = "rule.changed"        // A rule is set or deleted
+)
+
+// Events lists the event types endpoints can subscribe to
+var Events = []string{EventThrottled, EventQuotaThreshold, EventRuleChanged}
+
+const (
+	// eventBuffer bounds the events waiting to be enqueued, further events are dropped
+	eventBuffer = 1000
+	// maxSeen bounds the keys deduplicated locally before asking the shared Dedup
+	maxSeen = 100000
+)
+
+// Event is the JSON body posted to the endpoints
+type Event struct {
+	ID   string    `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data EventData `json:"data"`
+
+	dedup string // name deduplicating the event, empty for rule changes
+}
+
+// EventData describes the key or rule of an event
+type EventData struct {
+	Key       string  `json:"key"`
+	Rate      int64   `json:"rate,omitempty"`
+	Burst     int64   `json:"burst,omitempty"`
+	Remain    *int64  `json:"remain,omitempty"`    // Tokens left in the bucket, key events only
+	Threshold float64 `json:"threshold,omitempty"` // Threshold reached, key.quota_threshold only
+	Deleted   bool    `json:"deleted,omitempty"`   // The rule was deleted, rule.changed only
+}
+
+// Dedup remembers the events already notified
+type Dedup interface {
+	// Acquire reports whether name was not acquired in the last ttl, and acquires it
+	Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error)
+}
+
+// Notifier turns limiter decisions and rule changes into webhook deliveries
+type Notifier struct {
+	cfg        config.WebhooksConfig
+	endpoints  map[string]config.WebhookEndpoint // by URL
+	thresholds []float64                         // ascending
+	queue      Queue
+	dedup      Dedup
+	sender     *sender
+
+	mu      sync.Mutex
+	seen    map[string]time.Time // dedup name -> expiry, saves a round trip to dedup
+	dropped int64
+
+	events chan Event
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// NewNotifier validates cfg and starts the workers delivering from queue. Call Close to stop them
+func NewNotifier(queue Queue, dedup Dedup, cfg *config.WebhooksConfig) (*Notifier, error) {
+	n := &Notifier{
+		cfg:       *cfg,
+		endpoints: make(map[string]config.WebhookEndpoint, len(cfg.Endpoints)),
+		queue:     queue,
+		dedup:     dedup,
+		seen:      make(map[string]time.Time),
+		events:    make(chan Event, eventBuffer),
+		stop:      make(chan struct{}),
+	}
+
+	for _, ep := range cfg.Endpoints {
+		u, err := url.Parse(ep.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid webhook url %q", ep.URL)
+		}
+		if ep.Secret == "" {
+			return nil, fmt.Errorf("webhook %s: secret is required", ep.URL)
+		}
+		for _, e := range ep.Events {
+			if !validEvent(e) {
+				return nil, fmt.Errorf("webhook %s: unknown event %q", ep.URL, e)
+			}
+		}
+		if _, ok := n.endpoints[ep.URL]; ok {
+			return nil, fmt.Errorf("duplicate webhook url %q", ep.URL)
+		}
+		n.endpoints[ep.URL] = ep
+	}
+	for _, t := range cfg.Thresholds {
+		if t <= 0 || t > 1 {
+			return nil, fmt.Errorf("invalid webhook threshold %v, must be in (0, 1]", t)
+		}
+		n.thresholds = append(n.thresholds, t)
+	}
+	sort.Float64s(n.thresholds)
+
+	if n.cfg.DedupWindow <= 0 {
+		n.cfg.DedupWindow = 5 * time.Minute
+	}
+	if n.cfg.MaxAttempts <= 0 {
+		n.cfg.MaxAttempts = 1
+	}
+	if n.cfg.Workers <= 0 {
+		n.cfg.Workers = 1
+	}
+	n.sender = newSender(queue, n.endpoints, &n.cfg)
+
+	n.wg.Add(1)
+	go n.run()
+	for i := 0; i < n.cfg.Workers; i++ {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			n.sender.run(n.stop)
+		}()
+	}
+	return n, nil
+}
+
+func validEvent(e string) bool {
+	for _, known := range Events {
+		if e == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Observe detects throttled keys and crossed thresholds, it is a limiter.Observer.
+// Degraded decisions do not reflect the usage of the key and are ignored
+func (n *Notifier) Observe(_ context.Context, d limiter.Decision) {
+	if d.Degraded != "" {
+		return
+	}
+	if !d.Allowed {
+		n.notifyKey(EventThrottled, "throttled:"+d.Key, d, 0)
+	}
+
+	if d.Burst <= 0 || len(n.thresholds) == 0 {
+		return
+	}
+	used := float64(d.Burst-d.Remain) / float64(d.Burst)
+	// Only the highest threshold reached is notified
+	for i := len(n.thresholds) - 1; i >= 0; i-- {
+		t := n.thresholds[i]
+		if used >= t {
+			name := "threshold:" + strconv.FormatFloat(t, 'f', -1, 64) + ":" + d.Key
+			n.notifyKey(EventQuotaThreshold, name, d, t)
+			return
+		}
+	}
+}
+
+// ObserveRule notifies rule changes, it is a limiter.RuleObserver. Rule changes are not deduplicated
+func (n *Notifier) ObserveRule(_ context.Context, c limiter.RuleChange) {
+	n.enqueue(Event{
+		Type: EventRuleChanged,
+		Time: c.Time.UTC(),
+		Data: EventData{Key: c.Key, Rate: c.Rate, Burst: c.Burst, Deleted: c.Deleted},
+	})
+}
+
+func (n *Notifier) notifyKey(eventType, name string, d limiter.Decision, threshold float64) {
+	if !n.subscribed(eventType) || !n.firstLocally(name, d.Time) {
+		return
+	}
+	remain := d.Remain
+	n.enqueue(Event{
+		dedup: name,
+		Type:  eventType,
+		Time:  d.Time.UTC(),
+		Data: EventData{
+			Key:       d.Key,
+			Rate:      d.Rate,
+			Burst:     d.Burst,
+			Remain:    &remain,
+			Threshold: threshold,
+		},
+	})
+}
+
+// subscribed reports whether an endpoint subscribes to eventType
+func (n *Notifier) subscribed(eventType string) bool {
+	for _, ep := range n.endpoints {
+		if subscribes(ep, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+func subscribes(ep config.WebhookEndpoint, eventType string) bool {
+	if len(ep.Events) == 0 {
+		return true
+	}
+	for _, e := range ep.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// firstLocally reports whether name was not seen by this instance in the dedup window. The shared
+// Dedup still decides across instances, this only keeps a throttled key off the event queue
+func (n *Notifier) firstLocally(name string, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if expiry, ok := n.seen[name]; ok && now.Before(expiry) {
+		return false
+	}
+	if len(n.seen) >= maxSeen {
+		for k, expiry := range n.seen {
+			if !now.Before(expiry) {
+				delete(n.seen, k)
+			}
+		}
+		if len(n.seen) >= maxSeen {
+			n.seen = make(map[string]time.Time)
+		}
+	}
+	n.seen[name] = now.Add(n.cfg.DedupWindow)
+	return true
+}
+
+// enqueue hands ev to the dispatcher without blocking the request path
+func (n *Notifier) enqueue(ev Event) {
+	select {
+	case n.events <- ev:
+	default:
+		n.mu.Lock()
+		n.dropped++
+		n.mu.Unlock()
+	}
+}
+
+// Close stops accepting events, enqueues the buffered ones and waits for the in-flight deliveries.
+// Deliveries still in the Redis queue are sent after the next start
+func (n *Notifier) Close() {
+	n.once.Do(func() {
+		close(n.stop)
+		n.wg.Wait()
+	})
+}
+
+// run deduplicates events and enqueues one delivery per subscribed endpoint
+func (n *Notifier) run() {
+	defer n.wg.Done()
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev := <-n.events:
+			n.dispatch(ev)
+		case <-ticker.C:
+			n.logDropped()
+		case <-n.stop:
+			for {
+				select {
+				case ev := <-n.events:
+					n.dispatch(ev)
+				default:
+					n.logDropped()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (n *Notifier) logDropped() {
+	n.mu.Lock()
+	dropped := n.dropped
+	n.dropped = 0
+	n.mu.Unlock()
+
+	if dropped > 0 {
+		logger.Warn("Webhook events dropped, too many events waiting to be enqueued",
+			logger.Int64("dropped", dropped),
+		)
+	}
+}
+
+func (n *Notifier) dispatch(ev Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if ev.dedup != "" {
+		first, err := n.dedup.Acquire(ctx, ev.dedup, n.cfg.DedupWindow)
+		if err != nil {
+			// Notifying twice is better than not notifying
+			logger.Error("Failed to deduplicate webhook event",
+				logger.String("event", ev.Type),
+				logger.ErrorField(err),
+			)
+		} else if !first {
+			return
+		}
+	}
+
+	ev.ID = newID()
+	body, err := json.Marshal(ev)
+	if err != nil {
+		logger.Error("Failed to encode webhook event", logger.ErrorField(err))
+		return
+	}
+
+	for _, ep := range n.endpoints {
+		if !subscribes(ep, ev.Type) {
+			continue
+		}
+		d := Delivery{ID: newID(), Event: ev.Type, URL: ep.URL, Body: body}
+		if err := n.queue.Enqueue(ctx, d, time.Now()); err != nil {
+			logger.Error("Failed to enqueue webhook delivery",
+				logger.String("event", ev.Type),
+				logger.String("url", ep.URL),
+				logger.ErrorField(err),
+			)
+		}
+	}
+}
+
+// newID returns a random 128-bit hex ID
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/your-org/rate-limiter/config"
+	"github.com/your-org/rate-limiter/limiter"
+)
+
+func TestSign(t *testing.T) {
+	got := Sign("secret", 1700000000, []byte(`{"a":1}`))
+	want := "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if Sign("other", 1700000000, []byte(`{"a":1}`)) == want || Sign("secret", 1700000001, []byte(`{"a":1}`)) == want {
+		t.Error("the signature does not depend on the secret and timestamp")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	s := newSender(nil, nil, &config.WebhooksConfig{MinBackoff: time.Second, MaxBackoff: 8 * time.Second})
+
+	tests := []struct {
+		attempts int
+		max      time.Duration // the wait is jittered in [max/2, max]
+	}{
+		{attempts: 1, max: time.Second},
+		{attempts: 2, max: 2 * time.Second},
+		{attempts: 4, max: 8 * time.Second},
+		{attempts: 20, max: 8 * time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if got := s.backoff(tt.attempts); got < tt.max/2 || got > tt.max {
+				t.Fatalf("attempts %d: got %s, want in [%s, %s]", tt.attempts, got, tt.max/2, tt.max)
+			}
+		}
+	}
+}
+
+// recordingQueue records the enqueued deliveries and never hands them to the workers
+type recordingQueue struct {
+	*MemoryQueue
+	mu       sync.Mutex
+	enqueued []Delivery
+}
+
+func (q *recordingQueue) Enqueue(_ context.Context, d Delivery, _ time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.enqueued = append(q.enqueued, d)
+	return nil
+}
+
+func (q *recordingQueue) Claim(context.Context, time.Time, time.Time) (*Delivery, error) {
+	return nil, nil
+}
+
+func TestNotifierKeyEvents(t *testing.T) {
+	queue := &recordingQueue{MemoryQueue: NewMemoryQueue()}
+	n, err := NewNotifier(queue, NewMemoryDedup(), &config.WebhooksConfig{
+		Endpoints:  []config.WebhookEndpoint{{URL: "http://example.com/hook", Secret: "secret"}},
+		Thresholds: []float64{0.8, 0.5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	decision := func(allowed bool, remain int64) limiter.Decision {
+		return limiter.Decision{Key: "key", Rate: 1, Burst: 10, Remain: remain, Allowed: allowed, Time: now}
+	}
+	degraded := decision(false, 0)
+	degraded.Degraded = limiter.FailClosed
+	for _, d := range []limiter.Decision{
+		decision(true, 6), // 40% used
+		decision(true, 5), // crosses 0.5
+		decision(true, 4),
+		decision(true, 2), // crosses 0.8
+		decision(true, 0),
+		degraded,           // ignored
+		decision(false, 0), // first throttle
+		decision(false, 0),
+	} {
+		n.Observe(context.Background(), d)
+	}
+	n.Close()
+
+	type event struct {
+		typ       string
+		threshold float64
+	}
+	want := []event{{EventQuotaThreshold, 0.5}, {EventQuotaThreshold, 0.8}, {EventThrottled, 0}}
+	if len(queue.enqueued) != len(want) {
+		t.Fatalf("got %d deliveries, want %d", len(queue.enqueued), len(want))
+	}
+	for i, d := range queue.enqueued {
+		var ev Event
+		if err := json.Unmarshal(d.Body, &ev); err != nil {
+			t.Fatal(err)
+		}
+		if got := (event{ev.Type, ev.Data.Threshold}); got != want[i] || d.Event != ev.Type || ev.Data.Key != "key" {
+			t.Errorf("delivery %d: got %+v, want %+v", i, ev, want[i])
+		}
+	}
+}
+
+func TestNotifierRetriesSignedDeliveries(t *testing.T) {
+	type request struct {
+		id, timestamp, signature string
+		body                     []byte
+	}
+	requests := make(chan request, 10)
+	var mu sync.Mutex
+	failures := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{
+			id:        r.Header.Get(HeaderID),
+			timestamp: r.Header.Get(HeaderTimestamp),
+			signature: r.Header.Get(HeaderSignature),
+			body:      body,
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	n, err := NewNotifier(NewMemoryQueue(), NewMemoryDedup(), &config.WebhooksConfig{
+		Endpoints:   []config.WebhookEndpoint{{URL: srv.URL, Secret: "secret", Events: []string{EventRuleChanged}}},
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+	n.ObserveRule(context.Background(), limiter.RuleChange{Key: "key", Rate: 1, Burst: 10, Time: time.Now()})
+
+	var got []request
+	for len(got) < 2 {
+		select {
+		case r := <-requests:
+			got = append(got, r)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d requests, want the failed delivery and its retry", len(got))
+		}
+	}
+	if got[0].id != got[1].id || string(got[0].body) != string(got[1].body) {
+		t.Errorf("the retry is not the failed delivery: %+v, %+v", got[0], got[1])
+	}
+	for i, r := range got {
+		timestamp, err := strconv.ParseInt(r.timestamp, 10, 64)
+		if err != nil {
+			t.Fatalf("request %d: timestamp %q", i, r.timestamp)
+		}
+		if want := Sign("secret", timestamp, r.body); r.signature != want {
+			t.Errorf("request %d: got signature %s, want %s", i, r.signature, want)
+		}
+	}
+	select {
+	case r := <-requests:
+		t.Errorf("unexpected request after the successful retry: %+v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+}